@@ -0,0 +1,47 @@
+package xone
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatAmount formats an amount of cents with two decimal places, e.g.
+// "12.50".
+func FormatAmount(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// ParseAmount parses an amount with at most two decimal places and returns
+// it in cents. Both "." and "," are accepted as decimal separator.
+func ParseAmount(s string) (int64, error) {
+	s = strings.TrimSpace(strings.Replace(s, ",", ".", 1))
+
+	negative := strings.HasPrefix(s, "-")
+	whole, frac := strings.TrimPrefix(s, "-"), ""
+	if i := strings.Index(whole, "."); i >= 0 {
+		whole, frac = whole[:i], whole[i+1:]
+	}
+	if whole == "" || len(frac) > 2 || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	cents, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || cents < 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		cents = -cents
+	}
+
+	return cents, nil
+}
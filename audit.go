@@ -0,0 +1,64 @@
+package xone
+
+import (
+	"context"
+	"time"
+)
+
+// AuditService provides access to the audit log, which records who changed
+// what and why.
+type AuditService interface {
+	// FindAuditEntries returns all entries of the given entity, e.g. the
+	// person with a certain PID, the oldest first.
+	FindAuditEntries(context.Context, AuditEntity, string) ([]AuditEntry, error)
+}
+
+// AuditOperation is the kind of change recorded in the audit log.
+type AuditOperation string
+
+const (
+	AuditCreate AuditOperation = "create"
+	AuditUpdate AuditOperation = "update"
+	AuditDelete AuditOperation = "delete"
+)
+
+// AuditEntity is the kind of entity which has been changed.
+type AuditEntity string
+
+const (
+	AuditPerson         AuditEntity = "person"
+	AuditMembership     AuditEntity = "membership"
+	AuditMembershipType AuditEntity = "membership_type"
+	AuditUser           AuditEntity = "user"
+	AuditFee            AuditEntity = "fee"
+	AuditLedgerEntry    AuditEntity = "ledger_entry"
+	AuditMandate        AuditEntity = "mandate"
+	AuditInvoice        AuditEntity = "invoice"
+	AuditHousehold      AuditEntity = "household"
+	AuditField          AuditEntity = "field"
+)
+
+// AuditEntry records a single change.
+type AuditEntry struct {
+	ID   int
+	Time time.Time
+
+	// ActorID and ActorEmail identify the user who made the change. They are
+	// empty if the change was not made on behalf of a user, e.g. by the
+	// command line tool.
+	ActorID    int
+	ActorEmail string
+
+	Operation AuditOperation
+	Entity    AuditEntity
+
+	// EntityID is the PID of a person and the ID of every other entity.
+	EntityID string
+
+	// Before and After contain the entity as JSON before and after the change.
+	// Before is empty for created entities, After for deleted ones.
+	Before string
+	After  string
+
+	Reason string
+}
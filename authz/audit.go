@@ -0,0 +1,25 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.AuditService = (*AuditService)(nil)
+
+type AuditService struct {
+	service xone.AuditService
+}
+
+func NewAuditService(service xone.AuditService) *AuditService {
+	return &AuditService{service: service}
+}
+
+func (s *AuditService) FindAuditEntries(ctx context.Context, entity xone.AuditEntity, id string) ([]xone.AuditEntry, error) {
+	if err := require(ctx, xone.PermissionReadAuditLog); err != nil {
+		return nil, err
+	}
+
+	return s.service.FindAuditEntries(ctx, entity, id)
+}
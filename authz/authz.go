@@ -0,0 +1,22 @@
+// Package authz wraps the xone services with permission checks. The user whose
+// permissions are checked is taken from the context, see
+// xone.NewContextWithUser. Operations without a user in the context are always
+// forbidden.
+package authz
+
+import (
+	"context"
+
+	"github.com/stillwondering/xone"
+)
+
+// require returns *xone.ErrForbidden unless the user stored in ctx has been
+// granted the permission.
+func require(ctx context.Context, p xone.Permission) error {
+	user, ok := xone.UserFromContext(ctx)
+	if !ok || !user.Can(p) {
+		return &xone.ErrForbidden{Permission: p}
+	}
+
+	return nil
+}
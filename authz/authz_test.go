@@ -0,0 +1,215 @@
+package authz_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/authz"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func TestPersonRepository(t *testing.T) {
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	repo := authz.NewPersonRepository(sqlite.NewPersonService(db))
+	membershipService := authz.NewMembershipService(sqlite.NewMembershipService(db))
+	historyService := authz.NewHistoryService(sqlite.NewHistoryService(db))
+	auditService := authz.NewAuditService(sqlite.NewAuditService(db))
+	feeService := authz.NewFeeService(sqlite.NewFeeService(db))
+	paymentService := authz.NewPaymentService(sqlite.NewPaymentService(db))
+	mandateService := authz.NewMandateService(sqlite.NewMandateService(db))
+	invoiceService := authz.NewInvoiceService(sqlite.NewInvoiceService(db))
+	householdService := authz.NewHouseholdService(sqlite.NewHouseholdService(db))
+	fieldService := authz.NewFieldService(sqlite.NewFieldService(db))
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		wantForbidden map[string]bool
+	}{
+		{
+			name: "No user",
+			ctx:  context.Background(),
+			wantForbidden: map[string]bool{
+				"FindAll":               true,
+				"FindMembership":        true,
+				"FindMany":              true,
+				"Timeline":              true,
+				"FindAuditEntries":      true,
+				"Purge":                 true,
+				"Delete":                true,
+				"Patch":                 true,
+				"CreateMembershipType":  true,
+				"ReinstateMembership":   true,
+				"CreateFee":             true,
+				"FindArrears":           true,
+				"FindDirectDebits":      true,
+				"CreateInvoice":         true,
+				"CreateHousehold":       true,
+				"CreateFieldDefinition": true,
+			},
+		},
+		{
+			name: "Read-only user",
+			ctx:  xone.NewContextWithUser(context.Background(), xone.User{Role: xone.RoleReadOnly}),
+			wantForbidden: map[string]bool{
+				"FindAll":               false,
+				"FindMembership":        false,
+				"FindMany":              false,
+				"Timeline":              false,
+				"FindAuditEntries":      true,
+				"Purge":                 true,
+				"Delete":                true,
+				"Patch":                 true,
+				"CreateMembershipType":  true,
+				"ReinstateMembership":   true,
+				"CreateFee":             true,
+				"FindArrears":           true,
+				"FindDirectDebits":      true,
+				"CreateInvoice":         true,
+				"CreateHousehold":       true,
+				"CreateFieldDefinition": true,
+			},
+		},
+		{
+			name: "Treasurer",
+			ctx:  xone.NewContextWithUser(context.Background(), xone.User{Role: xone.RoleTreasurer}),
+			wantForbidden: map[string]bool{
+				"FindAll":               false,
+				"FindMembership":        false,
+				"FindMany":              false,
+				"Timeline":              false,
+				"FindAuditEntries":      true,
+				"Purge":                 true,
+				"Delete":                true,
+				"Patch":                 false,
+				"CreateMembershipType":  true,
+				"ReinstateMembership":   true,
+				"CreateFee":             false,
+				"FindArrears":           false,
+				"FindDirectDebits":      false,
+				"CreateInvoice":         false,
+				"CreateHousehold":       false,
+				"CreateFieldDefinition": true,
+			},
+		},
+		{
+			name: "Board member",
+			ctx:  xone.NewContextWithUser(context.Background(), xone.User{Role: xone.RoleBoard}),
+			wantForbidden: map[string]bool{
+				"FindAll":               false,
+				"FindMembership":        false,
+				"FindMany":              false,
+				"Timeline":              false,
+				"FindAuditEntries":      true,
+				"Purge":                 true,
+				"Delete":                false,
+				"Patch":                 false,
+				"CreateMembershipType":  false,
+				"ReinstateMembership":   false,
+				"CreateFee":             true,
+				"FindArrears":           true,
+				"FindDirectDebits":      true,
+				"CreateInvoice":         true,
+				"CreateHousehold":       false,
+				"CreateFieldDefinition": true,
+			},
+		},
+		{
+			name: "Admin",
+			ctx:  xone.NewContextWithUser(context.Background(), xone.User{Role: xone.RoleAdmin}),
+			wantForbidden: map[string]bool{
+				"FindAll":               false,
+				"FindMembership":        false,
+				"FindMany":              false,
+				"Timeline":              false,
+				"FindAuditEntries":      false,
+				"Purge":                 false,
+				"Delete":                false,
+				"Patch":                 false,
+				"CreateMembershipType":  false,
+				"ReinstateMembership":   false,
+				"CreateFee":             false,
+				"FindArrears":           false,
+				"FindDirectDebits":      false,
+				"CreateInvoice":         false,
+				"CreateHousehold":       false,
+				"CreateFieldDefinition": false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := map[string]error{}
+			_, errs["FindAll"] = repo.FindAll(tt.ctx)
+			_, _, errs["FindMany"] = repo.FindMany(tt.ctx, xone.PersonFilter{})
+			_, _, errs["FindMembership"] = membershipService.FindMembership(tt.ctx, 0)
+			_, errs["Timeline"] = historyService.Timeline(tt.ctx, "unknown")
+			_, errs["Purge"] = repo.Purge(tt.ctx)
+			_, errs["FindAuditEntries"] = auditService.FindAuditEntries(tt.ctx, xone.AuditPerson, "unknown")
+			errs["Delete"] = repo.Delete(tt.ctx, "unknown")
+			errs["Patch"] = repo.Patch(tt.ctx, "unknown", xone.PatchPersonData{})
+			_, errs["CreateMembershipType"] = membershipService.CreateMembershipType(tt.ctx, tt.name)
+			errs["ReinstateMembership"] = membershipService.ReinstateMembership(tt.ctx, 0)
+			_, errs["CreateFee"] = feeService.CreateFee(tt.ctx, xone.CreateFeeData{})
+			_, errs["FindArrears"] = paymentService.FindArrears(tt.ctx, time.Now())
+			_, errs["FindDirectDebits"] = mandateService.FindDirectDebits(tt.ctx, time.Now())
+			_, errs["CreateInvoice"] = invoiceService.CreateInvoice(tt.ctx, xone.CreateInvoiceData{})
+			_, errs["CreateHousehold"] = householdService.CreateHousehold(tt.ctx, xone.CreateHouseholdData{})
+			_, errs["CreateFieldDefinition"] = fieldService.CreateFieldDefinition(tt.ctx, xone.CreateFieldDefinitionData{})
+
+			for op, wantForbidden := range tt.wantForbidden {
+				var e *xone.ErrForbidden
+				if got := errors.As(errs[op], &e); got != wantForbidden {
+					t.Errorf("%s() err = %v, wantForbidden %v", op, errs[op], wantForbidden)
+				}
+			}
+		})
+	}
+}
+
+func TestMembershipService_FindAllMembershipTypes(t *testing.T) {
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqlite.NewFeeService(db).CreateFee(ctx, xone.CreateFeeData{MembershipTypeID: mt.ID, Amount: 12000, Period: xone.BillingAnnual, ValidFrom: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+
+	membershipService := authz.NewMembershipService(sqlite.NewMembershipService(db))
+	tests := []struct {
+		role     xone.Role
+		wantFees bool
+	}{
+		{role: xone.RoleReadOnly, wantFees: false},
+		{role: xone.RoleBoard, wantFees: false},
+		{role: xone.RoleTreasurer, wantFees: true},
+		{role: xone.RoleAdmin, wantFees: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			membershipTypes, err := membershipService.FindAllMembershipTypes(xone.NewContextWithUser(ctx, xone.User{Role: tt.role}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(membershipTypes[0].Fees) > 0; got != tt.wantFees {
+				t.Errorf("FindAllMembershipTypes() fees = %v, wantFees %v", membershipTypes[0].Fees, tt.wantFees)
+			}
+		})
+	}
+}
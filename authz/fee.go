@@ -0,0 +1,50 @@
+package authz
+
+import (
+	"context"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.FeeService = (*FeeService)(nil)
+
+type FeeService struct {
+	service xone.FeeService
+}
+
+func NewFeeService(service xone.FeeService) *FeeService {
+	return &FeeService{service: service}
+}
+
+func (s *FeeService) FindFees(ctx context.Context, membershipTypeID int) ([]xone.Fee, error) {
+	if err := require(ctx, xone.PermissionReadFees); err != nil {
+		return nil, err
+	}
+
+	return s.service.FindFees(ctx, membershipTypeID)
+}
+
+func (s *FeeService) CreateFee(ctx context.Context, data xone.CreateFeeData) (xone.Fee, error) {
+	if err := require(ctx, xone.PermissionWriteFees); err != nil {
+		return xone.Fee{}, err
+	}
+
+	return s.service.CreateFee(ctx, data)
+}
+
+func (s *FeeService) DeleteFee(ctx context.Context, id int) error {
+	if err := require(ctx, xone.PermissionWriteFees); err != nil {
+		return err
+	}
+
+	return s.service.DeleteFee(ctx, id)
+}
+
+func (s *FeeService) CalculateDues(ctx context.Context, pid string, from, until time.Time) (xone.Dues, error) {
+	if err := require(ctx, xone.PermissionReadFees); err != nil {
+		return xone.Dues{}, err
+	}
+
+	return s.service.CalculateDues(ctx, pid, from, until)
+}
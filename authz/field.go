@@ -0,0 +1,41 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.FieldService = (*FieldService)(nil)
+
+type FieldService struct {
+	service xone.FieldService
+}
+
+func NewFieldService(service xone.FieldService) *FieldService {
+	return &FieldService{service: service}
+}
+
+func (s *FieldService) FindFieldDefinitions(ctx context.Context) ([]xone.FieldDefinition, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return nil, err
+	}
+
+	return s.service.FindFieldDefinitions(ctx)
+}
+
+func (s *FieldService) CreateFieldDefinition(ctx context.Context, data xone.CreateFieldDefinitionData) (xone.FieldDefinition, error) {
+	if err := require(ctx, xone.PermissionManageFields); err != nil {
+		return xone.FieldDefinition{}, err
+	}
+
+	return s.service.CreateFieldDefinition(ctx, data)
+}
+
+func (s *FieldService) DeleteFieldDefinition(ctx context.Context, id int) error {
+	if err := require(ctx, xone.PermissionManageFields); err != nil {
+		return err
+	}
+
+	return s.service.DeleteFieldDefinition(ctx, id)
+}
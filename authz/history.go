@@ -0,0 +1,34 @@
+package authz
+
+import (
+	"context"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.HistoryService = (*HistoryService)(nil)
+
+type HistoryService struct {
+	service xone.HistoryService
+}
+
+func NewHistoryService(service xone.HistoryService) *HistoryService {
+	return &HistoryService{service: service}
+}
+
+func (s *HistoryService) Timeline(ctx context.Context, pid string) ([]xone.PersonChange, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return nil, err
+	}
+
+	return s.service.Timeline(ctx, pid)
+}
+
+func (s *HistoryService) PersonAt(ctx context.Context, pid string, t time.Time) (xone.Person, bool, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return xone.Person{}, false, err
+	}
+
+	return s.service.PersonAt(ctx, pid, t)
+}
@@ -0,0 +1,81 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.HouseholdService = (*HouseholdService)(nil)
+
+type HouseholdService struct {
+	service xone.HouseholdService
+}
+
+func NewHouseholdService(service xone.HouseholdService) *HouseholdService {
+	return &HouseholdService{service: service}
+}
+
+func (s *HouseholdService) FindHouseholds(ctx context.Context) ([]xone.Household, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return nil, err
+	}
+
+	return s.service.FindHouseholds(ctx)
+}
+
+func (s *HouseholdService) FindHousehold(ctx context.Context, id int) (xone.Household, bool, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return xone.Household{}, false, err
+	}
+
+	return s.service.FindHousehold(ctx, id)
+}
+
+func (s *HouseholdService) FindHouseholdOfPerson(ctx context.Context, pid string) (xone.Household, bool, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return xone.Household{}, false, err
+	}
+
+	return s.service.FindHouseholdOfPerson(ctx, pid)
+}
+
+func (s *HouseholdService) CreateHousehold(ctx context.Context, data xone.CreateHouseholdData) (xone.Household, error) {
+	if err := require(ctx, xone.PermissionWritePersons); err != nil {
+		return xone.Household{}, err
+	}
+
+	return s.service.CreateHousehold(ctx, data)
+}
+
+func (s *HouseholdService) UpdateHousehold(ctx context.Context, id int, data xone.UpdateHouseholdData) error {
+	if err := require(ctx, xone.PermissionWritePersons); err != nil {
+		return err
+	}
+
+	return s.service.UpdateHousehold(ctx, id, data)
+}
+
+func (s *HouseholdService) DeleteHousehold(ctx context.Context, id int) error {
+	if err := require(ctx, xone.PermissionWritePersons); err != nil {
+		return err
+	}
+
+	return s.service.DeleteHousehold(ctx, id)
+}
+
+func (s *HouseholdService) AddHouseholdMember(ctx context.Context, id int, pid string) error {
+	if err := require(ctx, xone.PermissionWritePersons); err != nil {
+		return err
+	}
+
+	return s.service.AddHouseholdMember(ctx, id, pid)
+}
+
+func (s *HouseholdService) RemoveHouseholdMember(ctx context.Context, id int, pid string) error {
+	if err := require(ctx, xone.PermissionWritePersons); err != nil {
+		return err
+	}
+
+	return s.service.RemoveHouseholdMember(ctx, id, pid)
+}
@@ -0,0 +1,41 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.InvoiceService = (*InvoiceService)(nil)
+
+type InvoiceService struct {
+	service xone.InvoiceService
+}
+
+func NewInvoiceService(service xone.InvoiceService) *InvoiceService {
+	return &InvoiceService{service: service}
+}
+
+func (s *InvoiceService) FindInvoices(ctx context.Context, pid string) ([]xone.Invoice, error) {
+	if err := require(ctx, xone.PermissionReadPayments); err != nil {
+		return nil, err
+	}
+
+	return s.service.FindInvoices(ctx, pid)
+}
+
+func (s *InvoiceService) FindInvoice(ctx context.Context, number string) (xone.Invoice, bool, error) {
+	if err := require(ctx, xone.PermissionReadPayments); err != nil {
+		return xone.Invoice{}, false, err
+	}
+
+	return s.service.FindInvoice(ctx, number)
+}
+
+func (s *InvoiceService) CreateInvoice(ctx context.Context, data xone.CreateInvoiceData) (xone.Invoice, error) {
+	if err := require(ctx, xone.PermissionWritePayments); err != nil {
+		return xone.Invoice{}, err
+	}
+
+	return s.service.CreateInvoice(ctx, data)
+}
@@ -0,0 +1,58 @@
+package authz
+
+import (
+	"context"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.MandateService = (*MandateService)(nil)
+
+type MandateService struct {
+	service xone.MandateService
+}
+
+func NewMandateService(service xone.MandateService) *MandateService {
+	return &MandateService{service: service}
+}
+
+func (s *MandateService) FindMandate(ctx context.Context, pid string) (xone.Mandate, bool, error) {
+	if err := require(ctx, xone.PermissionReadPayments); err != nil {
+		return xone.Mandate{}, false, err
+	}
+
+	return s.service.FindMandate(ctx, pid)
+}
+
+func (s *MandateService) SaveMandate(ctx context.Context, pid string, data xone.SaveMandateData) (xone.Mandate, error) {
+	if err := require(ctx, xone.PermissionWritePayments); err != nil {
+		return xone.Mandate{}, err
+	}
+
+	return s.service.SaveMandate(ctx, pid, data)
+}
+
+func (s *MandateService) DeleteMandate(ctx context.Context, pid string) error {
+	if err := require(ctx, xone.PermissionWritePayments); err != nil {
+		return err
+	}
+
+	return s.service.DeleteMandate(ctx, pid)
+}
+
+func (s *MandateService) FindDirectDebits(ctx context.Context, collectionDate time.Time) ([]xone.DirectDebit, error) {
+	if err := require(ctx, xone.PermissionReadPayments); err != nil {
+		return nil, err
+	}
+
+	return s.service.FindDirectDebits(ctx, collectionDate)
+}
+
+func (s *MandateService) BookDirectDebits(ctx context.Context, collectionDate time.Time, debits []xone.DirectDebit) error {
+	if err := require(ctx, xone.PermissionWritePayments); err != nil {
+		return err
+	}
+
+	return s.service.BookDirectDebits(ctx, collectionDate, debits)
+}
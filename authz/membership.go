@@ -0,0 +1,77 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.MembershipService = (*MembershipService)(nil)
+
+type MembershipService struct {
+	service xone.MembershipService
+}
+
+func NewMembershipService(service xone.MembershipService) *MembershipService {
+	return &MembershipService{service: service}
+}
+
+func (s *MembershipService) FindAllMembershipTypes(ctx context.Context) ([]xone.MembershipType, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return nil, err
+	}
+
+	membershipTypes, err := s.service.FindAllMembershipTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fees are only visible to users who are allowed to read them.
+	if require(ctx, xone.PermissionReadFees) != nil {
+		for i := range membershipTypes {
+			membershipTypes[i].Fees = nil
+		}
+	}
+
+	return membershipTypes, nil
+}
+
+func (s *MembershipService) CreateMembershipType(ctx context.Context, name string) (xone.MembershipType, error) {
+	if err := require(ctx, xone.PermissionWriteMembershipTypes); err != nil {
+		return xone.MembershipType{}, err
+	}
+
+	return s.service.CreateMembershipType(ctx, name)
+}
+
+func (s *MembershipService) FindMembership(ctx context.Context, id int) (xone.Membership, bool, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return xone.Membership{}, false, err
+	}
+
+	return s.service.FindMembership(ctx, id)
+}
+
+func (s *MembershipService) UpdateMembership(ctx context.Context, id int, data xone.UpdateMembershipData) error {
+	if err := require(ctx, xone.PermissionWriteMemberships); err != nil {
+		return err
+	}
+
+	return s.service.UpdateMembership(ctx, id, data)
+}
+
+func (s *MembershipService) TerminateMembership(ctx context.Context, id int, data xone.TerminateMembershipData) error {
+	if err := require(ctx, xone.PermissionWriteMemberships); err != nil {
+		return err
+	}
+
+	return s.service.TerminateMembership(ctx, id, data)
+}
+
+func (s *MembershipService) ReinstateMembership(ctx context.Context, id int) error {
+	if err := require(ctx, xone.PermissionWriteMemberships); err != nil {
+		return err
+	}
+
+	return s.service.ReinstateMembership(ctx, id)
+}
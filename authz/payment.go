@@ -0,0 +1,58 @@
+package authz
+
+import (
+	"context"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.PaymentService = (*PaymentService)(nil)
+
+type PaymentService struct {
+	service xone.PaymentService
+}
+
+func NewPaymentService(service xone.PaymentService) *PaymentService {
+	return &PaymentService{service: service}
+}
+
+func (s *PaymentService) FindLedgerEntries(ctx context.Context, pid string) ([]xone.LedgerEntry, error) {
+	if err := require(ctx, xone.PermissionReadPayments); err != nil {
+		return nil, err
+	}
+
+	return s.service.FindLedgerEntries(ctx, pid)
+}
+
+func (s *PaymentService) CreateLedgerEntry(ctx context.Context, data xone.CreateLedgerEntryData) (xone.LedgerEntry, error) {
+	if err := require(ctx, xone.PermissionWritePayments); err != nil {
+		return xone.LedgerEntry{}, err
+	}
+
+	return s.service.CreateLedgerEntry(ctx, data)
+}
+
+func (s *PaymentService) Balance(ctx context.Context, pid string) (int64, error) {
+	if err := require(ctx, xone.PermissionReadPayments); err != nil {
+		return 0, err
+	}
+
+	return s.service.Balance(ctx, pid)
+}
+
+func (s *PaymentService) FindArrears(ctx context.Context, today time.Time) ([]xone.Arrear, error) {
+	if err := require(ctx, xone.PermissionReadPayments); err != nil {
+		return nil, err
+	}
+
+	return s.service.FindArrears(ctx, today)
+}
+
+func (s *PaymentService) ChargeDues(ctx context.Context, from, until time.Time) ([]xone.LedgerEntry, error) {
+	if err := require(ctx, xone.PermissionWritePayments); err != nil {
+		return nil, err
+	}
+
+	return s.service.ChargeDues(ctx, from, until)
+}
@@ -0,0 +1,89 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.PersonRepository = (*PersonRepository)(nil)
+
+type PersonRepository struct {
+	repo xone.PersonRepository
+}
+
+func NewPersonRepository(repo xone.PersonRepository) *PersonRepository {
+	return &PersonRepository{repo: repo}
+}
+
+func (r *PersonRepository) FindAll(ctx context.Context) ([]xone.Person, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return nil, err
+	}
+
+	return r.repo.FindAll(ctx)
+}
+
+func (r *PersonRepository) FindMany(ctx context.Context, filter xone.PersonFilter) ([]xone.Person, int, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return nil, 0, err
+	}
+
+	return r.repo.FindMany(ctx, filter)
+}
+
+func (r *PersonRepository) Find(ctx context.Context, id string) (xone.Person, bool, error) {
+	if err := require(ctx, xone.PermissionReadPersons); err != nil {
+		return xone.Person{}, false, err
+	}
+
+	return r.repo.Find(ctx, id)
+}
+
+func (r *PersonRepository) Create(ctx context.Context, data xone.CreatePersonData) (xone.Person, error) {
+	if err := require(ctx, xone.PermissionWritePersons); err != nil {
+		return xone.Person{}, err
+	}
+
+	return r.repo.Create(ctx, data)
+}
+
+func (r *PersonRepository) Delete(ctx context.Context, id string) error {
+	if err := require(ctx, xone.PermissionDeletePersons); err != nil {
+		return err
+	}
+
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *PersonRepository) Restore(ctx context.Context, id string) error {
+	if err := require(ctx, xone.PermissionDeletePersons); err != nil {
+		return err
+	}
+
+	return r.repo.Restore(ctx, id)
+}
+
+func (r *PersonRepository) Purge(ctx context.Context) (int, error) {
+	if err := require(ctx, xone.PermissionPurgePersons); err != nil {
+		return 0, err
+	}
+
+	return r.repo.Purge(ctx)
+}
+
+func (r *PersonRepository) Update(ctx context.Context, id string, data xone.UpdatePersonData) error {
+	if err := require(ctx, xone.PermissionWritePersons); err != nil {
+		return err
+	}
+
+	return r.repo.Update(ctx, id, data)
+}
+
+func (r *PersonRepository) Patch(ctx context.Context, id string, data xone.PatchPersonData) error {
+	if err := require(ctx, xone.PermissionWritePersons); err != nil {
+		return err
+	}
+
+	return r.repo.Patch(ctx, id, data)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/csv"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+// registerFormatFlags registers the flags which describe the layout of a CSV
+// file. The returned function builds the format after the flags have been
+// parsed.
+func registerFormatFlags(fs *flag.FlagSet) func() (csv.Format, error) {
+	short := fs.Bool("short", false, "use the short format without header (first name, last name, date of birth)")
+	delimiter := fs.String("delimiter", ",", "field delimiter, e.g. ; for files exported by Excel")
+	dateFormat := fs.String("date-format", xone.FormatDateOfBirth, "layout of dates, see https://pkg.go.dev/time#pkg-constants")
+
+	return func() (csv.Format, error) {
+		format := csv.DefaultFormat()
+		if *short {
+			format = csv.ShortFormat
+		}
+
+		comma, size := utf8.DecodeRuneInString(*delimiter)
+		if size == 0 || size != len(*delimiter) {
+			return csv.Format{}, fmt.Errorf("delimiter must be a single character")
+		}
+		format.Comma = comma
+		format.DateFormat = *dateFormat
+
+		return format, nil
+	}
+}
+
+// addFieldColumns appends a column for every custom field to a format with a
+// header, so custom fields are exported and can be imported again.
+func addFieldColumns(ctx context.Context, e *env, f csv.Format) (csv.Format, error) {
+	if !f.Header {
+		return f, nil
+	}
+
+	defs, err := sqlite.NewFieldService(e.db).FindFieldDefinitions(ctx)
+	if err != nil {
+		return csv.Format{}, err
+	}
+
+	columns := append([]csv.Column{}, f.Columns...)
+	for _, d := range defs {
+		columns = append(columns, csv.FieldColumn(d.Name))
+	}
+	f.Columns = columns
+
+	return f, nil
+}
+
+func runImport(ctx context.Context, e *env, args []string) error {
+	var opts xone.ImportOptions
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.StringVar(&opts.DefaultMembershipType, "type", "", "membership type for persons without one in the file")
+	fs.Var(dateFlag{&opts.DefaultEffectiveFrom}, "effective-from", "start of memberships without one in the file (YYYY-MM-DD)")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "only report what would be imported")
+	format := registerFormatFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	f, err := format()
+	if err != nil {
+		return err
+	}
+	if f, err = addFieldColumns(ctx, e, f); err != nil {
+		return err
+	}
+
+	result, err := f.ParseFileLenient(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// Rows which could not be parsed are reported along with the rows the
+	// importer rejects, so the whole file can be fixed in one go. Nothing is
+	// imported in that case.
+	for _, row := range result.Rejected {
+		for _, err := range row.Errors {
+			fmt.Fprintln(e.stdout, err)
+		}
+	}
+	if len(result.Rejected) > 0 {
+		opts.DryRun = true
+	}
+
+	report, err := sqlite.NewPersonService(e.db).Import(ctx, result.Persons, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		for _, err := range row.Errors {
+			fmt.Fprintf(e.stdout, "line %d (%s %s): %v\n", result.Lines[row.Index], row.Person.FirstName, row.Person.LastName, err)
+		}
+	}
+
+	if len(result.Rejected) > 0 {
+		return fmt.Errorf("import failed, no persons have been imported")
+	}
+
+	switch {
+	case report.Failed():
+		return fmt.Errorf("import failed, no persons have been imported")
+	case opts.DryRun:
+		fmt.Fprintf(e.stdout, "%d persons would be imported\n", len(report.Rows))
+	default:
+		fmt.Fprintf(e.stdout, "%d persons imported\n", len(report.Rows))
+	}
+
+	return nil
+}
+
+func runExport(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := registerFormatFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	f, err := format()
+	if err != nil {
+		return err
+	}
+	if f, err = addFieldColumns(ctx, e, f); err != nil {
+		return err
+	}
+
+	persons, err := sqlite.NewPersonService(e.db).FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	return f.WriteFile(fs.Arg(0), persons)
+}
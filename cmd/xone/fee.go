@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func runFeeList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("fee list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	mt, err := findMembershipType(ctx, sqlite.NewMembershipService(e.db), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "ID\tAMOUNT\tPERIOD\tVALID FROM\tVALID UNTIL")
+	for _, f := range mt.Fees {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", f.ID, xone.FormatAmount(f.Amount), f.Period, formatDate(f.ValidFrom), formatDate(f.ValidUntil))
+	}
+
+	return tw.Flush()
+}
+
+func runFeeAdd(ctx context.Context, e *env, args []string) error {
+	var data xone.CreateFeeData
+
+	fs := flag.NewFlagSet("fee add", flag.ContinueOnError)
+	membershipType := fs.String("type", "", "name of the membership type")
+	amount := fs.String("amount", "", "amount per billing period, e.g. 120.00")
+	period := fs.String("period", string(xone.BillingAnnual), "billing period: annual, quarterly or monthly")
+	fs.Var(dateFlag{&data.ValidFrom}, "from", "first day the fee is valid (YYYY-MM-DD)")
+	fs.Var(dateFlag{&data.ValidUntil}, "until", "last day the fee is valid (YYYY-MM-DD), empty for no end")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	mt, err := findMembershipType(ctx, sqlite.NewMembershipService(e.db), *membershipType)
+	if err != nil {
+		return err
+	}
+	data.MembershipTypeID = mt.ID
+
+	if data.Amount, err = xone.ParseAmount(*amount); err != nil {
+		return err
+	}
+	data.Period = xone.BillingPeriod(*period)
+
+	fee, err := sqlite.NewFeeService(e.db).CreateFee(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, fee.ID)
+
+	return nil
+}
+
+func runFeeDelete(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("fee delete", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid fee ID %q", fs.Arg(0))
+	}
+
+	return sqlite.NewFeeService(e.db).DeleteFee(ctx, id)
+}
+
+func runPersonDues(ctx context.Context, e *env, args []string) error {
+	from, until := xone.BillingAnnual.Bounds(time.Now())
+
+	fs := flag.NewFlagSet("person dues", flag.ContinueOnError)
+	fs.Var(dateFlag{&from}, "from", "first day of the period (YYYY-MM-DD)")
+	fs.Var(dateFlag{&until}, "until", "last day of the period (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	dues, err := sqlite.NewFeeService(e.db).CalculateDues(ctx, fs.Arg(0), from, until)
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "FROM\tUNTIL\tMEMBERSHIP\tFEE\tAMOUNT")
+	for _, item := range dues.Items {
+		fee := fmt.Sprintf("%s %s", xone.FormatAmount(item.Fee.Amount), item.Fee.Period)
+		fmt.Fprintf(tw, "%s\t%s\t#%d %s\t%s\t%s\n", formatDate(item.From), formatDate(item.Until), item.MembershipID, item.MembershipType, fee, xone.FormatAmount(item.Amount))
+	}
+	fmt.Fprintf(tw, "Total\t\t\t\t%s\n", xone.FormatAmount(dues.Total))
+
+	return tw.Flush()
+}
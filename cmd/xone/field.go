@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func runFieldList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("field list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	defs, err := sqlite.NewFieldService(e.db).FindFieldDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "ID\tNAME\tTYPE\tREQUIRED\tOPTIONS")
+	for _, d := range defs {
+		options := "-"
+		if len(d.Options) > 0 {
+			options = strings.Join(d.Options, ", ")
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%t\t%s\n", d.ID, d.Name, d.Type, d.Required, options)
+	}
+
+	return tw.Flush()
+}
+
+func runFieldAdd(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("field add", flag.ContinueOnError)
+	fieldType := fs.String("type", string(xone.FieldText), "text, number, date, bool or enum")
+	required := fs.Bool("required", false, "require a value for every person")
+	options := fs.String("options", "", "comma-separated values of an enum field")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	data := xone.CreateFieldDefinitionData{
+		Name:     fs.Arg(0),
+		Type:     xone.FieldType(*fieldType),
+		Required: *required,
+	}
+	if *options != "" {
+		data.Options = strings.Split(*options, ",")
+	}
+
+	d, err := sqlite.NewFieldService(e.db).CreateFieldDefinition(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, d.ID)
+
+	return nil
+}
+
+func runFieldDelete(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("field delete", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid field ID %q", fs.Arg(0))
+	}
+
+	return sqlite.NewFieldService(e.db).DeleteFieldDefinition(ctx, id)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func runHouseholdList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("household list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	households, err := sqlite.NewHouseholdService(e.db).FindHouseholds(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "ID\tNAME\tADDRESS\tMEMBERS")
+	for _, h := range households {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\n", h.ID, h.Name, formatHouseholdAddress(h), len(h.Members))
+	}
+
+	return tw.Flush()
+}
+
+func runHouseholdShow(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("household show", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	h, err := findHousehold(ctx, sqlite.NewHouseholdService(e.db), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintf(tw, "ID:\t%d\n", h.ID)
+	fmt.Fprintf(tw, "Name:\t%s\n", h.Name)
+	fmt.Fprintf(tw, "Address:\t%s\n", formatHouseholdAddress(h))
+	for _, p := range h.Members {
+		primary := ""
+		if p.PID == h.PrimaryContact {
+			primary = " (primary contact)"
+		}
+		fmt.Fprintf(tw, "Member:\t%s %s %s%s\n", p.PID, p.FirstName, p.LastName, primary)
+	}
+
+	return tw.Flush()
+}
+
+func runHouseholdAdd(ctx context.Context, e *env, args []string) error {
+	var data xone.CreateHouseholdData
+
+	fs := flag.NewFlagSet("household add", flag.ContinueOnError)
+	fs.StringVar(&data.Name, "name", "", "name of the household, defaults to the last name of the primary contact")
+	fs.StringVar(&data.Street, "street", "", "street, defaults to the address of the primary contact")
+	fs.StringVar(&data.HouseNumber, "house-number", "", "house number")
+	fs.StringVar(&data.ZipCode, "zip-code", "", "zip code")
+	fs.StringVar(&data.City, "city", "", "city")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	data.PrimaryContact = fs.Arg(0)
+
+	h, err := sqlite.NewHouseholdService(e.db).CreateHousehold(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, h.ID)
+
+	return nil
+}
+
+func runHouseholdEdit(ctx context.Context, e *env, args []string) error {
+	hs := sqlite.NewHouseholdService(e.db)
+
+	// The ID precedes the flags, so the household has to be looked up before
+	// the flags can be registered with its current data as defaults.
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	h, err := findHousehold(ctx, hs, args[0])
+	if err != nil {
+		return err
+	}
+
+	data := h.ToUpdateData()
+	fs := flag.NewFlagSet("household edit", flag.ContinueOnError)
+	fs.StringVar(&data.Name, "name", data.Name, "name of the household")
+	fs.StringVar(&data.PrimaryContact, "primary-contact", data.PrimaryContact, "PID of the primary contact, who must be a member")
+	fs.StringVar(&data.Street, "street", data.Street, "street")
+	fs.StringVar(&data.HouseNumber, "house-number", data.HouseNumber, "house number")
+	fs.StringVar(&data.ZipCode, "zip-code", data.ZipCode, "zip code")
+	fs.StringVar(&data.City, "city", data.City, "city")
+	fs.BoolVar(&data.PropagateAddress, "propagate", false, "copy the address to all members")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	return hs.UpdateHousehold(ctx, h.ID, data)
+}
+
+func runHouseholdDelete(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("household delete", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	hs := sqlite.NewHouseholdService(e.db)
+	h, err := findHousehold(ctx, hs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return hs.DeleteHousehold(ctx, h.ID)
+}
+
+func runHouseholdJoin(ctx context.Context, e *env, args []string) error {
+	return runHouseholdMember(ctx, e, "household join", args, (*sqlite.HouseholdService).AddHouseholdMember)
+}
+
+func runHouseholdLeave(ctx context.Context, e *env, args []string) error {
+	return runHouseholdMember(ctx, e, "household leave", args, (*sqlite.HouseholdService).RemoveHouseholdMember)
+}
+
+// runHouseholdMember adds a person to or removes them from a household, both
+// of which take the ID of the household and the PID of the person.
+func runHouseholdMember(ctx context.Context, e *env, name string, args []string, change func(*sqlite.HouseholdService, context.Context, int, string) error) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 2); err != nil {
+		return err
+	}
+
+	hs := sqlite.NewHouseholdService(e.db)
+	h, err := findHousehold(ctx, hs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if _, found, err := sqlite.NewPersonService(e.db).Find(ctx, fs.Arg(1)); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("person %s not found", fs.Arg(1))
+	}
+
+	return change(hs, ctx, h.ID, fs.Arg(1))
+}
+
+// findHousehold resolves a household by the ID given on the command line.
+func findHousehold(ctx context.Context, hs *sqlite.HouseholdService, arg string) (xone.Household, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return xone.Household{}, fmt.Errorf("invalid household ID %q", arg)
+	}
+
+	h, found, err := hs.FindHousehold(ctx, id)
+	if err != nil {
+		return xone.Household{}, err
+	} else if !found {
+		return xone.Household{}, fmt.Errorf("household %d not found", id)
+	}
+
+	return h, nil
+}
+
+func formatHouseholdAddress(h xone.Household) string {
+	return fmt.Sprintf("%s %s, %s %s", h.Street, h.HouseNumber, h.ZipCode, h.City)
+}
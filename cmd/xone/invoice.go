@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/invoice"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+// registerIssuerFlags registers the flags which describe the club that is
+// printed on invoices and receipts.
+func registerIssuerFlags(fs *flag.FlagSet) *invoice.Issuer {
+	var issuer invoice.Issuer
+	fs.StringVar(&issuer.Name, "issuer-name", "", "name of the club")
+	fs.StringVar(&issuer.Address, "issuer-address", "", "postal address of the club")
+	fs.StringVar(&issuer.Email, "issuer-email", "", "email address of the club")
+	fs.StringVar(&issuer.IBAN, "issuer-iban", "", "IBAN to which invoices are paid")
+	fs.StringVar(&issuer.BIC, "issuer-bic", "", "BIC of the club's bank")
+
+	return &issuer
+}
+
+func runInvoiceList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("invoice list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	invoices, err := sqlite.NewInvoiceService(e.db).FindInvoices(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "NUMBER\tKIND\tISSUED ON\tTOTAL")
+	for _, i := range invoices {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", i.Number, i.Kind, formatDate(i.IssuedOn), xone.FormatAmount(i.Total()))
+	}
+
+	return tw.Flush()
+}
+
+// runInvoiceCreate issues an invoice for the dues of a person within a period,
+// which defaults to the current year.
+func runInvoiceCreate(ctx context.Context, e *env, args []string) error {
+	from, until := xone.BillingAnnual.Bounds(time.Now())
+	var issuedOn time.Time
+
+	fs := flag.NewFlagSet("invoice create", flag.ContinueOnError)
+	fs.Var(dateFlag{&from}, "from", "first day of the period (YYYY-MM-DD)")
+	fs.Var(dateFlag{&until}, "until", "last day of the period (YYYY-MM-DD)")
+	fs.Var(dateFlag{&issuedOn}, "date", "date of the invoice (YYYY-MM-DD), defaults to today")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	dues, err := sqlite.NewFeeService(e.db).CalculateDues(ctx, fs.Arg(0), from, until)
+	if err != nil {
+		return err
+	}
+	if dues.Total <= 0 {
+		return fmt.Errorf("person %s owes no dues between %s and %s", fs.Arg(0), formatDate(from), formatDate(until))
+	}
+
+	i, err := sqlite.NewInvoiceService(e.db).CreateInvoice(ctx, xone.CreateInvoiceData{
+		PID:      fs.Arg(0),
+		Kind:     xone.InvoiceMembershipFees,
+		IssuedOn: issuedOn,
+		Items:    xone.NewDuesInvoiceItems(dues),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, i.Number)
+
+	return nil
+}
+
+func runInvoiceReceipt(ctx context.Context, e *env, args []string) error {
+	data := xone.CreateInvoiceData{Kind: xone.InvoiceDonationReceipt}
+
+	fs := flag.NewFlagSet("invoice receipt", flag.ContinueOnError)
+	amount := fs.String("amount", "", "donated amount, e.g. 50.00")
+	description := fs.String("description", "Donation", "description of the donation")
+	fs.Var(dateFlag{&data.IssuedOn}, "date", "date of the receipt (YYYY-MM-DD), defaults to today")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	data.PID = fs.Arg(0)
+
+	cents, err := xone.ParseAmount(*amount)
+	if err != nil {
+		return err
+	}
+	data.Items = []xone.InvoiceItem{{Description: *description, Amount: cents}}
+
+	i, err := sqlite.NewInvoiceService(e.db).CreateInvoice(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, i.Number)
+
+	return nil
+}
+
+// runInvoiceRender writes an issued document to a file. The format is derived
+// from the extension of the file, which must be either ".html" or ".pdf".
+func runInvoiceRender(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("invoice render", flag.ContinueOnError)
+	issuer := registerIssuerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 2); err != nil {
+		return err
+	}
+
+	var render func(io.Writer, xone.Invoice, invoice.Issuer) error
+	switch filepath.Ext(fs.Arg(1)) {
+	case ".html":
+		render = invoice.WriteHTML
+	case ".pdf":
+		render = invoice.WritePDF
+	default:
+		return errors.New("file must end with .html or .pdf")
+	}
+
+	i, found, err := sqlite.NewInvoiceService(e.db).FindInvoice(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("invoice %s not found", fs.Arg(0))
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf, i, *issuer); err != nil {
+		return err
+	}
+
+	return os.WriteFile(fs.Arg(1), buf.Bytes(), 0644)
+}
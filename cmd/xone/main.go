@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+// DefaultDSN is the database used when neither the -db flag nor the XONE_DB
+// environment variable is set.
+const DefaultDSN = "xone.db"
+
+const usage = `Usage: xone [-db DSN] <command> [arguments]
+
+Commands:
+  person list [-archived]          List all persons
+  person show <pid>                Show a single person
+  person search <query>            Find persons by name, email, street or city
+  person add [flags]               Add a new person
+  person edit <pid> [flags]        Edit an existing person
+  person delete <pid>              Delete a person
+  person history <pid>             Show all changes of a person
+  person restore <pid>             Restore a deleted person
+  person purge [-retention]        Remove deleted persons for good
+  person dues [flags] <pid>        Calculate the dues of a person
+  household list                   List all households
+  household show <id>              Show a household and its members
+  household add [flags] <pid>      Add a household with a primary contact
+  household edit <id> [flags]      Edit a household
+  household delete <id>            Dissolve a household
+  household join <id> <pid>        Add a person to a household
+  household leave <id> <pid>       Remove a person from a household
+  membership terminate <id>        End a membership
+  membership reinstate <id>        Undo the termination of a membership
+  membership-type list             List all membership types
+  membership-type add <name>       Add a new membership type
+  field list                       List all custom fields
+  field add [flags] <name>         Add a custom field
+  field delete <id>                Delete a custom field along with its values
+  fee list <membership-type>       List the fees of a membership type
+  fee add [flags]                  Add a fee to a membership type
+  fee delete <id>                  Delete a fee
+  ledger show <pid>                Show the charges and payments of a person
+  ledger charge [flags] <pid>      Charge a person
+  ledger pay [flags] <pid>         Record a payment of a person
+  ledger arrears [-date]           List all persons who owe money
+  ledger dues [-from] [-until]     Charge all persons their dues
+  mandate show <pid>               Show the SEPA mandate of a person
+  mandate set [flags] <pid>        Add or replace the SEPA mandate of a person
+  mandate delete <pid>             Delete the SEPA mandate of a person
+  sepa export [flags] <file>       Export direct debits as a pain.008 file
+  invoice list <pid>               List the invoices and receipts of a person
+  invoice create [flags] <pid>     Issue an invoice for the dues of a person
+  invoice receipt [flags] <pid>    Issue a receipt for a donation
+  invoice render [flags] <number> <file>
+                                   Write an invoice as an HTML or PDF file
+  user add -email <email> [-role]  Add a new user, the password is read from stdin
+  import [flags] <file>            Import persons from a CSV file
+  export <file>                    Export all persons to a CSV file
+  serve [-addr ADDR]               Serve the HTTP API
+
+Run "xone <command> [subcommand] -h" for the flags of a command.
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
+
+// env bundles everything a command needs to do its work.
+type env struct {
+	db     *sql.DB
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command func(ctx context.Context, e *env, args []string) error
+
+var commands = map[string]map[string]command{
+	"person": {
+		"list":    runPersonList,
+		"show":    runPersonShow,
+		"search":  runPersonSearch,
+		"add":     runPersonAdd,
+		"edit":    runPersonEdit,
+		"delete":  runPersonDelete,
+		"history": runPersonHistory,
+		"restore": runPersonRestore,
+		"purge":   runPersonPurge,
+		"dues":    runPersonDues,
+	},
+	"household": {
+		"list":   runHouseholdList,
+		"show":   runHouseholdShow,
+		"add":    runHouseholdAdd,
+		"edit":   runHouseholdEdit,
+		"delete": runHouseholdDelete,
+		"join":   runHouseholdJoin,
+		"leave":  runHouseholdLeave,
+	},
+	"membership": {
+		"terminate": runMembershipTerminate,
+		"reinstate": runMembershipReinstate,
+	},
+	"membership-type": {
+		"list": runMembershipTypeList,
+		"add":  runMembershipTypeAdd,
+	},
+	"field": {
+		"list":   runFieldList,
+		"add":    runFieldAdd,
+		"delete": runFieldDelete,
+	},
+	"fee": {
+		"list":   runFeeList,
+		"add":    runFeeAdd,
+		"delete": runFeeDelete,
+	},
+	"ledger": {
+		"show":    runLedgerShow,
+		"charge":  runLedgerCharge,
+		"pay":     runLedgerPay,
+		"arrears": runLedgerArrears,
+		"dues":    runLedgerDues,
+	},
+	"mandate": {
+		"show":   runMandateShow,
+		"set":    runMandateSet,
+		"delete": runMandateDelete,
+	},
+	"sepa": {
+		"export": runSEPAExport,
+	},
+	"invoice": {
+		"list":    runInvoiceList,
+		"create":  runInvoiceCreate,
+		"receipt": runInvoiceReceipt,
+		"render":  runInvoiceRender,
+	},
+	"user": {
+		"add": runUserAdd,
+	},
+}
+
+var topLevelCommands = map[string]command{
+	"import": runImport,
+	"export": runExport,
+	"serve":  runServe,
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("xone", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), usage) }
+	dsn := fs.String("db", defaultDSN(), "database file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cmd, args, err := lookupCommand(fs.Args())
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	db, err := sqlite.Open(*dsn)
+	if err != nil {
+		return fmt.Errorf("cannot open database: %w", err)
+	}
+	defer db.Close()
+
+	return cmd(ctx, &env{db: db, stdin: stdin, stdout: stdout, stderr: stderr}, args)
+}
+
+// lookupCommand finds the command referenced by the given arguments and
+// returns it along with its remaining arguments.
+func lookupCommand(args []string) (command, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, errUsage
+	}
+
+	if cmd, ok := topLevelCommands[args[0]]; ok {
+		return cmd, args[1:], nil
+	}
+
+	subcommands, ok := commands[args[0]]
+	if !ok || len(args) < 2 {
+		return nil, nil, errUsage
+	}
+
+	cmd, ok := subcommands[args[1]]
+	if !ok {
+		return nil, nil, errUsage
+	}
+
+	return cmd, args[2:], nil
+}
+
+func defaultDSN() string {
+	if dsn := os.Getenv("XONE_DB"); dsn != "" {
+		return dsn
+	}
+
+	return DefaultDSN
+}
+
+// requireArgs verifies the number of positional arguments left after parsing
+// the flags of a command.
+func requireArgs(fs *flag.FlagSet, n int) error {
+	if fs.NArg() != n {
+		fs.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// dateFlag is a flag.Value which accepts dates in the xone.FormatDateOfBirth
+// format.
+type dateFlag struct {
+	t *time.Time
+}
+
+func (f dateFlag) String() string {
+	if f.t == nil || f.t.IsZero() {
+		return ""
+	}
+
+	return f.t.Format(xone.FormatDateOfBirth)
+}
+
+func (f dateFlag) Set(s string) error {
+	if s == "" {
+		*f.t = time.Time{}
+		return nil
+	}
+
+	t, err := time.Parse(xone.FormatDateOfBirth, s)
+	if err != nil {
+		return fmt.Errorf("date must be formatted as %s", xone.FormatDateOfBirth)
+	}
+	*f.t = t
+
+	return nil
+}
+
+// fieldFlag is a flag.Value which sets the value of a custom field given as
+// name=value. It may be repeated to set several fields.
+type fieldFlag struct {
+	values *map[string]string
+}
+
+func (f fieldFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(*f.values))
+	for name := range *f.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + (*f.values)[name]
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (f fieldFlag) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("custom field must be given as name=value")
+	}
+
+	if *f.values == nil {
+		*f.values = map[string]string{}
+	}
+	(*f.values)[s[:i]] = s[i+1:]
+
+	return nil
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Format(xone.FormatDateOfBirth)
+}
+
+func newTabWriter(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+}
+
+// findMembershipType resolves a membership type by its name.
+func findMembershipType(ctx context.Context, ms *sqlite.MembershipService, name string) (xone.MembershipType, error) {
+	membershipTypes, err := ms.FindAllMembershipTypes(ctx)
+	if err != nil {
+		return xone.MembershipType{}, err
+	}
+
+	for _, mt := range membershipTypes {
+		if strings.EqualFold(mt.Name, name) {
+			return mt, nil
+		}
+	}
+
+	return xone.MembershipType{}, fmt.Errorf("unknown membership type %q", name)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mustRun runs the command line tool against the given database and returns
+// everything it has written to stdout.
+func mustRun(t *testing.T, dsn string, stdin string, args ...string) string {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	args = append([]string{"-db", dsn}, args...)
+	if err := run(context.Background(), args, strings.NewReader(stdin), &stdout, io.Discard); err != nil {
+		t.Fatalf("run(%v) error = %v", args, err)
+	}
+
+	return stdout.String()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "xone.db")
+
+	mustRun(t, dsn, "", "membership-type", "add", "active")
+	if out := mustRun(t, dsn, "", "membership-type", "list"); !strings.Contains(out, "active") {
+		t.Errorf("membership-type list = %q, want it to contain %q", out, "active")
+	}
+
+	pid := strings.TrimSpace(mustRun(t, dsn, "", "person", "add", "-first-name", "Harry", "-last-name", "Potter", "-dob", "1980-07-31", "-type", "active"))
+	if pid == "" {
+		t.Fatal("person add did not print a PID")
+	}
+
+	mustRun(t, dsn, "", "person", "edit", pid, "-email", "harry.potter@hogwarts.co.uk")
+	out := mustRun(t, dsn, "", "person", "show", pid)
+	for _, want := range []string{"Harry", "Potter", "1980-07-31", "harry.potter@hogwarts.co.uk", "active"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("person show = %q, want it to contain %q", out, want)
+		}
+	}
+
+	if out := mustRun(t, dsn, "", "fee", "add", "-type", "active", "-amount", "120", "-from", "2020-01-01"); strings.TrimSpace(out) == "" {
+		t.Error("fee add did not print an ID")
+	}
+	if out := mustRun(t, dsn, "", "fee", "list", "active"); !strings.Contains(out, "120.00") {
+		t.Errorf("fee list = %q, want it to contain %q", out, "120.00")
+	}
+	if out := mustRun(t, dsn, "", "person", "dues", "-from", "2021-01-01", "-until", "2021-12-31", pid); !strings.Contains(out, "120.00") {
+		t.Errorf("person dues = %q, want it to contain %q", out, "120.00")
+	}
+
+	if out := mustRun(t, dsn, "", "ledger", "dues", "-from", "2021-01-01", "-until", "2021-12-31"); !strings.Contains(out, "1 charges") {
+		t.Errorf("ledger dues = %q, want it to report one charge", out)
+	}
+	if out := mustRun(t, dsn, "", "ledger", "dues", "-from", "2021-01-01", "-until", "2021-12-31"); !strings.Contains(out, "0 charges") {
+		t.Errorf("ledger dues = %q, want persons not to be charged twice", out)
+	}
+	if out := mustRun(t, dsn, "", "ledger", "arrears"); !strings.Contains(out, "120.00") {
+		t.Errorf("ledger arrears = %q, want it to contain %q", out, "120.00")
+	}
+	mustRun(t, dsn, "", "ledger", "pay", "-amount", "120", "-method", "cash", pid)
+	if out := mustRun(t, dsn, "", "ledger", "show", pid); !strings.Contains(out, "-120.00") {
+		t.Errorf("ledger show = %q, want it to contain the payment", out)
+	}
+
+	mustRun(t, dsn, "", "ledger", "charge", "-amount", "50", "-date", "2021-06-01", pid)
+	mustRun(t, dsn, "", "mandate", "set", "-iban", "DE89 3704 0044 0532 0130 00", "-reference", "M-1", "-signed-on", "2021-01-01", pid)
+	if out := mustRun(t, dsn, "", "mandate", "show", pid); !strings.Contains(out, "DE89370400440532013000") {
+		t.Errorf("mandate show = %q, want it to contain the normalized IBAN", out)
+	}
+	sepaFile := filepath.Join(dir, "debits.xml")
+	if out := mustRun(t, dsn, "", "sepa", "export", "-creditor-name", "Hogwarts", "-creditor-iban", "DE02120300000000202051", "-creditor-id", "DE98ZZZ09999999999", "-book", sepaFile); !strings.Contains(out, "1 direct debits") {
+		t.Errorf("sepa export = %q, want it to report one direct debit", out)
+	}
+	if buf, err := os.ReadFile(sepaFile); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(buf), "<SeqTp>FRST</SeqTp>") || !strings.Contains(string(buf), ">50.00<") {
+		t.Errorf("sepa export wrote %q, want a first direct debit of 50.00", buf)
+	}
+	if out := mustRun(t, dsn, "", "mandate", "show", pid); !strings.Contains(out, "RCUR") {
+		t.Errorf("mandate show = %q, want the next direct debit to be recurring", out)
+	}
+
+	number := strings.TrimSpace(mustRun(t, dsn, "", "invoice", "create", "-from", "2021-01-01", "-until", "2021-12-31", "-date", "2021-01-15", pid))
+	if number != "INV-2021-0001" {
+		t.Errorf("invoice create = %q, want %q", number, "INV-2021-0001")
+	}
+	if out := mustRun(t, dsn, "", "invoice", "receipt", "-amount", "25", "-date", "2021-03-01", pid); strings.TrimSpace(out) != "REC-2021-0001" {
+		t.Errorf("invoice receipt = %q, want %q", out, "REC-2021-0001")
+	}
+	if out := mustRun(t, dsn, "", "invoice", "list", pid); !strings.Contains(out, "INV-2021-0001") || !strings.Contains(out, "REC-2021-0001") {
+		t.Errorf("invoice list = %q, want the invoice and the receipt", out)
+	}
+	for _, file := range []string{"invoice.html", "invoice.pdf"} {
+		mustRun(t, dsn, "", "invoice", "render", "-issuer-name", "Hogwarts", number, filepath.Join(dir, file))
+		if buf, err := os.ReadFile(filepath.Join(dir, file)); err != nil || !bytes.Contains(buf, []byte(number)) {
+			t.Errorf("invoice render wrote %q, %v, want it to contain %q", buf, err, number)
+		}
+	}
+
+	mustRun(t, dsn, "", "membership", "terminate", "-end-date", "2030-12-31", "-reason", "resignation", "1")
+	if out := mustRun(t, dsn, "", "person", "show", pid); !strings.Contains(out, "until 2030-12-31 (resignation)") {
+		t.Errorf("person show = %q, want it to contain the end of the membership", out)
+	}
+	mustRun(t, dsn, "", "membership", "reinstate", "1")
+
+	if out := mustRun(t, dsn, "", "person", "history", pid); !strings.Contains(out, "harry.potter@hogwarts.co.uk") {
+		t.Errorf("person history = %q, want it to contain the changed email address", out)
+	}
+
+	export := filepath.Join(dir, "export.csv")
+	mustRun(t, dsn, "", "export", export)
+	mustRun(t, dsn, "", "person", "delete", pid)
+	if out := mustRun(t, dsn, "", "person", "list"); strings.Contains(out, pid) {
+		t.Errorf("person list = %q, want it not to contain %q", out, pid)
+	}
+	if out := mustRun(t, dsn, "", "person", "list", "-archived"); !strings.Contains(out, pid) {
+		t.Errorf("person list -archived = %q, want it to contain %q", out, pid)
+	}
+	mustRun(t, dsn, "", "person", "restore", pid)
+	mustRun(t, dsn, "", "person", "delete", pid)
+	if out := mustRun(t, dsn, "", "person", "purge", "-retention", "0s"); !strings.Contains(out, "1 persons purged") {
+		t.Errorf("person purge = %q, want it to report one purged person", out)
+	}
+
+	if out := mustRun(t, dsn, "", "import", "-dry-run", export); !strings.Contains(out, "1 persons would be imported") {
+		t.Errorf("import -dry-run = %q, want it to report one importable person", out)
+	}
+	if out := mustRun(t, dsn, "", "import", export); !strings.Contains(out, "1 persons imported") {
+		t.Errorf("import = %q, want it to report one imported person", out)
+	}
+
+	var stdout bytes.Buffer
+	if err := run(context.Background(), []string{"-db", dsn, "import", export}, strings.NewReader(""), &stdout, io.Discard); err == nil {
+		t.Errorf("import of duplicates error = nil, want an error")
+	} else if !strings.Contains(stdout.String(), "line 2 (Harry Potter)") {
+		t.Errorf("import of duplicates = %q, want a report for line 2", stdout.String())
+	}
+
+	short := filepath.Join(dir, "short.csv")
+	mustRun(t, dsn, "", "export", "-short", "-delimiter", ";", short)
+	other := filepath.Join(dir, "other.db")
+	mustRun(t, other, "", "membership-type", "add", "active")
+	if out := mustRun(t, other, "", "import", "-short", "-delimiter", ";", "-type", "active", short); !strings.Contains(out, "1 persons imported") {
+		t.Errorf("import -short = %q, want it to report one imported person", out)
+	}
+	if out := mustRun(t, dsn, "", "person", "list"); !strings.Contains(out, "Potter") {
+		t.Errorf("person list = %q, want it to contain %q", out, "Potter")
+	}
+	if out := mustRun(t, dsn, "", "person", "search", "pott"); !strings.Contains(out, "Potter") {
+		t.Errorf("person search = %q, want it to contain %q", out, "Potter")
+	}
+
+	households := filepath.Join(dir, "households.db")
+	mustRun(t, households, "", "membership-type", "add", "family")
+	harry := strings.TrimSpace(mustRun(t, households, "", "person", "add", "-first-name", "Harry", "-last-name", "Potter", "-type", "family"))
+	ginny := strings.TrimSpace(mustRun(t, households, "", "person", "add", "-first-name", "Ginny", "-last-name", "Weasley", "-type", "family"))
+	household := strings.TrimSpace(mustRun(t, households, "", "household", "add", "-name", "Potter", "-city", "Godric's Hollow", harry))
+	mustRun(t, households, "", "household", "join", household, ginny)
+	mustRun(t, households, "", "household", "edit", household, "-street", "Church Lane", "-propagate")
+	if out := mustRun(t, households, "", "person", "show", ginny); !strings.Contains(out, "Church Lane") || !strings.Contains(out, "Household:") {
+		t.Errorf("person show = %q, want the household and its address", out)
+	}
+	if out := mustRun(t, households, "", "household", "show", household); !strings.Contains(out, "Ginny") || !strings.Contains(out, "(primary contact)") {
+		t.Errorf("household show = %q, want both members", out)
+	}
+	mustRun(t, households, "", "household", "leave", household, ginny)
+	if out := mustRun(t, households, "", "household", "list"); !strings.Contains(out, "Potter") {
+		t.Errorf("household list = %q, want it to contain %q", out, "Potter")
+	}
+	mustRun(t, households, "", "household", "delete", household)
+
+	fields := filepath.Join(dir, "fields.db")
+	mustRun(t, fields, "", "membership-type", "add", "active")
+	field := strings.TrimSpace(mustRun(t, fields, "", "field", "add", "-type", "enum", "-options", "S,M,L", "-required", "shirt_size"))
+	mustRun(t, fields, "", "field", "add", "-type", "number", "license")
+	if out := mustRun(t, fields, "", "field", "list"); !strings.Contains(out, "S, M, L") || !strings.Contains(out, "license") {
+		t.Errorf("field list = %q, want both fields", out)
+	}
+	neville := strings.TrimSpace(mustRun(t, fields, "", "person", "add", "-first-name", "Neville", "-last-name", "Longbottom", "-type", "active", "-field", "shirt_size=m"))
+	mustRun(t, fields, "", "person", "edit", neville, "-field", "license=7")
+	if out := mustRun(t, fields, "", "person", "show", neville); !strings.Contains(out, "shirt_size:") || !strings.Contains(out, "license:") {
+		t.Errorf("person show = %q, want both custom fields", out)
+	}
+	fieldsExport := filepath.Join(dir, "fields.csv")
+	mustRun(t, fields, "", "export", fieldsExport)
+	if buf, err := os.ReadFile(fieldsExport); err != nil || !bytes.Contains(buf, []byte(",license,shirt_size\n")) || !bytes.Contains(buf, []byte(",7,M\n")) {
+		t.Errorf("export wrote %q, %v, want the custom fields", buf, err)
+	}
+	mustRun(t, fields, "", "field", "delete", field)
+
+	mustRun(t, dsn, "SuperSecretPassword\n", "user", "add", "-email", "albus.dumbledore@hogwarts.co.uk")
+}
+
+func TestRun_Usage(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "xone.db")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "No command", args: []string{}},
+		{name: "Unknown command", args: []string{"unknown"}},
+		{name: "Missing subcommand", args: []string{"person"}},
+		{name: "Unknown subcommand", args: []string{"person", "unknown"}},
+		{name: "Missing PID", args: []string{"person", "show"}},
+		{name: "Unknown person", args: []string{"person", "show", "unknown"}},
+		{name: "Missing search query", args: []string{"person", "search"}},
+		{name: "Invalid fee amount", args: []string{"fee", "add", "-type", "active", "-amount", "12.345", "-from", "2020-01-01"}},
+		{name: "Invalid payment method", args: []string{"ledger", "pay", "-amount", "10", "-method", "cheque", "unknown"}},
+		{name: "Invalid IBAN", args: []string{"mandate", "set", "-iban", "DE00123", "-reference", "M-1", "-signed-on", "2021-01-01", "unknown"}},
+		{name: "Invalid invoice file", args: []string{"invoice", "render", "INV-2021-0001", "invoice.docx"}},
+		{name: "Unknown household", args: []string{"household", "show", "42"}},
+		{name: "Invalid field type", args: []string{"field", "add", "-type", "list", "shirt_size"}},
+		{name: "Invalid custom field", args: []string{"person", "add", "-first-name", "Harry", "-type", "active", "-field", "shirt_size"}},
+		{name: "Invalid termination reason", args: []string{"membership", "terminate", "-end-date", "2030-12-31", "-reason", "boredom", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			args := append([]string{"-db", dsn}, tt.args...)
+			if err := run(context.Background(), args, strings.NewReader(""), &stdout, io.Discard); err == nil {
+				t.Errorf("run(%v) error = nil, want an error", args)
+			}
+		})
+	}
+}
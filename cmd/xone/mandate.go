@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/sepa"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func runMandateShow(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("mandate show", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	mandate, found, err := sqlite.NewMandateService(e.db).FindMandate(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("person %s has no mandate", fs.Arg(0))
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintf(tw, "Reference:\t%s\n", mandate.Reference)
+	fmt.Fprintf(tw, "Account holder:\t%s\n", mandate.AccountHolder)
+	fmt.Fprintf(tw, "IBAN:\t%s\n", mandate.IBAN)
+	fmt.Fprintf(tw, "BIC:\t%s\n", mandate.BIC)
+	fmt.Fprintf(tw, "Signed on:\t%s\n", formatDate(mandate.SignedOn))
+	fmt.Fprintf(tw, "Last collected on:\t%s\n", formatDate(mandate.LastCollectedOn))
+	fmt.Fprintf(tw, "Next sequence type:\t%s\n", mandate.SequenceType())
+
+	return tw.Flush()
+}
+
+func runMandateSet(ctx context.Context, e *env, args []string) error {
+	var data xone.SaveMandateData
+
+	fs := flag.NewFlagSet("mandate set", flag.ContinueOnError)
+	fs.StringVar(&data.IBAN, "iban", "", "IBAN of the debtor")
+	fs.StringVar(&data.BIC, "bic", "", "BIC of the debtor's bank (optional)")
+	fs.StringVar(&data.Reference, "reference", "", "unique mandate reference")
+	fs.StringVar(&data.AccountHolder, "account-holder", "", "account holder, if it is not the person")
+	fs.Var(dateFlag{&data.SignedOn}, "signed-on", "date of signature (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	_, err := sqlite.NewMandateService(e.db).SaveMandate(ctx, fs.Arg(0), data)
+
+	return err
+}
+
+func runMandateDelete(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("mandate delete", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	return sqlite.NewMandateService(e.db).DeleteMandate(ctx, fs.Arg(0))
+}
+
+// runSEPAExport writes the outstanding balances of all persons with a mandate
+// to a pain.008 file, which can be uploaded to the club's bank. The balances
+// are taken from the ledger, so the dues of a period have to be charged with
+// "ledger dues" before they are collected.
+func runSEPAExport(ctx context.Context, e *env, args []string) error {
+	now := time.Now()
+	batch := sepa.Batch{CreatedAt: now, CollectionDate: now}
+
+	fs := flag.NewFlagSet("sepa export", flag.ContinueOnError)
+	fs.Var(dateFlag{&batch.CollectionDate}, "date", "collection date (YYYY-MM-DD), defaults to today")
+	fs.StringVar(&batch.Creditor.Name, "creditor-name", "", "name of the club")
+	fs.StringVar(&batch.Creditor.IBAN, "creditor-iban", "", "IBAN of the club")
+	fs.StringVar(&batch.Creditor.BIC, "creditor-bic", "", "BIC of the club's bank (optional)")
+	fs.StringVar(&batch.Creditor.ID, "creditor-id", "", "SEPA creditor identifier of the club")
+	fs.StringVar(&batch.MessageID, "message-id", "", `unique ID of the file, defaults to "XONE-" and the collection date`)
+	fs.StringVar(&batch.RemittanceInformation, "remittance", "Membership fees", "text shown on the debtors' bank statements")
+	book := fs.Bool("book", false, "record the direct debits as payments")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	batch.Creditor.IBAN = xone.NormalizeIBAN(batch.Creditor.IBAN)
+	if batch.MessageID == "" {
+		batch.MessageID = "XONE-" + batch.CollectionDate.Format("20060102")
+	}
+
+	ms := sqlite.NewMandateService(e.db)
+	debits, err := ms.FindDirectDebits(ctx, batch.CollectionDate)
+	if err != nil {
+		return err
+	}
+	batch.Debits = debits
+
+	f, err := os.Create(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := sepa.Write(f, batch); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if *book {
+		if err := ms.BookDirectDebits(ctx, batch.CollectionDate, debits); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(e.stdout, "%d direct debits\n", len(debits))
+
+	return nil
+}
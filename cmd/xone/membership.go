@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func runMembershipTypeList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("membership-type list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	membershipTypes, err := sqlite.NewMembershipService(e.db).FindAllMembershipTypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "ID\tNAME")
+	for _, mt := range membershipTypes {
+		fmt.Fprintf(tw, "%d\t%s\n", mt.ID, mt.Name)
+	}
+
+	return tw.Flush()
+}
+
+func runMembershipTypeAdd(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("membership-type add", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	mt, err := sqlite.NewMembershipService(e.db).CreateMembershipType(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, mt.ID)
+
+	return nil
+}
+
+func runMembershipTerminate(ctx context.Context, e *env, args []string) error {
+	var data xone.TerminateMembershipData
+
+	fs := flag.NewFlagSet("membership terminate", flag.ContinueOnError)
+	fs.Var(dateFlag{&data.EndDate}, "end-date", "last day of the membership (YYYY-MM-DD)")
+	reason := fs.String("reason", string(xone.TerminationResignation), "resignation, death, exclusion or other")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	data.Reason = xone.TerminationReason(*reason)
+
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid membership ID %q", fs.Arg(0))
+	}
+
+	return sqlite.NewMembershipService(e.db).TerminateMembership(ctx, id, data)
+}
+
+func runMembershipReinstate(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("membership reinstate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid membership ID %q", fs.Arg(0))
+	}
+
+	return sqlite.NewMembershipService(e.db).ReinstateMembership(ctx, id)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func runLedgerShow(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("ledger show", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	if _, found, err := sqlite.NewPersonService(e.db).Find(ctx, fs.Arg(0)); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("person %s not found", fs.Arg(0))
+	}
+
+	entries, err := sqlite.NewPaymentService(e.db).FindLedgerEntries(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "DATE\tKIND\tAMOUNT\tMETHOD\tDESCRIPTION\tREFERENCE")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", formatDate(entry.Date), entry.Kind, xone.FormatAmount(entry.Signed()), entry.Method, entry.Description, entry.Reference)
+	}
+	fmt.Fprintf(tw, "Balance\t\t%s\t\t\t\n", xone.FormatAmount(xone.Balance(entries)))
+
+	return tw.Flush()
+}
+
+func runLedgerCharge(ctx context.Context, e *env, args []string) error {
+	return runLedgerEntry(ctx, e, args, xone.LedgerCharge)
+}
+
+func runLedgerPay(ctx context.Context, e *env, args []string) error {
+	return runLedgerEntry(ctx, e, args, xone.LedgerPayment)
+}
+
+// runLedgerEntry records a charge or a payment, which only differ in the
+// payment method and the reference.
+func runLedgerEntry(ctx context.Context, e *env, args []string, kind xone.LedgerEntryKind) error {
+	data := xone.CreateLedgerEntryData{Kind: kind}
+
+	fs := flag.NewFlagSet("ledger "+string(kind), flag.ContinueOnError)
+	amount := fs.String("amount", "", "amount, e.g. 120.00")
+	fs.Var(dateFlag{&data.Date}, "date", "date of the entry (YYYY-MM-DD), defaults to today")
+	fs.StringVar(&data.Description, "description", "", "description")
+	var method *string
+	if kind == xone.LedgerPayment {
+		method = fs.String("method", string(xone.PaymentBankTransfer), "cash, bank_transfer, direct_debit or card")
+		fs.StringVar(&data.Reference, "reference", "", "reference, e.g. the purpose of a bank transfer")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	data.PID = fs.Arg(0)
+	if method != nil {
+		data.Method = xone.PaymentMethod(*method)
+	}
+
+	var err error
+	if data.Amount, err = xone.ParseAmount(*amount); err != nil {
+		return err
+	}
+
+	entry, err := sqlite.NewPaymentService(e.db).CreateLedgerEntry(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, entry.ID)
+
+	return nil
+}
+
+// runLedgerDues charges all persons the dues of a period, which defaults to
+// the current year. Days a person has already been charged for, even by a run
+// for another period, are not charged again.
+func runLedgerDues(ctx context.Context, e *env, args []string) error {
+	from, until := xone.BillingAnnual.Bounds(time.Now())
+
+	fs := flag.NewFlagSet("ledger dues", flag.ContinueOnError)
+	fs.Var(dateFlag{&from}, "from", "first day of the period (YYYY-MM-DD)")
+	fs.Var(dateFlag{&until}, "until", "last day of the period (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	entries, err := sqlite.NewPaymentService(e.db).ChargeDues(ctx, from, until)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "%d charges\n", len(entries))
+
+	return nil
+}
+
+func runLedgerArrears(ctx context.Context, e *env, args []string) error {
+	today := time.Now()
+
+	fs := flag.NewFlagSet("ledger arrears", flag.ContinueOnError)
+	fs.Var(dateFlag{&today}, "date", "date up to which entries are due (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	arrears, err := sqlite.NewPaymentService(e.db).FindArrears(ctx, today)
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "PID\tFIRST NAME\tLAST NAME\tBALANCE")
+	for _, a := range arrears {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.Person.PID, a.Person.FirstName, a.Person.LastName, xone.FormatAmount(a.Balance))
+	}
+
+	return tw.Flush()
+}
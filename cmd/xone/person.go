@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+// registerPersonFlags registers the flags shared by "person add" and
+// "person edit". The current values of p are used as defaults.
+func registerPersonFlags(fs *flag.FlagSet, p *xone.UpdatePersonData) {
+	fs.StringVar(&p.FirstName, "first-name", p.FirstName, "first name")
+	fs.StringVar(&p.LastName, "last-name", p.LastName, "last name")
+	fs.Var(dateFlag{&p.DateOfBirth}, "dob", "date of birth (YYYY-MM-DD)")
+	fs.StringVar(&p.Email, "email", p.Email, "email address")
+	fs.StringVar(&p.Phone, "phone", p.Phone, "phone number")
+	fs.StringVar(&p.Mobile, "mobile", p.Mobile, "mobile phone number")
+	fs.StringVar(&p.Street, "street", p.Street, "street")
+	fs.StringVar(&p.HouseNumber, "house-number", p.HouseNumber, "house number")
+	fs.StringVar(&p.ZipCode, "zip", p.ZipCode, "zip code")
+	fs.StringVar(&p.City, "city", p.City, "city")
+	fs.Var(fieldFlag{&p.CustomFields}, "field", "custom field as name=value, may be repeated, an empty value removes it")
+}
+
+func runPersonList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("person list", flag.ContinueOnError)
+	archived := fs.Bool("archived", false, "list deleted persons instead")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	persons, _, err := sqlite.NewPersonService(e.db).FindMany(ctx, xone.PersonFilter{Archived: *archived})
+	if err != nil {
+		return err
+	}
+
+	return printPersons(e.stdout, persons)
+}
+
+// runPersonSearch lists the persons matching the query, the best matches
+// first. Without full-text search, the results are not ranked, which is
+// pointed out on stderr.
+func runPersonSearch(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("person search", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	if ranked, err := sqlite.FullTextSearch(ctx, e.db); err != nil {
+		return err
+	} else if !ranked {
+		fmt.Fprintln(e.stderr, "warning: built without the sqlite_fts5 tag, the results are not ranked")
+	}
+
+	persons, err := sqlite.NewPersonService(e.db).Search(ctx, strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	return printPersons(e.stdout, persons)
+}
+
+// printPersons writes a table of persons along with their current membership.
+func printPersons(w io.Writer, persons []xone.Person) error {
+	tw := newTabWriter(w)
+	fmt.Fprintln(tw, "PID\tFIRST NAME\tLAST NAME\tDATE OF BIRTH\tMEMBERSHIP")
+	for _, p := range persons {
+		membership := "-"
+		if m := p.CurrentMembership(); m != nil {
+			membership = m.Type.Name
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p.PID, p.FirstName, p.LastName, formatDate(p.DateOfBirth), membership)
+	}
+
+	return tw.Flush()
+}
+
+func runPersonShow(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("person show", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	p, found, err := sqlite.NewPersonService(e.db).Find(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("person %s not found", fs.Arg(0))
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintf(tw, "PID:\t%s\n", p.PID)
+	fmt.Fprintf(tw, "First name:\t%s\n", p.FirstName)
+	fmt.Fprintf(tw, "Last name:\t%s\n", p.LastName)
+	fmt.Fprintf(tw, "Date of birth:\t%s\n", formatDate(p.DateOfBirth))
+	fmt.Fprintf(tw, "Email:\t%s\n", p.Email)
+	fmt.Fprintf(tw, "Phone:\t%s\n", p.Phone)
+	fmt.Fprintf(tw, "Mobile:\t%s\n", p.Mobile)
+	fmt.Fprintf(tw, "Address:\t%s %s, %s %s\n", p.Street, p.HouseNumber, p.ZipCode, p.City)
+	names := make([]string, 0, len(p.CustomFields))
+	for name := range p.CustomFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s:\t%s\n", name, p.CustomFields[name])
+	}
+	for _, m := range p.Memberships {
+		if m.Terminated() {
+			fmt.Fprintf(tw, "Membership:\t#%d %s since %s until %s (%s)\n", m.ID, m.Type.Name, formatDate(m.EffectiveFrom), formatDate(m.EndDate), m.TerminationReason)
+			continue
+		}
+		fmt.Fprintf(tw, "Membership:\t#%d %s since %s\n", m.ID, m.Type.Name, formatDate(m.EffectiveFrom))
+	}
+
+	if h, found, err := sqlite.NewHouseholdService(e.db).FindHouseholdOfPerson(ctx, p.PID); err != nil {
+		return err
+	} else if found {
+		fmt.Fprintf(tw, "Household:\t#%d %s\n", h.ID, h.Name)
+	}
+
+	return tw.Flush()
+}
+
+func runPersonAdd(ctx context.Context, e *env, args []string) error {
+	var data xone.UpdatePersonData
+	var membershipType string
+	effectiveFrom := dateFlag{new(time.Time)}
+
+	fs := flag.NewFlagSet("person add", flag.ContinueOnError)
+	registerPersonFlags(fs, &data)
+	fs.StringVar(&membershipType, "type", "", "membership type (required)")
+	fs.Var(effectiveFrom, "effective-from", "start of the membership (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+	if membershipType == "" {
+		return fmt.Errorf("membership type required")
+	}
+
+	mt, err := findMembershipType(ctx, sqlite.NewMembershipService(e.db), membershipType)
+	if err != nil {
+		return err
+	}
+
+	p, err := sqlite.NewPersonService(e.db).Create(ctx, xone.CreatePersonData{
+		FirstName:        data.FirstName,
+		LastName:         data.LastName,
+		DateOfBirth:      data.DateOfBirth,
+		Email:            data.Email,
+		Phone:            data.Phone,
+		Mobile:           data.Mobile,
+		Street:           data.Street,
+		HouseNumber:      data.HouseNumber,
+		ZipCode:          data.ZipCode,
+		City:             data.City,
+		MembershipTypeID: mt.ID,
+		EffectiveFrom:    *effectiveFrom.t,
+		CustomFields:     data.CustomFields,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, p.PID)
+
+	return nil
+}
+
+func runPersonEdit(ctx context.Context, e *env, args []string) error {
+	ps := sqlite.NewPersonService(e.db)
+
+	// The PID precedes the flags, so it has to be looked up before the flags
+	// can be registered with the person's current data as defaults.
+	if len(args) == 0 {
+		return errUsage
+	}
+	pid := args[0]
+
+	p, found, err := ps.Find(ctx, pid)
+	if err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("person %s not found", pid)
+	}
+
+	data := p.ToUpdateData()
+	fs := flag.NewFlagSet("person edit", flag.ContinueOnError)
+	registerPersonFlags(fs, &data)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	return ps.Update(ctx, pid, data)
+}
+
+func runPersonDelete(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("person delete", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	ps := sqlite.NewPersonService(e.db)
+	if _, found, err := ps.Find(ctx, fs.Arg(0)); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("person %s not found", fs.Arg(0))
+	}
+
+	return ps.Delete(ctx, fs.Arg(0))
+}
+
+func runPersonHistory(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("person history", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	changes, err := sqlite.NewHistoryService(e.db).Timeline(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	} else if len(changes) == 0 {
+		return fmt.Errorf("person %s not found", fs.Arg(0))
+	}
+
+	tw := newTabWriter(e.stdout)
+	fmt.Fprintln(tw, "TIME\tSUBJECT\tFIELD\tOLD\tNEW")
+	for _, c := range changes {
+		subject := "person"
+		if c.MembershipID != 0 {
+			subject = fmt.Sprintf("membership #%d", c.MembershipID)
+		}
+		if c.Created {
+			subject += " (created)"
+		}
+
+		for _, f := range c.Fields {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Time.Format(time.RFC3339), subject, f.Field, f.Old, f.New)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func runPersonRestore(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("person restore", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+
+	return sqlite.NewPersonService(e.db).Restore(ctx, fs.Arg(0))
+}
+
+func runPersonPurge(ctx context.Context, e *env, args []string) error {
+	ps := sqlite.NewPersonService(e.db)
+
+	fs := flag.NewFlagSet("person purge", flag.ContinueOnError)
+	fs.DurationVar(&ps.Retention, "retention", sqlite.DefaultRetention, "time deleted persons are kept, e.g. 720h")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	n, err := ps.Purge(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "%d persons purged\n", n)
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/stillwondering/xone/authz"
+	"github.com/stillwondering/xone/http"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func runServe(ctx context.Context, e *env, args []string) error {
+	s := http.NewServer()
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.StringVar(&s.Addr, "addr", ":8080", "listen address")
+	issuer := registerIssuerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	us, err := sqlite.NewUserService(e.db)
+	if err != nil {
+		return err
+	}
+
+	s.PersonRepository = authz.NewPersonRepository(sqlite.NewPersonService(e.db))
+	s.MembershipService = authz.NewMembershipService(sqlite.NewMembershipService(e.db))
+	s.FeeService = authz.NewFeeService(sqlite.NewFeeService(e.db))
+	s.PaymentService = authz.NewPaymentService(sqlite.NewPaymentService(e.db))
+	s.MandateService = authz.NewMandateService(sqlite.NewMandateService(e.db))
+	s.InvoiceService = authz.NewInvoiceService(sqlite.NewInvoiceService(e.db))
+	s.HouseholdService = authz.NewHouseholdService(sqlite.NewHouseholdService(e.db))
+	s.FieldService = authz.NewFieldService(sqlite.NewFieldService(e.db))
+	s.Issuer = *issuer
+	s.UserService = us
+	s.SessionService = sqlite.NewSessionService(e.db)
+
+	if err := s.Open(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "listening on %s\n", s.URL())
+
+	<-ctx.Done()
+
+	return s.Close()
+}
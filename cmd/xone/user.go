@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/bcrypt"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+func runUserAdd(ctx context.Context, e *env, args []string) error {
+	var email, role string
+
+	fs := flag.NewFlagSet("user add", flag.ContinueOnError)
+	fs.StringVar(&email, "email", "", "email address (required)")
+	fs.StringVar(&role, "role", string(xone.RoleReadOnly), "role of the user: admin, board, treasurer or read-only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+	if email == "" {
+		return fmt.Errorf("email required")
+	}
+
+	// Reading the password from stdin keeps it out of the shell history.
+	password, err := bufio.NewReader(e.stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("cannot read password: %w", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return fmt.Errorf("password required")
+	}
+
+	hash, err := bcrypt.HashFromPassword([]byte(password))
+	if err != nil {
+		return err
+	}
+
+	us, err := sqlite.NewUserService(e.db)
+	if err != nil {
+		return err
+	}
+
+	_, err = us.Create(ctx, xone.CreateUserData{
+		Email:    email,
+		Password: string(hash),
+		Role:     xone.Role(role),
+	})
+
+	return err
+}
@@ -0,0 +1,37 @@
+package xone
+
+import "context"
+
+type contextKey int
+
+const (
+	userContextKey contextKey = iota + 1
+	reasonContextKey
+)
+
+// NewContextWithUser returns a new context which carries the given user, e.g.
+// the user who is logged in for the current request. The password hash of the
+// user is removed, so that it cannot leak from the context.
+func NewContextWithUser(ctx context.Context, user User) context.Context {
+	user.Password = ""
+
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the user stored in ctx, if any.
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userContextKey).(User)
+	return user, ok
+}
+
+// NewContextWithReason returns a new context which carries the reason for the
+// changes made with it. The reason is recorded in the audit log.
+func NewContextWithReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, reasonContextKey, reason)
+}
+
+// ReasonFromContext returns the reason stored in ctx, if any.
+func ReasonFromContext(ctx context.Context) string {
+	reason, _ := ctx.Value(reasonContextKey).(string)
+	return reason
+}
@@ -0,0 +1,18 @@
+package xone
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewContextWithUser(t *testing.T) {
+	user := User{ID: 1, Email: "albus.dumbledore@hogwarts.co.uk", Password: "$2a$08$hash", Role: RoleAdmin}
+
+	got, ok := UserFromContext(NewContextWithUser(context.Background(), user))
+	if !ok {
+		t.Fatalf("UserFromContext() ok = false, want true")
+	}
+	if want := (User{ID: 1, Email: "albus.dumbledore@hogwarts.co.uk", Role: RoleAdmin}); got != want {
+		t.Errorf("UserFromContext() = %v, want %v", got, want)
+	}
+}
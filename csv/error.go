@@ -0,0 +1,51 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/stillwondering/xone"
+)
+
+var (
+	ErrUnknownColumn   = errors.New("unknown column")
+	ErrDuplicateColumn = errors.New("duplicate column")
+	ErrInvalidDate     = errors.New("invalid date")
+)
+
+// ParseError describes a problem with a single value or record of a CSV file.
+// Column and Value are empty if the record as a whole is invalid, e.g. because
+// it has the wrong number of fields.
+type ParseError struct {
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("line %d, column %s: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// RejectedRow is a record which could not be parsed in lenient mode.
+type RejectedRow struct {
+	Line   int
+	Record []string
+	Errors []*ParseError
+}
+
+// Result is the outcome of parsing a CSV file in lenient mode. Lines[i] is the
+// line on which the record of Persons[i] starts.
+type Result struct {
+	Persons  []xone.Person
+	Lines    []int
+	Rejected []RejectedRow
+}
@@ -0,0 +1,108 @@
+package csv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+func TestFormat_Parse_ParseError(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    *ParseError
+		wantMsg string
+	}{
+		{
+			name:    "Invalid date",
+			src:     "first_name,date_of_birth\nHarry,1980-07-31\nRon,01.03.1980\n",
+			want:    &ParseError{Line: 3, Column: "date_of_birth", Value: "01.03.1980"},
+			wantMsg: `line 3, column date_of_birth: invalid date: "01.03.1980" does not match 2006-01-02`,
+		},
+		{
+			name:    "Wrong number of fields",
+			src:     "first_name,last_name\nHarry,Potter,1980-07-31\n",
+			want:    &ParseError{Line: 2},
+			wantMsg: "line 2: record has 3 fields, want 2",
+		},
+		{
+			name:    "Unknown column",
+			src:     "first_name,nickname\nHarry,The Chosen One\n",
+			want:    &ParseError{Line: 1, Column: "nickname"},
+			wantMsg: "line 1, column nickname: unknown column",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DefaultFormat().Parse(strings.NewReader(tt.src))
+
+			var got *ParseError
+			if !errors.As(err, &got) {
+				t.Fatalf("Format.Parse() error = %v, want %T", err, got)
+			}
+			if got.Line != tt.want.Line || got.Column != tt.want.Column || got.Value != tt.want.Value {
+				t.Errorf("Format.Parse() error = %#v, want %#v", got, tt.want)
+			}
+			if got.Error() != tt.wantMsg {
+				t.Errorf("ParseError.Error() = %q, want %q", got.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestFormat_ParseFileLenient(t *testing.T) {
+	got, err := DefaultFormat().ParseFileLenient("testdata/Lenient.csv")
+	if err != nil {
+		t.Fatalf("Format.ParseFileLenient() error = %v", err)
+	}
+
+	wantPersons := []xone.Person{
+		{FirstName: "Harry", LastName: "Potter", DateOfBirth: dateFromString(t, "1980-07-31")},
+		{FirstName: "Neville\nLongbottom", DateOfBirth: dateFromString(t, "1980-07-30")},
+		{FirstName: "Luna", LastName: "Lovegood"},
+	}
+	if !reflect.DeepEqual(got.Persons, wantPersons) {
+		t.Errorf("Format.ParseFileLenient() Persons = %v, want %v", got.Persons, wantPersons)
+	}
+	if wantLines := []int{2, 5, 7}; !reflect.DeepEqual(got.Lines, wantLines) {
+		t.Errorf("Format.ParseFileLenient() Lines = %v, want %v", got.Lines, wantLines)
+	}
+
+	if len(got.Rejected) != 2 {
+		t.Fatalf("Format.ParseFileLenient() rejected %d rows, want %d", len(got.Rejected), 2)
+	}
+	if got.Rejected[0].Line != 3 || got.Rejected[0].Errors[0].Column != "date_of_birth" || !errors.Is(got.Rejected[0].Errors[0], ErrInvalidDate) {
+		t.Errorf("Format.ParseFileLenient() Rejected[0] = %+v", got.Rejected[0])
+	}
+	if got.Rejected[1].Line != 4 || len(got.Rejected[1].Record) != 4 {
+		t.Errorf("Format.ParseFileLenient() Rejected[1] = %+v", got.Rejected[1])
+	}
+}
+
+// TestValidationFields makes sure that the fields named by validation errors
+// can be looked up in the header of a CSV file.
+func TestValidationFields(t *testing.T) {
+	empty := ""
+	future := time.Now().AddDate(1, 0, 0)
+	errs := []error{
+		xone.CreatePersonData{DateOfBirth: future, Email: "harry"}.Validate(),
+		xone.PatchPersonData{FirstName: &empty, LastName: &empty}.Validate(),
+	}
+
+	format := DefaultFormat()
+	for _, err := range errs {
+		var invalid *xone.ValidationError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("Validate() error = %v, want *xone.ValidationError", err)
+		}
+		for _, fe := range invalid.Errors {
+			if _, ok := format.column(fe.Field); !ok {
+				t.Errorf("FieldError.Field = %q, want a CSV column", fe.Field)
+			}
+		}
+	}
+}
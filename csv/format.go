@@ -0,0 +1,480 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+// Column identifies a data point of a person which can be stored in a CSV
+// file.
+type Column string
+
+const (
+	ColumnPID            Column = "pid"
+	ColumnFirstName      Column = "first_name"
+	ColumnLastName       Column = "last_name"
+	ColumnDateOfBirth    Column = "date_of_birth"
+	ColumnEmail          Column = "email"
+	ColumnPhone          Column = "phone"
+	ColumnMobile         Column = "mobile"
+	ColumnStreet         Column = "street"
+	ColumnHouseNumber    Column = "house_number"
+	ColumnZipCode        Column = "zip_code"
+	ColumnCity           Column = "city"
+	ColumnMembershipType Column = "membership_type"
+	ColumnEffectiveFrom  Column = "effective_from"
+)
+
+// fieldPrefix marks the columns which contain the value of a custom field.
+const fieldPrefix = "field:"
+
+// FieldColumn returns the column which contains the value of the custom field
+// with the given name. Its header is the name of the field unless a different
+// name is configured in Format.Names.
+func FieldColumn(name string) Column {
+	return Column(fieldPrefix + name)
+}
+
+// Field returns the name of the custom field whose value is contained in the
+// column. It returns false for all other columns.
+func (c Column) Field() (string, bool) {
+	if !strings.HasPrefix(string(c), fieldPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(string(c), fieldPrefix), true
+}
+
+// AllColumns contains every column in the order they are written by
+// DefaultFormat.
+var AllColumns = []Column{
+	ColumnPID,
+	ColumnFirstName,
+	ColumnLastName,
+	ColumnDateOfBirth,
+	ColumnEmail,
+	ColumnPhone,
+	ColumnMobile,
+	ColumnStreet,
+	ColumnHouseNumber,
+	ColumnZipCode,
+	ColumnCity,
+	ColumnMembershipType,
+	ColumnEffectiveFrom,
+}
+
+// utf8BOM is prepended to CSV files by some spreadsheet applications.
+const utf8BOM = "\ufeff"
+
+// Format describes the layout of a CSV file.
+type Format struct {
+	// Columns determines which columns are written and in which order. When
+	// a file with a header is parsed, the columns are taken from the header
+	// instead. Custom fields are only recognized in the header if their
+	// column is contained, see FieldColumn.
+	Columns []Column
+
+	// Header indicates whether the first record contains the column names.
+	Header bool
+
+	// Names maps columns to the names used in the header, e.g. ColumnFirstName
+	// to "Vorname". Columns without an entry are named after their identifier.
+	// Header names are matched case-insensitively when parsing.
+	Names map[Column]string
+
+	// Comma is the field delimiter, e.g. ';' for files exported by Excel.
+	Comma rune
+
+	// DateFormat is the layout used for all dates, see time.Parse.
+	DateFormat string
+}
+
+// DefaultFormat returns a format which contains every column of a person
+// including their current membership and starts with a header.
+func DefaultFormat() Format {
+	return Format{
+		Columns:    AllColumns,
+		Header:     true,
+		Comma:      ',',
+		DateFormat: xone.FormatDateOfBirth,
+	}
+}
+
+// name returns the header name of a column.
+func (f Format) name(c Column) string {
+	if name, ok := f.Names[c]; ok {
+		return name
+	}
+	if field, ok := c.Field(); ok {
+		return field
+	}
+
+	return string(c)
+}
+
+// column returns the column which is referenced by a header name.
+func (f Format) column(name string) (Column, bool) {
+	name = strings.TrimSpace(name)
+
+	for _, c := range AllColumns {
+		if strings.EqualFold(f.name(c), name) {
+			return c, true
+		}
+	}
+
+	for _, c := range f.Columns {
+		if _, ok := c.Field(); ok && strings.EqualFold(f.name(c), name) {
+			return c, true
+		}
+	}
+
+	return "", false
+}
+
+func (f Format) newReader(src io.Reader) *csv.Reader {
+	reader := csv.NewReader(src)
+	reader.TrimLeadingSpace = true
+	if f.Comma != 0 {
+		reader.Comma = f.Comma
+	}
+
+	return reader
+}
+
+func (f Format) newWriter(dst io.Writer) *csv.Writer {
+	writer := csv.NewWriter(dst)
+	if f.Comma != 0 {
+		writer.Comma = f.Comma
+	}
+
+	return writer
+}
+
+// Write writes the persons to dst.
+func (f Format) Write(dst io.Writer, persons []xone.Person) error {
+	var buf bytes.Buffer
+	writer := f.newWriter(&buf)
+
+	if f.Header {
+		header := make([]string, len(f.Columns))
+		for i, c := range f.Columns {
+			header[i] = f.name(c)
+		}
+
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for _, person := range persons {
+		record := make([]string, len(f.Columns))
+		for i, c := range f.Columns {
+			record[i] = f.value(person, c)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	_, err := io.Copy(dst, &buf)
+
+	return err
+}
+
+// WriteFile writes the persons to the given file.
+func (f Format) WriteFile(file string, persons []xone.Person) error {
+	fh, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	if err := f.Write(fh, persons); err != nil {
+		return err
+	}
+
+	return fh.Close()
+}
+
+// Parse reads all persons from src. It stops at the first invalid value or
+// record and returns a *ParseError describing it.
+func (f Format) Parse(src io.Reader) ([]xone.Person, error) {
+	result, err := f.parse(src, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Persons, nil
+}
+
+// ParseFile reads all persons from the given file, see Parse.
+func (f Format) ParseFile(file string) ([]xone.Person, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	return f.Parse(fh)
+}
+
+// ParseLenient reads all persons from src. Unlike Parse it does not stop at
+// invalid records but collects them, along with every error found in them, in
+// the result. An error is only returned if the file cannot be read any further,
+// e.g. because of an invalid header or malformed quotes.
+func (f Format) ParseLenient(src io.Reader) (Result, error) {
+	return f.parse(src, true)
+}
+
+// ParseFileLenient reads all persons from the given file, see ParseLenient.
+func (f Format) ParseFileLenient(file string) (Result, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return Result{}, err
+	}
+	defer fh.Close()
+
+	return f.ParseLenient(fh)
+}
+
+func (f Format) parse(src io.Reader, lenient bool) (Result, error) {
+	reader := f.newReader(src)
+	// The number of fields is checked below so that a wrong number of fields
+	// can be reported like any other error.
+	reader.FieldsPerRecord = -1
+
+	columns := f.Columns
+	if f.Header {
+		header, err := reader.Read()
+		if err == io.EOF {
+			return Result{}, nil
+		} else if err != nil {
+			return Result{}, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if columns, err = f.parseHeader(header, line); err != nil {
+			return Result{}, err
+		}
+	}
+
+	var result Result
+
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return Result{}, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		person, errs := f.parseRecord(columns, record, line)
+		if len(errs) > 0 {
+			if !lenient {
+				return Result{}, errs[0]
+			}
+
+			result.Rejected = append(result.Rejected, RejectedRow{Line: line, Record: record, Errors: errs})
+			continue
+		}
+
+		result.Persons = append(result.Persons, person)
+		result.Lines = append(result.Lines, line)
+	}
+
+	return result, nil
+}
+
+// parseRecord converts a single record into a person. All errors found in the
+// record are returned.
+func (f Format) parseRecord(columns []Column, record []string, line int) (xone.Person, []*ParseError) {
+	if len(record) != len(columns) {
+		return xone.Person{}, []*ParseError{{
+			Line: line,
+			Err:  fmt.Errorf("record has %d fields, want %d", len(record), len(columns)),
+		}}
+	}
+
+	var person xone.Person
+	var errs []*ParseError
+	for i, c := range columns {
+		if err := f.setValue(&person, c, record[i]); err != nil {
+			errs = append(errs, &ParseError{Line: line, Column: f.name(c), Value: record[i], Err: err})
+		}
+	}
+
+	return person, errs
+}
+
+func (f Format) parseHeader(header []string, line int) ([]Column, error) {
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	}
+
+	columns := make([]Column, len(header))
+	seen := map[Column]bool{}
+	for i, name := range header {
+		c, ok := f.column(name)
+		if !ok {
+			return nil, &ParseError{Line: line, Column: name, Err: ErrUnknownColumn}
+		}
+		if seen[c] {
+			return nil, &ParseError{Line: line, Column: name, Err: ErrDuplicateColumn}
+		}
+
+		columns[i] = c
+		seen[c] = true
+	}
+
+	return columns, nil
+}
+
+// value returns the string representation of a person's data point.
+func (f Format) value(p xone.Person, c Column) string {
+	switch c {
+	case ColumnPID:
+		return p.PID
+	case ColumnFirstName:
+		return p.FirstName
+	case ColumnLastName:
+		return p.LastName
+	case ColumnDateOfBirth:
+		return f.formatDate(p.DateOfBirth)
+	case ColumnEmail:
+		return p.Email
+	case ColumnPhone:
+		return p.Phone
+	case ColumnMobile:
+		return p.Mobile
+	case ColumnStreet:
+		return p.Street
+	case ColumnHouseNumber:
+		return p.HouseNumber
+	case ColumnZipCode:
+		return p.ZipCode
+	case ColumnCity:
+		return p.City
+	case ColumnMembershipType:
+		if m := p.CurrentMembership(); m != nil {
+			return m.Type.Name
+		}
+	case ColumnEffectiveFrom:
+		if m := p.CurrentMembership(); m != nil {
+			return f.formatDate(m.EffectiveFrom)
+		}
+	default:
+		if field, ok := c.Field(); ok {
+			return p.CustomFields[field]
+		}
+	}
+
+	return ""
+}
+
+// setValue parses s and stores it in the person's data point identified by c.
+// The membership columns populate a single membership whose type is only
+// identified by its name. Empty custom fields are left out.
+func (f Format) setValue(p *xone.Person, c Column, s string) (err error) {
+	switch c {
+	case ColumnPID:
+		p.PID = s
+	case ColumnFirstName:
+		p.FirstName = s
+	case ColumnLastName:
+		p.LastName = s
+	case ColumnDateOfBirth:
+		if p.DateOfBirth, err = f.parseDate(s); err != nil {
+			return f.invalidDate(s)
+		}
+	case ColumnEmail:
+		p.Email = s
+	case ColumnPhone:
+		p.Phone = s
+	case ColumnMobile:
+		p.Mobile = s
+	case ColumnStreet:
+		p.Street = s
+	case ColumnHouseNumber:
+		p.HouseNumber = s
+	case ColumnZipCode:
+		p.ZipCode = s
+	case ColumnCity:
+		p.City = s
+	case ColumnMembershipType:
+		if s != "" {
+			membership(p).Type.Name = s
+		}
+	case ColumnEffectiveFrom:
+		effectiveFrom, err := f.parseDate(s)
+		if err != nil {
+			return f.invalidDate(s)
+		}
+		if !effectiveFrom.IsZero() {
+			membership(p).EffectiveFrom = effectiveFrom
+		}
+	default:
+		// The values of custom fields are validated by the repository which
+		// knows their types.
+		if field, ok := c.Field(); ok && s != "" {
+			if p.CustomFields == nil {
+				p.CustomFields = map[string]string{}
+			}
+			p.CustomFields[field] = s
+		}
+	}
+
+	return nil
+}
+
+// membership returns the membership which is populated from a CSV record.
+func membership(p *xone.Person) *xone.Membership {
+	if len(p.Memberships) == 0 {
+		p.Memberships = []xone.Membership{{}}
+	}
+
+	return &p.Memberships[0]
+}
+
+func (f Format) invalidDate(s string) error {
+	return fmt.Errorf("%w: %q does not match %s", ErrInvalidDate, s, f.dateFormat())
+}
+
+func (f Format) dateFormat() string {
+	if f.DateFormat == "" {
+		return xone.FormatDateOfBirth
+	}
+
+	return f.DateFormat
+}
+
+func (f Format) formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(f.dateFormat())
+}
+
+func (f Format) parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(f.dateFormat(), s)
+}
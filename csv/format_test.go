@@ -0,0 +1,211 @@
+package csv
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var excelFormat = Format{
+	Header: true,
+	Names: map[Column]string{
+		ColumnFirstName:      "Vorname",
+		ColumnLastName:       "Nachname",
+		ColumnDateOfBirth:    "Geburtsdatum",
+		ColumnEmail:          "E-Mail",
+		ColumnCity:           "Ort",
+		ColumnMembershipType: "Mitgliedschaft",
+		ColumnEffectiveFrom:  "Mitglied seit",
+	},
+	Comma:      ';',
+	DateFormat: "02.01.2006",
+}
+
+func TestFormat_WriteParse(t *testing.T) {
+	persons := []xone.Person{
+		{
+			PID:         "1",
+			FirstName:   "Harry",
+			LastName:    "Potter",
+			DateOfBirth: dateFromString(t, "1980-07-31"),
+			Email:       "harry.potter@hogwarts.co.uk",
+			Phone:       "0123",
+			Mobile:      "0456",
+			Street:      "Privet Drive",
+			HouseNumber: "4",
+			ZipCode:     "12345",
+			City:        "Little Whinging",
+			Memberships: []xone.Membership{
+				{Type: xone.MembershipType{Name: "active"}, EffectiveFrom: dateFromString(t, "1998-07-31")},
+			},
+			CustomFields: map[string]string{"shirt_size": "M"},
+		},
+		{
+			PID:       "2",
+			FirstName: "Ron",
+			LastName:  "Weasley",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{
+			name:   "Default format",
+			format: DefaultFormat(),
+			want: "pid,first_name,last_name,date_of_birth,email,phone,mobile,street,house_number,zip_code,city,membership_type,effective_from\n" +
+				"1,Harry,Potter,1980-07-31,harry.potter@hogwarts.co.uk,0123,0456,Privet Drive,4,12345,Little Whinging,active,1998-07-31\n" +
+				"2,Ron,Weasley,,,,,,,,,,\n",
+		},
+		{
+			name: "Custom columns, names, delimiter and date format",
+			format: Format{
+				Columns:    []Column{ColumnLastName, ColumnFirstName, ColumnDateOfBirth},
+				Header:     true,
+				Names:      map[Column]string{ColumnLastName: "Nachname", ColumnFirstName: "Vorname"},
+				Comma:      ';',
+				DateFormat: "02.01.2006",
+			},
+			want: "Nachname;Vorname;date_of_birth\nPotter;Harry;31.07.1980\nWeasley;Ron;\n",
+		},
+		{
+			name: "Custom fields",
+			format: Format{
+				Columns:    []Column{ColumnFirstName, ColumnDateOfBirth, FieldColumn("shirt_size"), FieldColumn("license")},
+				Header:     true,
+				Names:      map[Column]string{FieldColumn("license"): "Lizenz"},
+				Comma:      ',',
+				DateFormat: xone.FormatDateOfBirth,
+			},
+			want: "first_name,date_of_birth,shirt_size,Lizenz\nHarry,1980-07-31,M,\nRon,,,\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.format.Write(&buf, persons); err != nil {
+				t.Fatalf("Format.Write() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Format.Write() = %q, want %q", got, tt.want)
+			}
+
+			got, err := tt.format.Parse(strings.NewReader(buf.String()))
+			if err != nil {
+				t.Fatalf("Format.Parse() error = %v", err)
+			}
+			if len(got) != len(persons) {
+				t.Fatalf("Format.Parse() returned %d persons, want %d", len(got), len(persons))
+			}
+			for i := range got {
+				if got[i].FirstName != persons[i].FirstName || !got[i].DateOfBirth.Equal(persons[i].DateOfBirth) {
+					t.Errorf("Format.Parse()[%d] = %v, want %v", i, got[i], persons[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormat_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		src     string
+		want    []xone.Person
+		wantErr bool
+	}{
+		{
+			name:    "Empty file",
+			format:  DefaultFormat(),
+			src:     "",
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:   "Columns in arbitrary order",
+			format: DefaultFormat(),
+			src:    "Last_Name, first_name\nPotter,Harry\n",
+			want: []xone.Person{
+				{FirstName: "Harry", LastName: "Potter"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Unknown column",
+			format:  DefaultFormat(),
+			src:     "first_name,nickname\nHarry,The Chosen One\n",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:   "Custom fields",
+			format: Format{Columns: []Column{FieldColumn("shirt_size"), FieldColumn("license")}, Header: true},
+			src:    "first_name,Shirt_Size,license\nHarry,M,\n",
+			want: []xone.Person{
+				{FirstName: "Harry", CustomFields: map[string]string{"shirt_size": "M"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Duplicate column",
+			format:  DefaultFormat(),
+			src:     "first_name,first_name\nHarry,Harry\n",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid effective from date",
+			format:  DefaultFormat(),
+			src:     "first_name,membership_type,effective_from\nHarry,active,31.07.1998\n",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format.Parse(strings.NewReader(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Format.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Format.Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_ParseFile(t *testing.T) {
+	got, err := excelFormat.ParseFile("testdata/Excel.csv")
+	if err != nil {
+		t.Fatalf("Format.ParseFile() error = %v", err)
+	}
+
+	want := []xone.Person{
+		{
+			FirstName:   "Harry",
+			LastName:    "Potter",
+			DateOfBirth: time.Date(1980, time.July, 31, 0, 0, 0, 0, time.UTC),
+			Email:       "harry.potter@hogwarts.co.uk",
+			City:        "Little Whinging",
+			Memberships: []xone.Membership{
+				{Type: xone.MembershipType{Name: "active"}, EffectiveFrom: time.Date(1998, time.July, 31, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			FirstName: "Ron",
+			LastName:  "Weasley",
+			Email:     "ron.weasley@hogwarts.co.uk",
+			City:      "Ottery St Catchpole",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Format.ParseFile() = %v, want %v", got, want)
+	}
+}
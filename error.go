@@ -2,8 +2,75 @@ package xone
 
 import (
 	"fmt"
+	"time"
 )
 
+// ErrNotFound is returned if an operation refers to an entity which does not
+// exist, e.g. a person who is updated by an unknown PID.
+type ErrNotFound struct {
+	Entity string
+	ID     string
+}
+
+func (e *ErrNotFound) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Entity, e.ID)
+}
+
+// ErrConflict is returned if an operation conflicts with the stored data, e.g.
+// because a name which has to be unique is already taken. The more specific
+// conflicts, like ErrUserExists, can be matched as an ErrConflict by
+// errors.As as well.
+type ErrConflict struct {
+	Msg string
+
+	// Err is the cause of the conflict reported by the storage, if any.
+	Err error
+}
+
+func (e *ErrConflict) Error() string {
+	return e.Msg
+}
+
+func (e *ErrConflict) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalid is returned if an operation is rejected because of invalid
+// input, e.g. a reference to an unknown entity or a value outside its range.
+// The more specific errors, like ErrInvalidIBAN or ValidationError, can be
+// matched as an ErrInvalid by errors.As as well.
+type ErrInvalid struct {
+	Msg string
+
+	// Err is the cause of the error reported by the storage, if any.
+	Err error
+}
+
+func (e *ErrInvalid) Error() string {
+	return e.Msg
+}
+
+func (e *ErrInvalid) Unwrap() error {
+	return e.Err
+}
+
+// ErrStaleVersion is returned if an update is based on an outdated version of
+// an entity because someone else has changed it in the meantime. The caller
+// should reload the entity and apply their changes again.
+type ErrStaleVersion struct {
+	Entity  string
+	ID      string
+	Version int
+}
+
+func (e *ErrStaleVersion) Error() string {
+	return fmt.Sprintf("%s %s has been changed since version %d", e.Entity, e.ID, e.Version)
+}
+
+func (e *ErrStaleVersion) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
 type ErrUserExists struct {
 	Data CreateUserData
 }
@@ -11,3 +78,292 @@ type ErrUserExists struct {
 func (e *ErrUserExists) Error() string {
 	return fmt.Sprintf(`user with email "%s" already exists`, e.Data.Email)
 }
+
+func (e *ErrUserExists) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
+// ErrInvalidCredentials is returned if a user cannot be authenticated. It
+// deliberately does not tell whether the email or the password was wrong.
+type ErrInvalidCredentials struct {
+	Email string
+}
+
+func (e *ErrInvalidCredentials) Error() string {
+	return "invalid email or password"
+}
+
+type ErrMembershipTypeExists struct {
+	Name string
+}
+
+func (e *ErrMembershipTypeExists) Error() string {
+	return fmt.Sprintf(`membership type "%s" already exists`, e.Name)
+}
+
+func (e *ErrMembershipTypeExists) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
+// ErrForbidden is returned if the current user lacks the permission to perform
+// an operation.
+type ErrForbidden struct {
+	Permission Permission
+}
+
+func (e *ErrForbidden) Error() string {
+	return fmt.Sprintf(`permission "%s" required`, e.Permission)
+}
+
+type ErrInvalidRole struct {
+	Role Role
+}
+
+func (e *ErrInvalidRole) Error() string {
+	return fmt.Sprintf(`"%s" is not a valid role`, e.Role)
+}
+
+func (e *ErrInvalidRole) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrInvalidTerminationReason is returned if a membership is terminated for an
+// unknown reason.
+type ErrInvalidTerminationReason struct {
+	Reason TerminationReason
+}
+
+func (e *ErrInvalidTerminationReason) Error() string {
+	return fmt.Sprintf(`"%s" is not a valid termination reason`, e.Reason)
+}
+
+func (e *ErrInvalidTerminationReason) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrInvalidBillingPeriod is returned if a fee is created with an unknown
+// billing period.
+type ErrInvalidBillingPeriod struct {
+	Period BillingPeriod
+}
+
+func (e *ErrInvalidBillingPeriod) Error() string {
+	return fmt.Sprintf(`"%s" is not a valid billing period`, e.Period)
+}
+
+func (e *ErrInvalidBillingPeriod) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrOverlappingFee is returned if a fee would be valid on the same day as
+// another fee of the same membership type.
+type ErrOverlappingFee struct {
+	Fee Fee
+}
+
+func (e *ErrOverlappingFee) Error() string {
+	return fmt.Sprintf("fee overlaps with the fee valid from %s", e.Fee.ValidFrom.Format(FormatDateOfBirth))
+}
+
+func (e *ErrOverlappingFee) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
+// ErrInvalidPaymentMethod is returned if a payment is recorded with an
+// unknown payment method.
+type ErrInvalidPaymentMethod struct {
+	Method PaymentMethod
+}
+
+func (e *ErrInvalidPaymentMethod) Error() string {
+	return fmt.Sprintf(`"%s" is not a valid payment method`, e.Method)
+}
+
+func (e *ErrInvalidPaymentMethod) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrOpenBalance is returned if a person who still owes money or has a credit
+// is about to be deleted.
+type ErrOpenBalance struct {
+	PID     string
+	Balance int64
+}
+
+func (e *ErrOpenBalance) Error() string {
+	return fmt.Sprintf("person %s has an open balance of %s", e.PID, FormatAmount(e.Balance))
+}
+
+func (e *ErrOpenBalance) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
+// ErrInvalidIBAN is returned if a mandate is saved with an IBAN whose length
+// or checksum is wrong.
+type ErrInvalidIBAN struct {
+	IBAN string
+}
+
+func (e *ErrInvalidIBAN) Error() string {
+	return fmt.Sprintf(`"%s" is not a valid IBAN`, e.IBAN)
+}
+
+func (e *ErrInvalidIBAN) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrHouseholdMember is returned if a person who already belongs to a
+// household is added to another one.
+type ErrHouseholdMember struct {
+	PID         string
+	HouseholdID int
+}
+
+func (e *ErrHouseholdMember) Error() string {
+	return fmt.Sprintf("person %s already belongs to household %d", e.PID, e.HouseholdID)
+}
+
+func (e *ErrHouseholdMember) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
+// ErrNotHouseholdMember is returned if a person who does not belong to a
+// household is made its primary contact or removed from it.
+type ErrNotHouseholdMember struct {
+	PID         string
+	HouseholdID int
+}
+
+func (e *ErrNotHouseholdMember) Error() string {
+	return fmt.Sprintf("person %s does not belong to household %d", e.PID, e.HouseholdID)
+}
+
+func (e *ErrNotHouseholdMember) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrPrimaryContact is returned if the primary contact of a household is
+// removed from it. Another member has to become the primary contact first.
+type ErrPrimaryContact struct {
+	PID string
+}
+
+func (e *ErrPrimaryContact) Error() string {
+	return fmt.Sprintf("person %s is the primary contact of their household", e.PID)
+}
+
+func (e *ErrPrimaryContact) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
+// ErrDuplicatePerson is returned if a person who is about to be imported
+// already exists.
+type ErrDuplicatePerson struct {
+	FirstName   string
+	LastName    string
+	DateOfBirth time.Time
+}
+
+func (e *ErrDuplicatePerson) Error() string {
+	if e.DateOfBirth.IsZero() {
+		return fmt.Sprintf("%s %s already exists", e.FirstName, e.LastName)
+	}
+
+	return fmt.Sprintf("%s %s born on %s already exists", e.FirstName, e.LastName, e.DateOfBirth.Format(FormatDateOfBirth))
+}
+
+func (e *ErrDuplicatePerson) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
+type ErrUnknownMembershipType struct {
+	Name string
+}
+
+func (e *ErrUnknownMembershipType) Error() string {
+	return fmt.Sprintf(`unknown membership type "%s"`, e.Name)
+}
+
+func (e *ErrUnknownMembershipType) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrInvalidInvoiceKind is returned if a document of an unknown kind is
+// about to be issued.
+type ErrInvalidInvoiceKind struct {
+	Kind InvoiceKind
+}
+
+func (e *ErrInvalidInvoiceKind) Error() string {
+	return fmt.Sprintf(`"%s" is not a valid kind of invoice`, e.Kind)
+}
+
+func (e *ErrInvalidInvoiceKind) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrInvalidFieldType is returned if a custom field is defined with an
+// unknown type.
+type ErrInvalidFieldType struct {
+	Type FieldType
+}
+
+func (e *ErrInvalidFieldType) Error() string {
+	return fmt.Sprintf(`"%s" is not a valid field type`, e.Type)
+}
+
+func (e *ErrInvalidFieldType) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+type ErrFieldExists struct {
+	Name string
+}
+
+func (e *ErrFieldExists) Error() string {
+	return fmt.Sprintf(`custom field "%s" already exists`, e.Name)
+}
+
+func (e *ErrFieldExists) Unwrap() error {
+	return &ErrConflict{Msg: e.Error()}
+}
+
+type ErrUnknownField struct {
+	Name string
+}
+
+func (e *ErrUnknownField) Error() string {
+	return fmt.Sprintf(`unknown custom field "%s"`, e.Name)
+}
+
+func (e *ErrUnknownField) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrInvalidFieldValue is returned if the value of a custom field does not
+// match the field's type.
+type ErrInvalidFieldValue struct {
+	Field string
+	Value string
+}
+
+func (e *ErrInvalidFieldValue) Error() string {
+	return fmt.Sprintf(`"%s" is not a valid value for custom field "%s"`, e.Value, e.Field)
+}
+
+func (e *ErrInvalidFieldValue) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// ErrFieldRequired is returned if a required custom field has no value.
+type ErrFieldRequired struct {
+	Field string
+}
+
+func (e *ErrFieldRequired) Error() string {
+	return fmt.Sprintf(`custom field "%s" required`, e.Field)
+}
+
+func (e *ErrFieldRequired) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
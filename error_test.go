@@ -0,0 +1,28 @@
+package xone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want interface{}
+	}{
+		{name: "User exists", err: &ErrUserExists{Data: CreateUserData{Email: "harry@hogwarts.co.uk"}}, want: new(*ErrConflict)},
+		{name: "Stale version", err: &ErrStaleVersion{Entity: "person", ID: "hp", Version: 1}, want: new(*ErrConflict)},
+		{name: "Open balance", err: &ErrOpenBalance{PID: "hp", Balance: 1000}, want: new(*ErrConflict)},
+		{name: "Invalid role", err: &ErrInvalidRole{Role: "wizard"}, want: new(*ErrInvalid)},
+		{name: "Unknown field", err: &ErrUnknownField{Name: "diet"}, want: new(*ErrInvalid)},
+		{name: "Validation", err: &ValidationError{Errors: []FieldError{{Field: "email", Code: ValidationFormat}}}, want: new(*ErrInvalid)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.As(tt.err, tt.want) {
+				t.Errorf("errors.As(%v) = false, want %T", tt.err, tt.want)
+			}
+		})
+	}
+}
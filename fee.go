@@ -0,0 +1,225 @@
+package xone
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// FeeService manages the fees charged for membership types and calculates
+// the dues of persons.
+type FeeService interface {
+	// FindFees returns all fees of the membership type with the given ID,
+	// ordered by the date they become valid.
+	FindFees(context.Context, int) ([]Fee, error)
+
+	// CreateFee adds a fee to a membership type. Fees of the same membership
+	// type must not overlap.
+	CreateFee(context.Context, CreateFeeData) (Fee, error)
+
+	DeleteFee(context.Context, int) error
+
+	// CalculateDues returns what the person with the given PID owes for the
+	// given range of dates, both inclusive.
+	CalculateDues(ctx context.Context, pid string, from, until time.Time) (Dues, error)
+}
+
+// BillingPeriod determines how often a fee is charged.
+type BillingPeriod string
+
+const (
+	BillingAnnual    BillingPeriod = "annual"
+	BillingQuarterly BillingPeriod = "quarterly"
+	BillingMonthly   BillingPeriod = "monthly"
+)
+
+// Valid reports whether p is one of the known billing periods.
+func (p BillingPeriod) Valid() bool {
+	switch p {
+	case BillingAnnual, BillingQuarterly, BillingMonthly:
+		return true
+	}
+
+	return false
+}
+
+// Bounds returns the first and the last day of the calendar year, quarter or
+// month which contains the given day.
+func (p BillingPeriod) Bounds(day time.Time) (time.Time, time.Time) {
+	y, m, _ := day.Date()
+
+	switch p {
+	case BillingQuarterly:
+		first := time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, time.UTC)
+		return first, first.AddDate(0, 3, -1)
+	case BillingMonthly:
+		first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+		return first, first.AddDate(0, 1, -1)
+	default:
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(y, time.December, 31, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Fee is the amount charged for a membership type per billing period during
+// a range of dates.
+type Fee struct {
+	ID               int
+	MembershipTypeID int
+
+	// Amount is given in cents.
+	Amount int64
+	Period BillingPeriod
+
+	// ValidFrom and ValidUntil are the first and the last day the fee is
+	// charged for. A zero ValidUntil means the fee is valid indefinitely.
+	ValidFrom  time.Time
+	ValidUntil time.Time
+}
+
+// Overlaps reports whether both fees are valid on at least one common day.
+func (f Fee) Overlaps(other Fee) bool {
+	if !f.ValidUntil.IsZero() && dateOf(f.ValidUntil).Before(dateOf(other.ValidFrom)) {
+		return false
+	}
+	if !other.ValidUntil.IsZero() && dateOf(other.ValidUntil).Before(dateOf(f.ValidFrom)) {
+		return false
+	}
+
+	return true
+}
+
+// CreateFeeData contains the data needed to create a new fee.
+type CreateFeeData struct {
+	MembershipTypeID int
+	Amount           int64
+	Period           BillingPeriod
+	ValidFrom        time.Time
+	ValidUntil       time.Time
+}
+
+// Dues is what a person owes for a range of dates.
+type Dues struct {
+	From  time.Time
+	Until time.Time
+
+	// Total is the sum of all items in cents.
+	Total int64
+	Items []DuesItem
+}
+
+// DuesItem is the part of the dues which is charged for a single membership
+// at a single fee.
+type DuesItem struct {
+	MembershipID   int
+	MembershipType string
+	Fee            Fee
+
+	// From and Until are the first and the last day covered by the item.
+	From  time.Time
+	Until time.Time
+
+	// Amount is given in cents.
+	Amount int64
+}
+
+// CalculateDues calculates what is owed for the given memberships of a person
+// between from and until, both inclusive. Each membership lasts until the
+// next one becomes effective or until it ends. Fees are charged per billing
+// period; periods which are only partly covered, e.g. because the membership
+// type changes mid-year, are pro-rated by day. Days without a valid fee are
+// free of charge. Each fee must be passed only once, even if several
+// memberships are of its type.
+func CalculateDues(memberships []Membership, fees []Fee, from, until time.Time) Dues {
+	from, until = dateOf(from), dateOf(until)
+	dues := Dues{From: from, Until: until}
+
+	// Memberships without an effective date are the oldest ones, just like in
+	// Person.Membership.
+	sorted := make([]Membership, len(memberships))
+	copy(sorted, memberships)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EffectiveFrom.Before(sorted[j].EffectiveFrom)
+	})
+
+	for i, m := range sorted {
+		start, end := from, until
+		if !m.EffectiveFrom.IsZero() && dateOf(m.EffectiveFrom).After(start) {
+			start = dateOf(m.EffectiveFrom)
+		}
+		if i+1 < len(sorted) && !sorted[i+1].EffectiveFrom.IsZero() {
+			if next := dateOf(sorted[i+1].EffectiveFrom).AddDate(0, 0, -1); next.Before(end) {
+				end = next
+			}
+		}
+		if m.Terminated() && dateOf(m.EndDate).Before(end) {
+			end = dateOf(m.EndDate)
+		}
+
+		for _, f := range fees {
+			if f.MembershipTypeID != m.Type.ID {
+				continue
+			}
+
+			s, e := start, end
+			if dateOf(f.ValidFrom).After(s) {
+				s = dateOf(f.ValidFrom)
+			}
+			if !f.ValidUntil.IsZero() && dateOf(f.ValidUntil).Before(e) {
+				e = dateOf(f.ValidUntil)
+			}
+			if s.After(e) {
+				continue
+			}
+
+			item := DuesItem{
+				MembershipID:   m.ID,
+				MembershipType: m.Type.Name,
+				Fee:            f,
+				From:           s,
+				Until:          e,
+				Amount:         prorate(f, s, e),
+			}
+			dues.Items = append(dues.Items, item)
+			dues.Total += item.Amount
+		}
+	}
+
+	sort.SliceStable(dues.Items, func(i, j int) bool {
+		return dues.Items[i].From.Before(dues.Items[j].From)
+	})
+
+	return dues
+}
+
+// prorate returns the amount charged at the given fee for the days between
+// from and until. Every billing period is charged in full if it is covered
+// completely and by day otherwise, rounded to the nearest cent.
+func prorate(f Fee, from, until time.Time) int64 {
+	var amount int64
+	for day := from; !day.After(until); {
+		first, last := f.Period.Bounds(day)
+		end := last
+		if until.Before(end) {
+			end = until
+		}
+
+		covered := days(day, end)
+		total := days(first, last)
+		amount += (f.Amount*covered + total/2) / total
+
+		day = end.AddDate(0, 0, 1)
+	}
+
+	return amount
+}
+
+// days returns the number of days between from and until, both inclusive.
+func days(from, until time.Time) int64 {
+	return int64(until.Sub(from).Hours()/24) + 1
+}
+
+// dateOf strips the time of day and the location from t.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,134 @@
+package xone
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateDues(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	adult := MembershipType{ID: 1, Name: "adult"}
+	youth := MembershipType{ID: 2, Name: "youth"}
+	honorary := MembershipType{ID: 3, Name: "honorary"}
+	fees := []Fee{
+		{ID: 1, MembershipTypeID: adult.ID, Amount: 12000, Period: BillingAnnual, ValidFrom: date(2020, time.January, 1)},
+		{ID: 2, MembershipTypeID: youth.ID, Amount: 1500, Period: BillingQuarterly, ValidFrom: date(2020, time.January, 1), ValidUntil: date(2022, time.June, 30)},
+		{ID: 3, MembershipTypeID: youth.ID, Amount: 2000, Period: BillingQuarterly, ValidFrom: date(2022, time.July, 1)},
+	}
+
+	tests := []struct {
+		name        string
+		memberships []Membership
+		wantTotal   int64
+		wantItems   int
+	}{
+		{
+			name:        "Full year",
+			memberships: []Membership{{ID: 1, Type: adult, EffectiveFrom: date(2021, time.May, 1)}},
+			wantTotal:   12000,
+			wantItems:   1,
+		},
+		{
+			name:        "Fee changes mid-year",
+			memberships: []Membership{{ID: 1, Type: youth}},
+			wantTotal:   1500 + 1500 + 2000 + 2000,
+			wantItems:   2,
+		},
+		{
+			name: "Membership type changes mid-year",
+			memberships: []Membership{
+				{ID: 2, Type: adult, EffectiveFrom: date(2022, time.July, 1)},
+				{ID: 1, Type: youth, EffectiveFrom: date(2015, time.March, 1)},
+			},
+			wantTotal: 1500 + 1500 + 6049,
+			wantItems: 2,
+		},
+		{
+			name:        "Membership ends",
+			memberships: []Membership{{ID: 1, Type: adult, EndDate: date(2022, time.March, 31), TerminationReason: TerminationResignation}},
+			wantTotal:   2959,
+			wantItems:   1,
+		},
+		{
+			name:        "Membership starts",
+			memberships: []Membership{{ID: 1, Type: youth, EffectiveFrom: date(2022, time.December, 1)}},
+			wantTotal:   674,
+			wantItems:   1,
+		},
+		{
+			name:        "No fee",
+			memberships: []Membership{{ID: 1, Type: honorary}},
+		},
+		{
+			name: "No membership",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateDues(tt.memberships, fees, date(2022, time.January, 1), date(2022, time.December, 31))
+			if got.Total != tt.wantTotal {
+				t.Errorf("CalculateDues() total = %v, want %v", got.Total, tt.wantTotal)
+			}
+			if len(got.Items) != tt.wantItems {
+				t.Errorf("CalculateDues() items = %v, want %v items", got.Items, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestBillingPeriod_Bounds(t *testing.T) {
+	day := time.Date(2024, time.February, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period    BillingPeriod
+		wantFirst string
+		wantLast  string
+	}{
+		{period: BillingAnnual, wantFirst: "2024-01-01", wantLast: "2024-12-31"},
+		{period: BillingQuarterly, wantFirst: "2024-01-01", wantLast: "2024-03-31"},
+		{period: BillingMonthly, wantFirst: "2024-02-01", wantLast: "2024-02-29"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			first, last := tt.period.Bounds(day)
+			if got := first.Format(FormatDateOfBirth); got != tt.wantFirst {
+				t.Errorf("Bounds() first = %v, want %v", got, tt.wantFirst)
+			}
+			if got := last.Format(FormatDateOfBirth); got != tt.wantLast {
+				t.Errorf("Bounds() last = %v, want %v", got, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{s: "120", want: 12000},
+		{s: "12.5", want: 1250},
+		{s: "12,50", want: 1250},
+		{s: "-0.99", want: -99},
+		{s: "1.234", wantErr: true},
+		{s: "abc", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseAmount(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAmount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAmount() = %v, want %v", got, tt.want)
+			}
+			if back, _ := ParseAmount(FormatAmount(got)); back != got {
+				t.Errorf("ParseAmount(FormatAmount(%v)) = %v", got, back)
+			}
+		})
+	}
+}
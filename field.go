@@ -0,0 +1,158 @@
+package xone
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldService manages the custom fields which are stored along with every
+// person in addition to their regular data, e.g. a shirt size or a license
+// number. The values themselves are read and written through the
+// PersonRepository.
+type FieldService interface {
+	// FindFieldDefinitions returns all custom fields ordered by name.
+	FindFieldDefinitions(context.Context) ([]FieldDefinition, error)
+
+	CreateFieldDefinition(context.Context, CreateFieldDefinitionData) (FieldDefinition, error)
+
+	// DeleteFieldDefinition removes a custom field along with the values of
+	// all persons.
+	DeleteFieldDefinition(context.Context, int) error
+}
+
+// FieldType determines which values a custom field accepts.
+type FieldType string
+
+const (
+	FieldText   FieldType = "text"
+	FieldNumber FieldType = "number"
+	FieldDate   FieldType = "date"
+	FieldBool   FieldType = "bool"
+	FieldEnum   FieldType = "enum"
+)
+
+// Valid reports whether t is one of the known field types.
+func (t FieldType) Valid() bool {
+	switch t {
+	case FieldText, FieldNumber, FieldDate, FieldBool, FieldEnum:
+		return true
+	}
+
+	return false
+}
+
+// FieldDefinition describes a custom field.
+type FieldDefinition struct {
+	ID   int
+	Name string
+	Type FieldType
+
+	// Required fields must have a value for every person who is created or
+	// updated.
+	Required bool
+
+	// Options contains the values an enum field accepts. It is empty for all
+	// other types.
+	Options []string
+}
+
+// Normalize checks whether value is valid for the field and returns its
+// canonical form: numbers without superfluous zeros, dates as YYYY-MM-DD,
+// booleans as "true" or "false" and enum values spelled like their option.
+// Surrounding whitespace is removed. An empty value means that the field is not
+// set and is always valid.
+func (d FieldDefinition) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	invalid := &ErrInvalidFieldValue{Field: d.Name, Value: value}
+
+	switch d.Type {
+	case FieldNumber:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", invalid
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case FieldDate:
+		t, err := time.Parse(FormatDateOfBirth, value)
+		if err != nil {
+			return "", invalid
+		}
+		return t.Format(FormatDateOfBirth), nil
+	case FieldBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", invalid
+		}
+		return strconv.FormatBool(b), nil
+	case FieldEnum:
+		for _, option := range d.Options {
+			if strings.EqualFold(option, value) {
+				return option, nil
+			}
+		}
+		return "", invalid
+	}
+
+	return value, nil
+}
+
+// CreateFieldDefinitionData contains the data needed to define a custom
+// field.
+type CreateFieldDefinitionData struct {
+	Name     string
+	Type     FieldType
+	Required bool
+	Options  []string
+}
+
+// ApplyFieldValues validates the changed values of a person's custom fields
+// against the definitions and merges them into the current values. Changes
+// with an empty value remove the value. The merged values are returned in
+// their canonical form, see FieldDefinition.Normalize. It fails if a change
+// refers to an unknown field, if a value does not match its field's type or if
+// a required field has no value afterwards.
+func ApplyFieldValues(defs []FieldDefinition, current, changes map[string]string) (map[string]string, error) {
+	byName := make(map[string]FieldDefinition, len(defs))
+	for _, d := range defs {
+		byName[d.Name] = d
+	}
+
+	values := map[string]string{}
+	for name, value := range current {
+		if _, ok := byName[name]; ok {
+			values[name] = value
+		}
+	}
+
+	for name, value := range changes {
+		d, ok := byName[name]
+		if !ok {
+			return nil, &ErrUnknownField{Name: name}
+		}
+
+		value, err := d.Normalize(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if value == "" {
+			delete(values, name)
+		} else {
+			values[name] = value
+		}
+	}
+
+	for _, d := range defs {
+		if d.Required && values[d.Name] == "" {
+			return nil, &ErrFieldRequired{Field: d.Name}
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,104 @@
+package xone
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFieldDefinition_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     FieldDefinition
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Text", def: FieldDefinition{Type: FieldText}, value: " vegan ", want: "vegan"},
+		{name: "Empty", def: FieldDefinition{Type: FieldNumber}, value: " ", want: ""},
+		{name: "Number", def: FieldDefinition{Type: FieldNumber}, value: "42.50", want: "42.5"},
+		{name: "Invalid number", def: FieldDefinition{Type: FieldNumber}, value: "42,5", wantErr: true},
+		{name: "Date", def: FieldDefinition{Type: FieldDate}, value: "2022-03-01", want: "2022-03-01"},
+		{name: "Invalid date", def: FieldDefinition{Type: FieldDate}, value: "01.03.2022", wantErr: true},
+		{name: "Bool", def: FieldDefinition{Type: FieldBool}, value: "1", want: "true"},
+		{name: "Invalid bool", def: FieldDefinition{Type: FieldBool}, value: "yes", wantErr: true},
+		{name: "Enum", def: FieldDefinition{Type: FieldEnum, Options: []string{"S", "M", "L"}}, value: "m", want: "M"},
+		{name: "Invalid enum", def: FieldDefinition{Type: FieldEnum, Options: []string{"S", "M", "L"}}, value: "XL", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.def.Normalize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FieldDefinition.Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FieldDefinition.Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyFieldValues(t *testing.T) {
+	defs := []FieldDefinition{
+		{Name: "shirt_size", Type: FieldEnum, Required: true, Options: []string{"S", "M", "L"}},
+		{Name: "license", Type: FieldNumber},
+	}
+
+	tests := []struct {
+		name    string
+		current map[string]string
+		changes map[string]string
+		want    map[string]string
+		wantErr interface{}
+	}{
+		{
+			name:    "New values",
+			changes: map[string]string{"shirt_size": "l", "license": "007"},
+			want:    map[string]string{"shirt_size": "L", "license": "7"},
+		},
+		{
+			name:    "Merge and remove",
+			current: map[string]string{"shirt_size": "S", "license": "7", "deleted": "x"},
+			changes: map[string]string{"license": ""},
+			want:    map[string]string{"shirt_size": "S"},
+		},
+		{
+			name:    "Unknown field",
+			changes: map[string]string{"shirt_size": "S", "diet": "vegan"},
+			wantErr: new(*ErrUnknownField),
+		},
+		{
+			name:    "Invalid value",
+			changes: map[string]string{"shirt_size": "XL"},
+			wantErr: new(*ErrInvalidFieldValue),
+		},
+		{
+			name:    "Required field missing",
+			changes: map[string]string{"license": "7"},
+			wantErr: new(*ErrFieldRequired),
+		},
+		{
+			name:    "Required field removed",
+			current: map[string]string{"shirt_size": "S"},
+			changes: map[string]string{"shirt_size": ""},
+			wantErr: new(*ErrFieldRequired),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyFieldValues(defs, tt.current, tt.changes)
+			if tt.wantErr != nil {
+				if !errors.As(err, tt.wantErr) {
+					t.Fatalf("ApplyFieldValues() error = %v, want %T", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyFieldValues() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyFieldValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package xone
+
+import "time"
+
+// PersonSortField identifies the data point persons are sorted by.
+type PersonSortField string
+
+const (
+	SortByID            PersonSortField = "id"
+	SortByFirstName     PersonSortField = "first_name"
+	SortByLastName      PersonSortField = "last_name"
+	SortByDateOfBirth   PersonSortField = "date_of_birth"
+	SortByEmail         PersonSortField = "email"
+	SortByPhone         PersonSortField = "phone"
+	SortByMobile        PersonSortField = "mobile"
+	SortByStreet        PersonSortField = "street"
+	SortByHouseNumber   PersonSortField = "house_number"
+	SortByZipCode       PersonSortField = "zip_code"
+	SortByCity          PersonSortField = "city"
+	SortByEffectiveFrom PersonSortField = "effective_from"
+)
+
+// Valid reports whether persons can be sorted by the field. The empty field
+// is valid and sorts persons in the order they were created.
+func (f PersonSortField) Valid() bool {
+	switch f {
+	case "", SortByID, SortByFirstName, SortByLastName, SortByDateOfBirth, SortByEmail, SortByPhone, SortByMobile, SortByStreet, SortByHouseNumber, SortByZipCode, SortByCity, SortByEffectiveFrom:
+		return true
+	}
+
+	return false
+}
+
+// PersonFilter describes which persons are returned by
+// PersonRepository.FindMany and in which order. The zero value matches every
+// person.
+type PersonFilter struct {
+	// Search matches persons whose first name, last name, email address or
+	// city contains it, ignoring case.
+	Search string
+
+	// MembershipTypeID matches persons whose current membership is of the
+	// given type, see Person.Membership.
+	MembershipTypeID int
+
+	// MinAge and MaxAge match persons of at least and at most the given age.
+	// Persons without a date of birth never match an age restriction. Zero
+	// means no restriction.
+	MinAge int
+	MaxAge int
+
+	// EffectiveFrom and EffectiveUntil match persons whose current membership
+	// became effective on or after and on or before the given dates.
+	EffectiveFrom  time.Time
+	EffectiveUntil time.Time
+
+	// Archived returns deleted persons instead of the active ones.
+	Archived bool
+
+	// Today is the date which the current membership and the age of a person
+	// are determined for. The zero value means the current date.
+	Today time.Time
+
+	SortBy     PersonSortField
+	Descending bool
+
+	// Limit restricts the number of returned persons, zero means no limit.
+	// Offset skips the given number of persons.
+	Limit  int
+	Offset int
+}
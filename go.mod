@@ -1,11 +1,17 @@
 module github.com/stillwondering/xone
 
-go 1.17
+go 1.18
 
 require (
+	github.com/fergusstrange/embedded-postgres v1.25.0
+	github.com/lib/pq v1.10.4
 	github.com/mattn/go-sqlite3 v1.14.10
 	github.com/satori/go.uuid v1.2.0
 	golang.org/x/crypto v0.0.0-20220131195533-30dcbda58838
+	golang.org/x/text v0.13.0
 )
 
-require gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+require (
+	github.com/xi2/xz v0.0.0-20171230120015-48954b6210f8 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+)
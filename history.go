@@ -0,0 +1,97 @@
+package xone
+
+import (
+	"context"
+	"time"
+)
+
+// HistoryService provides access to all previous versions of persons and their
+// memberships.
+type HistoryService interface {
+	// Timeline returns every change of the person with the given PID and
+	// their memberships, the oldest change first.
+	Timeline(context.Context, string) ([]PersonChange, error)
+
+	// PersonAt returns the person with the given PID as they were at the
+	// given point in time. The returned bool is false if the person did not
+	// exist yet.
+	PersonAt(context.Context, string, time.Time) (Person, bool, error)
+}
+
+// PersonChange describes a single change of a person or one of their
+// memberships.
+type PersonChange struct {
+	Time time.Time
+
+	// MembershipID is set if the change affects a membership instead of the
+	// person's data.
+	MembershipID int
+
+	// Created indicates that the person or the membership was created. In
+	// that case Fields contains all data points which were set initially.
+	Created bool
+
+	Fields []FieldChange
+}
+
+// FieldChange describes the change of a single data point. Values are
+// formatted as strings, dates in FormatDateOfBirth. Fields are named like the
+// respective PersonSortField, membership fields are named "membership_type",
+// "effective_from", "end_date" and "termination_reason".
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// DiffPersons returns the data points which differ between two versions of a
+// person. Memberships are not compared, see DiffMemberships.
+func DiffPersons(old, new Person) []FieldChange {
+	var changes []FieldChange
+	diff := func(field PersonSortField, o, n string) {
+		if o != n {
+			changes = append(changes, FieldChange{Field: string(field), Old: o, New: n})
+		}
+	}
+
+	diff(SortByFirstName, old.FirstName, new.FirstName)
+	diff(SortByLastName, old.LastName, new.LastName)
+	diff(SortByDateOfBirth, formatDate(old.DateOfBirth), formatDate(new.DateOfBirth))
+	diff(SortByEmail, old.Email, new.Email)
+	diff(SortByPhone, old.Phone, new.Phone)
+	diff(SortByMobile, old.Mobile, new.Mobile)
+	diff(SortByStreet, old.Street, new.Street)
+	diff(SortByHouseNumber, old.HouseNumber, new.HouseNumber)
+	diff(SortByZipCode, old.ZipCode, new.ZipCode)
+	diff(SortByCity, old.City, new.City)
+
+	return changes
+}
+
+// DiffMemberships returns the data points which differ between two versions
+// of a membership. Membership types are compared by name.
+func DiffMemberships(old, new Membership) []FieldChange {
+	var changes []FieldChange
+	if old.Type.Name != new.Type.Name {
+		changes = append(changes, FieldChange{Field: "membership_type", Old: old.Type.Name, New: new.Type.Name})
+	}
+	if o, n := formatDate(old.EffectiveFrom), formatDate(new.EffectiveFrom); o != n {
+		changes = append(changes, FieldChange{Field: "effective_from", Old: o, New: n})
+	}
+	if o, n := formatDate(old.EndDate), formatDate(new.EndDate); o != n {
+		changes = append(changes, FieldChange{Field: "end_date", Old: o, New: n})
+	}
+	if old.TerminationReason != new.TerminationReason {
+		changes = append(changes, FieldChange{Field: "termination_reason", Old: string(old.TerminationReason), New: string(new.TerminationReason)})
+	}
+
+	return changes
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(FormatDateOfBirth)
+}
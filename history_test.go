@@ -0,0 +1,36 @@
+package xone
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffPersons(t *testing.T) {
+	old := Person{FirstName: "Harry", LastName: "Potter", City: "Little Whinging"}
+	new := Person{FirstName: "Harry", LastName: "Potter", DateOfBirth: time.Date(1980, time.July, 31, 0, 0, 0, 0, time.UTC), City: "London"}
+
+	want := []FieldChange{
+		{Field: "date_of_birth", New: "1980-07-31"},
+		{Field: "city", Old: "Little Whinging", New: "London"},
+	}
+	if got := DiffPersons(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffPersons() = %v, want %v", got, want)
+	}
+	if got := DiffPersons(new, new); got != nil {
+		t.Errorf("DiffPersons() = %v, want nil", got)
+	}
+}
+
+func TestDiffMemberships(t *testing.T) {
+	old := Membership{Type: MembershipType{ID: 1, Name: "active"}}
+	new := Membership{Type: MembershipType{ID: 2, Name: "passive"}, EffectiveFrom: time.Date(1998, time.May, 2, 0, 0, 0, 0, time.UTC)}
+
+	want := []FieldChange{
+		{Field: "membership_type", Old: "active", New: "passive"},
+		{Field: "effective_from", New: "1998-05-02"},
+	}
+	if got := DiffMemberships(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffMemberships() = %v, want %v", got, want)
+	}
+}
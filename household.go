@@ -0,0 +1,106 @@
+package xone
+
+import "context"
+
+// HouseholdService groups persons who live at the same address, e.g. a family
+// sharing a family membership. Every person belongs to at most one household.
+type HouseholdService interface {
+	// FindHouseholds returns all households along with their members, ordered
+	// by name.
+	FindHouseholds(context.Context) ([]Household, error)
+
+	FindHousehold(context.Context, int) (Household, bool, error)
+
+	// FindHouseholdOfPerson returns the household the person with the given
+	// PID belongs to.
+	FindHouseholdOfPerson(context.Context, string) (Household, bool, error)
+
+	// CreateHousehold creates a household whose first member is its primary
+	// contact.
+	CreateHousehold(context.Context, CreateHouseholdData) (Household, error)
+
+	UpdateHousehold(context.Context, int, UpdateHouseholdData) error
+
+	// DeleteHousehold dissolves a household. Its members are kept.
+	DeleteHousehold(context.Context, int) error
+
+	AddHouseholdMember(context.Context, int, string) error
+
+	// RemoveHouseholdMember removes a person from a household. The primary
+	// contact cannot be removed, see ErrPrimaryContact.
+	RemoveHouseholdMember(context.Context, int, string) error
+}
+
+// Household is a group of persons who share an address.
+type Household struct {
+	ID   int
+	Name string
+
+	Street      string
+	HouseNumber string
+	ZipCode     string
+	City        string
+
+	// PrimaryContact is the PID of the member who is contacted on behalf of
+	// the household. It is empty if the primary contact has been archived or
+	// purged.
+	PrimaryContact string
+
+	// Members does not include deleted persons.
+	Members []Person
+}
+
+// HasAddress reports whether any part of the address is set.
+func (h Household) HasAddress() bool {
+	return h.Street != "" || h.HouseNumber != "" || h.ZipCode != "" || h.City != ""
+}
+
+// IsMember reports whether the person with the given PID is a member of the
+// household.
+func (h Household) IsMember(pid string) bool {
+	for _, p := range h.Members {
+		if p.PID == pid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CreateHouseholdData contains the data needed to create a household. If no
+// address is given, the household takes the address of its primary contact.
+type CreateHouseholdData struct {
+	Name           string
+	PrimaryContact string
+	Street         string
+	HouseNumber    string
+	ZipCode        string
+	City           string
+}
+
+// UpdateHouseholdData contains the data of a household which can be updated.
+type UpdateHouseholdData struct {
+	Name           string
+	PrimaryContact string
+	Street         string
+	HouseNumber    string
+	ZipCode        string
+	City           string
+
+	// PropagateAddress copies the address of the household to all of its
+	// members.
+	PropagateAddress bool
+}
+
+// ToUpdateData returns a struct that can be used as a starting point when
+// updating a household.
+func (h Household) ToUpdateData() UpdateHouseholdData {
+	return UpdateHouseholdData{
+		Name:           h.Name,
+		PrimaryContact: h.PrimaryContact,
+		Street:         h.Street,
+		HouseNumber:    h.HouseNumber,
+		ZipCode:        h.ZipCode,
+		City:           h.City,
+	}
+}
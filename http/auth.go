@@ -0,0 +1,150 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+// SessionCookieName is the name of the cookie which carries the session token
+// for browser based clients. API clients may send the token in an
+// "Authorization: Bearer <token>" header instead.
+const SessionCookieName = "xone_session"
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type sessionResponse struct {
+	Token     string `json:"token"`
+	Email     string `json:"email"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// authenticate looks up the session belonging to the request. If there is no
+// valid session, an error response is written and false is returned.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (xone.Session, bool) {
+	token := sessionToken(r)
+	if token == "" {
+		writeError(w, http.StatusUnauthorized, "authentication required")
+		return xone.Session{}, false
+	}
+
+	session, found, err := s.SessionService.Find(r.Context(), token)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return xone.Session{}, false
+	} else if !found {
+		writeError(w, http.StatusUnauthorized, "invalid or expired session")
+		return xone.Session{}, false
+	}
+
+	return session, true
+}
+
+// sessionToken extracts the session token from the request.
+func sessionToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	if cookie, err := r.Cookie(SessionCookieName); err == nil {
+		return cookie.Value
+	}
+
+	return ""
+}
+
+// loginEnabled reports whether users can log in, i.e. whether both the
+// UserService and the SessionService are set. Otherwise "/login" and
+// "/logout" do not exist.
+func (s *Server) loginEnabled() bool {
+	return s.UserService != nil && s.SessionService != nil
+}
+
+// handleLogin handles requests to "/login".
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !s.loginEnabled() {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var req loginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	user, err := s.UserService.Authenticate(r.Context(), req.Email, req.Password)
+	var invalidCredentials *xone.ErrInvalidCredentials
+	if errors.As(err, &invalidCredentials) {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	} else if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	session, err := s.SessionService.Create(r.Context(), user)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	writeJSON(w, http.StatusOK, sessionResponse{
+		Token:     session.Token,
+		Email:     user.Email,
+		ExpiresAt: session.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// handleLogout handles requests to "/logout". If the query parameter "all" is
+// set to "true", all sessions of the current user are revoked.
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if !s.loginEnabled() {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var err error
+	if r.URL.Query().Get("all") == "true" {
+		user, _ := xone.UserFromContext(r.Context())
+		err = s.SessionService.RevokeAll(r.Context(), user.ID)
+	} else {
+		err = s.SessionService.Revoke(r.Context(), sessionToken(r))
+	}
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   SessionCookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,133 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/authz"
+	"github.com/stillwondering/xone/bcrypt"
+	"github.com/stillwondering/xone/http"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+// MustOpenAuthServer returns a server which requires authentication and knows
+// a single user with the given credentials and role.
+func MustOpenAuthServer(tb testing.TB, email, password string, role xone.Role) *http.Server {
+	tb.Helper()
+
+	db, err := sqlite.Open(filepath.Join(tb.TempDir(), "xone.db"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		db.Close()
+	})
+
+	us, err := sqlite.NewUserService(db)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	hash, err := bcrypt.HashFromPassword([]byte(password))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := us.Create(context.Background(), xone.CreateUserData{Email: email, Password: string(hash), Role: role}); err != nil {
+		tb.Fatal(err)
+	}
+
+	s := http.NewServer()
+	s.PersonRepository = authz.NewPersonRepository(sqlite.NewPersonService(db))
+	s.MembershipService = authz.NewMembershipService(sqlite.NewMembershipService(db))
+	s.UserService = us
+	s.SessionService = sqlite.NewSessionService(db)
+
+	return s
+}
+
+func doWithToken(s *http.Server, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	r := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestServer_Authentication(t *testing.T) {
+	s := MustOpenAuthServer(t, "albus.dumbledore@hogwarts.co.uk", "Harrydidyouputyournameinthegobletoffire", xone.RoleReadOnly)
+
+	if w := doWithToken(s, "GET", "/persons", "", nil); w.Code != nethttp.StatusUnauthorized {
+		t.Errorf("GET /persons without session status = %v, want %v", w.Code, nethttp.StatusUnauthorized)
+	}
+
+	w := doWithToken(s, "POST", "/login", "", map[string]string{"email": "albus.dumbledore@hogwarts.co.uk", "password": "wrong"})
+	if w.Code != nethttp.StatusUnauthorized {
+		t.Errorf("POST /login with wrong password status = %v, want %v", w.Code, nethttp.StatusUnauthorized)
+	}
+
+	login := func() string {
+		w := doWithToken(s, "POST", "/login", "", map[string]string{"email": "albus.dumbledore@hogwarts.co.uk", "password": "Harrydidyouputyournameinthegobletoffire"})
+		if w.Code != nethttp.StatusOK {
+			t.Fatalf("POST /login status = %v, want %v", w.Code, nethttp.StatusOK)
+		}
+
+		var resp map[string]string
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		return resp["token"]
+	}
+
+	token := login()
+	if w := doWithToken(s, "GET", "/persons", token, nil); w.Code != nethttp.StatusOK {
+		t.Errorf("GET /persons with session status = %v, want %v", w.Code, nethttp.StatusOK)
+	}
+
+	if w := doWithToken(s, "POST", "/logout", token, nil); w.Code != nethttp.StatusNoContent {
+		t.Errorf("POST /logout status = %v, want %v", w.Code, nethttp.StatusNoContent)
+	}
+	if w := doWithToken(s, "GET", "/persons", token, nil); w.Code != nethttp.StatusUnauthorized {
+		t.Errorf("GET /persons after logout status = %v, want %v", w.Code, nethttp.StatusUnauthorized)
+	}
+
+	first, second := login(), login()
+	if w := doWithToken(s, "POST", "/logout?all=true", first, nil); w.Code != nethttp.StatusNoContent {
+		t.Errorf("POST /logout?all=true status = %v, want %v", w.Code, nethttp.StatusNoContent)
+	}
+	if w := doWithToken(s, "GET", "/persons", second, nil); w.Code != nethttp.StatusUnauthorized {
+		t.Errorf("GET /persons after logging out everywhere status = %v, want %v", w.Code, nethttp.StatusUnauthorized)
+	}
+}
+
+func TestServer_Authorization(t *testing.T) {
+	s := MustOpenAuthServer(t, "argus.filch@hogwarts.co.uk", "MrsNorris", xone.RoleReadOnly)
+
+	w := doWithToken(s, "POST", "/login", "", map[string]string{"email": "argus.filch@hogwarts.co.uk", "password": "MrsNorris"})
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doWithToken(s, "GET", "/membership-types", resp["token"], nil); w.Code != nethttp.StatusOK {
+		t.Errorf("GET /membership-types status = %v, want %v", w.Code, nethttp.StatusOK)
+	}
+	if w := doWithToken(s, "POST", "/membership-types", resp["token"], map[string]string{"name": "active"}); w.Code != nethttp.StatusForbidden {
+		t.Errorf("POST /membership-types status = %v, want %v", w.Code, nethttp.StatusForbidden)
+	}
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+type feeResponse struct {
+	ID               int    `json:"id"`
+	MembershipTypeID int    `json:"membershipTypeId"`
+	Amount           int64  `json:"amount"`
+	Period           string `json:"period"`
+	ValidFrom        string `json:"validFrom"`
+	ValidUntil       string `json:"validUntil,omitempty"`
+}
+
+type feeRequest struct {
+	Amount     int64  `json:"amount"`
+	Period     string `json:"period"`
+	ValidFrom  string `json:"validFrom"`
+	ValidUntil string `json:"validUntil"`
+}
+
+type duesResponse struct {
+	From  string             `json:"from"`
+	Until string             `json:"until"`
+	Total int64              `json:"total"`
+	Items []duesItemResponse `json:"items"`
+}
+
+type duesItemResponse struct {
+	MembershipID   int    `json:"membershipId"`
+	MembershipType string `json:"membershipType"`
+	FeeID          int    `json:"feeId"`
+	From           string `json:"from"`
+	Until          string `json:"until"`
+	Amount         int64  `json:"amount"`
+}
+
+func newFeeResponse(f xone.Fee) feeResponse {
+	return feeResponse{
+		ID:               f.ID,
+		MembershipTypeID: f.MembershipTypeID,
+		Amount:           f.Amount,
+		Period:           string(f.Period),
+		ValidFrom:        formatDate(f.ValidFrom),
+		ValidUntil:       formatDate(f.ValidUntil),
+	}
+}
+
+func newDuesResponse(d xone.Dues) duesResponse {
+	resp := duesResponse{
+		From:  formatDate(d.From),
+		Until: formatDate(d.Until),
+		Total: d.Total,
+		Items: []duesItemResponse{},
+	}
+	for _, item := range d.Items {
+		resp.Items = append(resp.Items, duesItemResponse{
+			MembershipID:   item.MembershipID,
+			MembershipType: item.MembershipType,
+			FeeID:          item.Fee.ID,
+			From:           formatDate(item.From),
+			Until:          formatDate(item.Until),
+			Amount:         item.Amount,
+		})
+	}
+
+	return resp
+}
+
+func (req feeRequest) toCreateData(membershipTypeID int) (xone.CreateFeeData, error) {
+	validFrom, err := parseDate(req.ValidFrom)
+	if err != nil {
+		return xone.CreateFeeData{}, fmt.Errorf("invalid valid from date: %s", req.ValidFrom)
+	}
+	if validFrom.IsZero() {
+		return xone.CreateFeeData{}, errors.New("valid from date required")
+	}
+
+	validUntil, err := parseDate(req.ValidUntil)
+	if err != nil {
+		return xone.CreateFeeData{}, fmt.Errorf("invalid valid until date: %s", req.ValidUntil)
+	}
+
+	if req.Amount < 0 {
+		return xone.CreateFeeData{}, errors.New("amount must not be negative")
+	}
+
+	return xone.CreateFeeData{
+		MembershipTypeID: membershipTypeID,
+		Amount:           req.Amount,
+		Period:           xone.BillingPeriod(req.Period),
+		ValidFrom:        validFrom,
+		ValidUntil:       validUntil,
+	}, nil
+}
+
+// handleMembershipTypeFees handles requests to
+// "/membership-types/{id}/fees".
+func (s *Server) handleMembershipTypeFees(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/membership-types/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) != 2 || parts[1] != "fees" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleFeeIndex(w, r, id)
+	case http.MethodPost:
+		s.handleFeeCreate(w, r, id)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handleFee handles requests to "/fees/{id}".
+func (s *Server) handleFee(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(pathParam(r, "/fees/"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodDelete)
+		return
+	}
+
+	if err := s.FeeService.DeleteFee(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleFeeIndex(w http.ResponseWriter, r *http.Request, membershipTypeID int) {
+	fees, err := s.FeeService.FindFees(r.Context(), membershipTypeID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := []feeResponse{}
+	for _, f := range fees {
+		resp = append(resp, newFeeResponse(f))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleFeeCreate(w http.ResponseWriter, r *http.Request, membershipTypeID int) {
+	var req feeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	data, err := req.toCreateData(membershipTypeID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fee, err := s.FeeService.CreateFee(r.Context(), data)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newFeeResponse(fee))
+}
+
+// handlePersonDues handles requests to "/persons/{pid}/dues". The range of
+// dates is given by the query parameters "from" and "until" and defaults to
+// the current calendar year.
+func (s *Server) handlePersonDues(w http.ResponseWriter, r *http.Request, pid string) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	from, until := xone.BillingAnnual.Bounds(time.Now())
+	q := r.URL.Query()
+	if v := q.Get("from"); v != "" {
+		t, err := parseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid from date: %s", v))
+			return
+		}
+		from = t
+	}
+	if v := q.Get("until"); v != "" {
+		t, err := parseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid until date: %s", v))
+			return
+		}
+		until = t
+	}
+	if until.Before(from) {
+		writeError(w, http.StatusBadRequest, "until date lies before from date")
+		return
+	}
+
+	if _, found, err := s.PersonRepository.Find(r.Context(), pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "person not found")
+		return
+	}
+
+	dues, err := s.FeeService.CalculateDues(r.Context(), pid, from, until)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newDuesResponse(dues))
+}
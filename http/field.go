@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/stillwondering/xone"
+)
+
+type fieldResponse struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options"`
+}
+
+type fieldRequest struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options"`
+}
+
+func newFieldResponse(d xone.FieldDefinition) fieldResponse {
+	resp := fieldResponse{
+		ID:       d.ID,
+		Name:     d.Name,
+		Type:     string(d.Type),
+		Required: d.Required,
+		Options:  []string{},
+	}
+	resp.Options = append(resp.Options, d.Options...)
+
+	return resp
+}
+
+// handleFields handles requests to "/fields".
+func (s *Server) handleFields(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		defs, err := s.FieldService.FindFieldDefinitions(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		resp := []fieldResponse{}
+		for _, d := range defs {
+			resp = append(resp, newFieldResponse(d))
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodPost:
+		var req fieldRequest
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+
+		def, err := s.FieldService.CreateFieldDefinition(r.Context(), xone.CreateFieldDefinitionData{
+			Name:     req.Name,
+			Type:     xone.FieldType(req.Type),
+			Required: req.Required,
+			Options:  req.Options,
+		})
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, newFieldResponse(def))
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handleField handles requests to "/fields/{id}".
+func (s *Server) handleField(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(pathParam(r, "/fields/"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodDelete)
+		return
+	}
+
+	defs, err := s.FieldService.FindFieldDefinitions(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	found := false
+	for _, d := range defs {
+		found = found || d.ID == id
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "custom field not found")
+		return
+	}
+
+	if err := s.FieldService.DeleteFieldDefinition(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,245 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/stillwondering/xone"
+)
+
+type householdResponse struct {
+	ID             int                       `json:"id"`
+	Name           string                    `json:"name"`
+	Street         string                    `json:"street"`
+	HouseNumber    string                    `json:"houseNumber"`
+	ZipCode        string                    `json:"zipCode"`
+	City           string                    `json:"city"`
+	PrimaryContact string                    `json:"primaryContact,omitempty"`
+	Members        []householdMemberResponse `json:"members"`
+}
+
+type householdMemberResponse struct {
+	PID       string `json:"pid"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
+type householdRequest struct {
+	Name             string `json:"name"`
+	PrimaryContact   string `json:"primaryContact"`
+	Street           string `json:"street"`
+	HouseNumber      string `json:"houseNumber"`
+	ZipCode          string `json:"zipCode"`
+	City             string `json:"city"`
+	PropagateAddress bool   `json:"propagateAddress"`
+}
+
+type householdMemberRequest struct {
+	PID string `json:"pid"`
+}
+
+func newHouseholdResponse(h xone.Household) householdResponse {
+	resp := householdResponse{
+		ID:             h.ID,
+		Name:           h.Name,
+		Street:         h.Street,
+		HouseNumber:    h.HouseNumber,
+		ZipCode:        h.ZipCode,
+		City:           h.City,
+		PrimaryContact: h.PrimaryContact,
+		Members:        []householdMemberResponse{},
+	}
+	for _, p := range h.Members {
+		resp.Members = append(resp.Members, householdMemberResponse{
+			PID:       p.PID,
+			FirstName: p.FirstName,
+			LastName:  p.LastName,
+		})
+	}
+
+	return resp
+}
+
+// handleHouseholds handles requests to "/households".
+func (s *Server) handleHouseholds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		households, err := s.HouseholdService.FindHouseholds(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		resp := []householdResponse{}
+		for _, h := range households {
+			resp = append(resp, newHouseholdResponse(h))
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodPost:
+		s.handleHouseholdCreate(w, r)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (s *Server) handleHouseholdCreate(w http.ResponseWriter, r *http.Request) {
+	var req householdRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if req.PrimaryContact == "" {
+		writeError(w, http.StatusBadRequest, "primary contact required")
+		return
+	}
+
+	if _, found, err := s.PersonRepository.Find(r.Context(), req.PrimaryContact); err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusBadRequest, "primary contact not found")
+		return
+	}
+
+	household, err := s.HouseholdService.CreateHousehold(r.Context(), xone.CreateHouseholdData{
+		Name:           req.Name,
+		PrimaryContact: req.PrimaryContact,
+		Street:         req.Street,
+		HouseNumber:    req.HouseNumber,
+		ZipCode:        req.ZipCode,
+		City:           req.City,
+	})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newHouseholdResponse(household))
+}
+
+// handleHousehold handles requests to "/households/{id}",
+// "/households/{id}/members" and "/households/{id}/members/{pid}".
+func (s *Server) handleHousehold(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/households/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) > 3 || (len(parts) > 1 && parts[1] != "members") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	household, found, err := s.HouseholdService.FindHousehold(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "household not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 3:
+		s.handleHouseholdMember(w, r, household, parts[2])
+	case len(parts) == 2:
+		s.handleHouseholdMembers(w, r, household)
+	case r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, newHouseholdResponse(household))
+	case r.Method == http.MethodPut:
+		s.handleHouseholdUpdate(w, r, household)
+	case r.Method == http.MethodDelete:
+		if err := s.HouseholdService.DeleteHousehold(r.Context(), id); err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+func (s *Server) handleHouseholdUpdate(w http.ResponseWriter, r *http.Request, household xone.Household) {
+	var req householdRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name required")
+		return
+	}
+
+	err := s.HouseholdService.UpdateHousehold(r.Context(), household.ID, xone.UpdateHouseholdData{
+		Name:             req.Name,
+		PrimaryContact:   req.PrimaryContact,
+		Street:           req.Street,
+		HouseNumber:      req.HouseNumber,
+		ZipCode:          req.ZipCode,
+		City:             req.City,
+		PropagateAddress: req.PropagateAddress,
+	})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	updated, _, err := s.HouseholdService.FindHousehold(r.Context(), household.ID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newHouseholdResponse(updated))
+}
+
+// handleHouseholdMembers adds a person to a household.
+func (s *Server) handleHouseholdMembers(w http.ResponseWriter, r *http.Request, household xone.Household) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var req householdMemberRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if _, found, err := s.PersonRepository.Find(r.Context(), req.PID); err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusBadRequest, "person not found")
+		return
+	}
+
+	if err := s.HouseholdService.AddHouseholdMember(r.Context(), household.ID, req.PID); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleHouseholdMember removes a person from a household.
+func (s *Server) handleHouseholdMember(w http.ResponseWriter, r *http.Request, household xone.Household, pid string) {
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodDelete)
+		return
+	}
+
+	if !household.IsMember(pid) {
+		writeError(w, http.StatusNotFound, "member not found")
+		return
+	}
+
+	if err := s.HouseholdService.RemoveHouseholdMember(r.Context(), household.ID, pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/invoice"
+)
+
+type invoiceResponse struct {
+	Number         string                `json:"number"`
+	Kind           string                `json:"kind"`
+	PID            string                `json:"pid,omitempty"`
+	IssuedOn       string                `json:"issuedOn"`
+	Recipient      []string              `json:"recipient"`
+	MembershipType string                `json:"membershipType,omitempty"`
+	Items          []invoiceItemResponse `json:"items"`
+	Total          int64                 `json:"total"`
+	CreatedAt      string                `json:"createdAt"`
+}
+
+type invoiceItemResponse struct {
+	Description string `json:"description"`
+	Amount      int64  `json:"amount"`
+}
+
+type invoiceRequest struct {
+	Kind     string                `json:"kind"`
+	IssuedOn string                `json:"issuedOn"`
+	Items    []invoiceItemResponse `json:"items"`
+}
+
+func newInvoiceResponse(i xone.Invoice) invoiceResponse {
+	resp := invoiceResponse{
+		Number:         i.Number,
+		Kind:           string(i.Kind),
+		PID:            i.PID,
+		IssuedOn:       formatDate(i.IssuedOn),
+		Recipient:      i.Recipient.AddressLines(),
+		MembershipType: i.MembershipType,
+		Items:          []invoiceItemResponse{},
+		Total:          i.Total(),
+		CreatedAt:      i.CreatedAt.Format(time.RFC3339),
+	}
+	for _, item := range i.Items {
+		resp.Items = append(resp.Items, invoiceItemResponse{Description: item.Description, Amount: item.Amount})
+	}
+
+	return resp
+}
+
+func (req invoiceRequest) toCreateData(pid string) (xone.CreateInvoiceData, error) {
+	issuedOn, err := parseDate(req.IssuedOn)
+	if err != nil {
+		return xone.CreateInvoiceData{}, fmt.Errorf("invalid date: %s", req.IssuedOn)
+	}
+
+	kind := xone.InvoiceKind(req.Kind)
+	if !kind.Valid() {
+		return xone.CreateInvoiceData{}, fmt.Errorf("invalid kind: %s", req.Kind)
+	}
+
+	if len(req.Items) == 0 {
+		return xone.CreateInvoiceData{}, fmt.Errorf("at least one item required")
+	}
+
+	data := xone.CreateInvoiceData{
+		PID:      pid,
+		Kind:     kind,
+		IssuedOn: issuedOn,
+	}
+	for _, item := range req.Items {
+		if strings.TrimSpace(item.Description) == "" || item.Amount <= 0 {
+			return xone.CreateInvoiceData{}, fmt.Errorf("items need a description and a positive amount")
+		}
+		data.Items = append(data.Items, xone.InvoiceItem{Description: item.Description, Amount: item.Amount})
+	}
+
+	return data, nil
+}
+
+// handlePersonInvoices handles requests to "/persons/{pid}/invoices".
+func (s *Server) handlePersonInvoices(w http.ResponseWriter, r *http.Request, pid string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		return
+	}
+
+	if _, found, err := s.PersonRepository.Find(r.Context(), pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "person not found")
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		s.handleInvoiceCreate(w, r, pid)
+		return
+	}
+
+	invoices, err := s.InvoiceService.FindInvoices(r.Context(), pid)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := []invoiceResponse{}
+	for _, i := range invoices {
+		resp = append(resp, newInvoiceResponse(i))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleInvoiceCreate(w http.ResponseWriter, r *http.Request, pid string) {
+	var req invoiceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	data, err := req.toCreateData(pid)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	i, err := s.InvoiceService.CreateInvoice(r.Context(), data)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newInvoiceResponse(i))
+}
+
+// handleInvoice handles requests to "/invoices/{number}". The query parameter
+// "format" selects whether the document is returned as JSON, which is the
+// default, or rendered as "html" or "pdf".
+func (s *Server) handleInvoice(w http.ResponseWriter, r *http.Request) {
+	number := pathParam(r, "/invoices/")
+	if number == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	var render func(io.Writer, xone.Invoice, invoice.Issuer) error
+	var contentType string
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+	case "html":
+		render = invoice.WriteHTML
+		contentType = "text/html; charset=utf-8"
+	case "pdf":
+		render = invoice.WritePDF
+		contentType = "application/pdf"
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid format: %s", format))
+		return
+	}
+
+	i, found, err := s.InvoiceService.FindInvoice(r.Context(), number)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "invoice not found")
+		return
+	}
+
+	if render == nil {
+		writeJSON(w, http.StatusOK, newInvoiceResponse(i))
+		return
+	}
+
+	// The document is rendered completely before anything is sent, so that
+	// an error can still be reported properly.
+	var buf bytes.Buffer
+	if err := render(&buf, i, s.Issuer); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/stillwondering/xone"
+)
+
+type mandateResponse struct {
+	ID              int    `json:"id"`
+	AccountHolder   string `json:"accountHolder,omitempty"`
+	IBAN            string `json:"iban"`
+	BIC             string `json:"bic,omitempty"`
+	Reference       string `json:"reference"`
+	SignedOn        string `json:"signedOn"`
+	LastCollectedOn string `json:"lastCollectedOn,omitempty"`
+	SequenceType    string `json:"sequenceType"`
+}
+
+type mandateRequest struct {
+	AccountHolder string `json:"accountHolder"`
+	IBAN          string `json:"iban"`
+	BIC           string `json:"bic"`
+	Reference     string `json:"reference"`
+	SignedOn      string `json:"signedOn"`
+}
+
+func newMandateResponse(m xone.Mandate) mandateResponse {
+	return mandateResponse{
+		ID:              m.ID,
+		AccountHolder:   m.AccountHolder,
+		IBAN:            m.IBAN,
+		BIC:             m.BIC,
+		Reference:       m.Reference,
+		SignedOn:        formatDate(m.SignedOn),
+		LastCollectedOn: formatDate(m.LastCollectedOn),
+		SequenceType:    m.SequenceType(),
+	}
+}
+
+func (req mandateRequest) toSaveData() (xone.SaveMandateData, error) {
+	signedOn, err := parseDate(req.SignedOn)
+	if err != nil || signedOn.IsZero() {
+		return xone.SaveMandateData{}, fmt.Errorf("invalid signature date: %s", req.SignedOn)
+	}
+
+	bic := strings.ToUpper(strings.TrimSpace(req.BIC))
+	if bic != "" && !xone.ValidBIC(bic) {
+		return xone.SaveMandateData{}, fmt.Errorf("invalid BIC: %s", req.BIC)
+	}
+
+	if !xone.ValidMandateReference(strings.TrimSpace(req.Reference)) {
+		return xone.SaveMandateData{}, fmt.Errorf("invalid mandate reference: %s", req.Reference)
+	}
+
+	return xone.SaveMandateData{
+		AccountHolder: req.AccountHolder,
+		IBAN:          req.IBAN,
+		BIC:           bic,
+		Reference:     req.Reference,
+		SignedOn:      signedOn,
+	}, nil
+}
+
+// handlePersonMandate handles requests to "/persons/{pid}/mandate".
+func (s *Server) handlePersonMandate(w http.ResponseWriter, r *http.Request, pid string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+		return
+	}
+
+	if _, found, err := s.PersonRepository.Find(r.Context(), pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "person not found")
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		s.handleMandateSave(w, r, pid)
+		return
+	}
+
+	mandate, found, err := s.MandateService.FindMandate(r.Context(), pid)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "mandate not found")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := s.MandateService.DeleteMandate(r.Context(), pid); err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newMandateResponse(mandate))
+}
+
+func (s *Server) handleMandateSave(w http.ResponseWriter, r *http.Request, pid string) {
+	var req mandateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	data, err := req.toSaveData()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mandate, err := s.MandateService.SaveMandate(r.Context(), pid, data)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newMandateResponse(mandate))
+}
@@ -0,0 +1,238 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/stillwondering/xone"
+)
+
+type membershipResponse struct {
+	ID            int                    `json:"id"`
+	Type          membershipTypeResponse `json:"type"`
+	EffectiveFrom string                 `json:"effectiveFrom"`
+
+	EndDate           string `json:"endDate,omitempty"`
+	TerminationReason string `json:"terminationReason,omitempty"`
+	Version           int    `json:"version"`
+}
+
+type membershipTypeResponse struct {
+	ID   int           `json:"id"`
+	Name string        `json:"name"`
+	Fees []feeResponse `json:"fees,omitempty"`
+}
+
+type membershipTypeRequest struct {
+	Name string `json:"name"`
+}
+
+type membershipRequest struct {
+	MembershipTypeID int    `json:"membershipTypeId"`
+	EffectiveFrom    string `json:"effectiveFrom"`
+}
+
+type terminateMembershipRequest struct {
+	EndDate string `json:"endDate"`
+	Reason  string `json:"reason"`
+}
+
+func newMembershipResponse(m xone.Membership) membershipResponse {
+	return membershipResponse{
+		ID:            m.ID,
+		Type:          newMembershipTypeResponse(m.Type),
+		EffectiveFrom: formatDate(m.EffectiveFrom),
+
+		EndDate:           formatDate(m.EndDate),
+		TerminationReason: string(m.TerminationReason),
+		Version:           m.Version,
+	}
+}
+
+func newMembershipTypeResponse(mt xone.MembershipType) membershipTypeResponse {
+	resp := membershipTypeResponse{
+		ID:   mt.ID,
+		Name: mt.Name,
+	}
+	for _, f := range mt.Fees {
+		resp.Fees = append(resp.Fees, newFeeResponse(f))
+	}
+
+	return resp
+}
+
+func (req membershipRequest) toUpdateData() (xone.UpdateMembershipData, error) {
+	effectiveFrom, err := parseDate(req.EffectiveFrom)
+	if err != nil {
+		return xone.UpdateMembershipData{}, fmt.Errorf("invalid effective from date: %s", req.EffectiveFrom)
+	}
+
+	return xone.UpdateMembershipData{
+		MembershipTypeID: req.MembershipTypeID,
+		EffectiveFrom:    effectiveFrom,
+	}, nil
+}
+
+func (req terminateMembershipRequest) toTerminateData() (xone.TerminateMembershipData, error) {
+	endDate, err := parseDate(req.EndDate)
+	if err != nil {
+		return xone.TerminateMembershipData{}, fmt.Errorf("invalid end date: %s", req.EndDate)
+	}
+	if endDate.IsZero() {
+		return xone.TerminateMembershipData{}, errors.New("end date required")
+	}
+
+	return xone.TerminateMembershipData{
+		EndDate: endDate,
+		Reason:  xone.TerminationReason(req.Reason),
+	}, nil
+}
+
+// handleMembershipTypes handles requests to "/membership-types".
+func (s *Server) handleMembershipTypes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleMembershipTypeIndex(w, r)
+	case http.MethodPost:
+		s.handleMembershipTypeCreate(w, r)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handleMembership handles requests to "/memberships/{id}",
+// "/memberships/{id}/terminate" and "/memberships/{id}/reinstate".
+func (s *Server) handleMembership(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/memberships/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) > 2 || (len(parts) == 2 && parts[1] != "terminate" && parts[1] != "reinstate") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if len(parts) == 2 {
+		s.handleMembershipAction(w, r, id, parts[1])
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.handleMembershipUpdate(w, r, id)
+	default:
+		methodNotAllowed(w, http.MethodPut)
+	}
+}
+
+func (s *Server) handleMembershipTypeIndex(w http.ResponseWriter, r *http.Request) {
+	membershipTypes, err := s.MembershipService.FindAllMembershipTypes(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := []membershipTypeResponse{}
+	for _, mt := range membershipTypes {
+		resp = append(resp, newMembershipTypeResponse(mt))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleMembershipTypeCreate(w http.ResponseWriter, r *http.Request) {
+	var req membershipTypeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name required")
+		return
+	}
+
+	mt, err := s.MembershipService.CreateMembershipType(r.Context(), req.Name)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newMembershipTypeResponse(mt))
+}
+
+// handleMembershipUpdate changes a membership. The If-Match header has to
+// contain the version of the membership as listed along with its person, or
+// "*" to change whatever version is current.
+func (s *Server) handleMembershipUpdate(w http.ResponseWriter, r *http.Request, id int) {
+	version, matchAny, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req membershipRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	data, err := req.toUpdateData()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data.Version = version
+
+	if matchAny {
+		current, found, err := s.MembershipService.FindMembership(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		} else if !found {
+			writeError(w, http.StatusNotFound, "membership not found")
+			return
+		}
+		data.Version = current.Version
+	}
+
+	if err := s.MembershipService.UpdateMembership(r.Context(), id, data); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleMembershipAction handles terminating and reinstating a membership.
+func (s *Server) handleMembershipAction(w http.ResponseWriter, r *http.Request, id int, action string) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var err error
+	if action == "reinstate" {
+		err = s.MembershipService.ReinstateMembership(r.Context(), id)
+	} else {
+		var req terminateMembershipRequest
+		if decodeJSON(r, &req) != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+
+		data, reqErr := req.toTerminateData()
+		if reqErr != nil {
+			writeError(w, http.StatusBadRequest, reqErr.Error())
+			return
+		}
+
+		err = s.MembershipService.TerminateMembership(r.Context(), id, data)
+	}
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+type ledgerResponse struct {
+	Balance int64                 `json:"balance"`
+	Entries []ledgerEntryResponse `json:"entries"`
+}
+
+type ledgerEntryResponse struct {
+	ID          int    `json:"id"`
+	Kind        string `json:"kind"`
+	Date        string `json:"date"`
+	Amount      int64  `json:"amount"`
+	Description string `json:"description,omitempty"`
+	Method      string `json:"method,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type ledgerEntryRequest struct {
+	Kind        string `json:"kind"`
+	Date        string `json:"date"`
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+	Method      string `json:"method"`
+	Reference   string `json:"reference"`
+}
+
+type arrearResponse struct {
+	PID       string `json:"pid"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Balance   int64  `json:"balance"`
+}
+
+func newLedgerEntryResponse(e xone.LedgerEntry) ledgerEntryResponse {
+	return ledgerEntryResponse{
+		ID:          e.ID,
+		Kind:        string(e.Kind),
+		Date:        formatDate(e.Date),
+		Amount:      e.Amount,
+		Description: e.Description,
+		Method:      string(e.Method),
+		Reference:   e.Reference,
+		CreatedAt:   e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (req ledgerEntryRequest) toCreateData(pid string) (xone.CreateLedgerEntryData, error) {
+	date, err := parseDate(req.Date)
+	if err != nil {
+		return xone.CreateLedgerEntryData{}, fmt.Errorf("invalid date: %s", req.Date)
+	}
+
+	kind := xone.LedgerEntryKind(req.Kind)
+	if !kind.Valid() {
+		return xone.CreateLedgerEntryData{}, fmt.Errorf("invalid kind: %s", req.Kind)
+	}
+
+	if req.Amount <= 0 {
+		return xone.CreateLedgerEntryData{}, fmt.Errorf("amount must be positive")
+	}
+
+	return xone.CreateLedgerEntryData{
+		PID:         pid,
+		Kind:        kind,
+		Date:        date,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Method:      xone.PaymentMethod(req.Method),
+		Reference:   req.Reference,
+	}, nil
+}
+
+// handlePersonLedger handles requests to "/persons/{pid}/ledger".
+func (s *Server) handlePersonLedger(w http.ResponseWriter, r *http.Request, pid string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		return
+	}
+
+	if _, found, err := s.PersonRepository.Find(r.Context(), pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "person not found")
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		s.handleLedgerEntryCreate(w, r, pid)
+		return
+	}
+
+	entries, err := s.PaymentService.FindLedgerEntries(r.Context(), pid)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := ledgerResponse{
+		Balance: xone.Balance(entries),
+		Entries: []ledgerEntryResponse{},
+	}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, newLedgerEntryResponse(e))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleLedgerEntryCreate(w http.ResponseWriter, r *http.Request, pid string) {
+	var req ledgerEntryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	data, err := req.toCreateData(pid)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entry, err := s.PaymentService.CreateLedgerEntry(r.Context(), data)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newLedgerEntryResponse(entry))
+}
+
+// handleArrears handles requests to "/arrears". The optional query parameter
+// "date" determines which entries are due, it defaults to today.
+func (s *Server) handleArrears(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	today := time.Now()
+	if v := r.URL.Query().Get("date"); v != "" {
+		t, err := parseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid date: %s", v))
+			return
+		}
+		today = t
+	}
+
+	arrears, err := s.PaymentService.FindArrears(r.Context(), today)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := []arrearResponse{}
+	for _, a := range arrears {
+		resp = append(resp, arrearResponse{
+			PID:       a.Person.PID,
+			FirstName: a.Person.FirstName,
+			LastName:  a.Person.LastName,
+			Balance:   a.Balance,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
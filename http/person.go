@@ -0,0 +1,510 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+type personResponse struct {
+	PID         string               `json:"pid"`
+	FirstName   string               `json:"firstName"`
+	LastName    string               `json:"lastName"`
+	DateOfBirth string               `json:"dateOfBirth"`
+	Email       string               `json:"email"`
+	Phone       string               `json:"phone"`
+	Mobile      string               `json:"mobile"`
+	Street      string               `json:"street"`
+	HouseNumber string               `json:"houseNumber"`
+	ZipCode     string               `json:"zipCode"`
+	City        string               `json:"city"`
+	Memberships []membershipResponse `json:"memberships"`
+	DeletedAt   string               `json:"deletedAt,omitempty"`
+	Version     int                  `json:"version"`
+
+	CustomFields map[string]string `json:"customFields"`
+}
+
+// personRequest is the payload accepted when creating or updating a person.
+// The membership fields are only evaluated when a person is created. Custom
+// fields which are not contained are left unchanged by an update.
+type personRequest struct {
+	FirstName        string `json:"firstName"`
+	LastName         string `json:"lastName"`
+	DateOfBirth      string `json:"dateOfBirth"`
+	Email            string `json:"email"`
+	Phone            string `json:"phone"`
+	Mobile           string `json:"mobile"`
+	Street           string `json:"street"`
+	HouseNumber      string `json:"houseNumber"`
+	ZipCode          string `json:"zipCode"`
+	City             string `json:"city"`
+	MembershipTypeID int    `json:"membershipTypeId"`
+	EffectiveFrom    string `json:"effectiveFrom"`
+
+	CustomFields map[string]string `json:"customFields"`
+}
+
+// personPatchRequest is the payload accepted when a person is patched. Fields
+// which are left out or null are not changed, an empty string clears a field.
+type personPatchRequest struct {
+	FirstName   *string `json:"firstName"`
+	LastName    *string `json:"lastName"`
+	DateOfBirth *string `json:"dateOfBirth"`
+	Email       *string `json:"email"`
+	Phone       *string `json:"phone"`
+	Mobile      *string `json:"mobile"`
+	Street      *string `json:"street"`
+	HouseNumber *string `json:"houseNumber"`
+	ZipCode     *string `json:"zipCode"`
+	City        *string `json:"city"`
+
+	CustomFields map[string]string `json:"customFields"`
+}
+
+func newPersonResponse(p xone.Person) personResponse {
+	resp := personResponse{
+		PID:         p.PID,
+		FirstName:   p.FirstName,
+		LastName:    p.LastName,
+		DateOfBirth: formatDate(p.DateOfBirth),
+		Email:       p.Email,
+		Phone:       p.Phone,
+		Mobile:      p.Mobile,
+		Street:      p.Street,
+		HouseNumber: p.HouseNumber,
+		ZipCode:     p.ZipCode,
+		City:        p.City,
+		Memberships: []membershipResponse{},
+		Version:     p.Version,
+
+		CustomFields: map[string]string{},
+	}
+	if p.Archived() {
+		resp.DeletedAt = p.DeletedAt.Format(time.RFC3339)
+	}
+
+	for _, m := range p.Memberships {
+		resp.Memberships = append(resp.Memberships, newMembershipResponse(m))
+	}
+	for name, value := range p.CustomFields {
+		resp.CustomFields[name] = value
+	}
+
+	return resp
+}
+
+func (req personRequest) toCreateData() (xone.CreatePersonData, error) {
+	dob, err := parseDate(req.DateOfBirth)
+	if err != nil {
+		return xone.CreatePersonData{}, fmt.Errorf("invalid date of birth: %s", req.DateOfBirth)
+	}
+
+	effectiveFrom, err := parseDate(req.EffectiveFrom)
+	if err != nil {
+		return xone.CreatePersonData{}, fmt.Errorf("invalid effective from date: %s", req.EffectiveFrom)
+	}
+
+	return xone.CreatePersonData{
+		FirstName:        req.FirstName,
+		LastName:         req.LastName,
+		DateOfBirth:      dob,
+		Email:            req.Email,
+		Phone:            req.Phone,
+		Mobile:           req.Mobile,
+		Street:           req.Street,
+		HouseNumber:      req.HouseNumber,
+		ZipCode:          req.ZipCode,
+		City:             req.City,
+		MembershipTypeID: req.MembershipTypeID,
+		EffectiveFrom:    effectiveFrom,
+		CustomFields:     req.CustomFields,
+	}, nil
+}
+
+func (req personRequest) toUpdateData() (xone.UpdatePersonData, error) {
+	dob, err := parseDate(req.DateOfBirth)
+	if err != nil {
+		return xone.UpdatePersonData{}, fmt.Errorf("invalid date of birth: %s", req.DateOfBirth)
+	}
+
+	return xone.UpdatePersonData{
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		DateOfBirth: dob,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Mobile:      req.Mobile,
+		Street:      req.Street,
+		HouseNumber: req.HouseNumber,
+		ZipCode:     req.ZipCode,
+		City:        req.City,
+
+		CustomFields: req.CustomFields,
+	}, nil
+}
+
+func (req personPatchRequest) toPatchData() (xone.PatchPersonData, error) {
+	data := xone.PatchPersonData{
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Mobile:      req.Mobile,
+		Street:      req.Street,
+		HouseNumber: req.HouseNumber,
+		ZipCode:     req.ZipCode,
+		City:        req.City,
+
+		CustomFields: req.CustomFields,
+	}
+
+	if req.DateOfBirth != nil {
+		dob, err := parseDate(*req.DateOfBirth)
+		if err != nil {
+			return xone.PatchPersonData{}, fmt.Errorf("invalid date of birth: %s", *req.DateOfBirth)
+		}
+		data.DateOfBirth = &dob
+	}
+
+	return data, nil
+}
+
+// handlePersons handles requests to "/persons".
+func (s *Server) handlePersons(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handlePersonIndex(w, r)
+	case http.MethodPost:
+		s.handlePersonCreate(w, r)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handlePerson handles requests to "/persons/{pid}",
+// "/persons/{pid}/restore", "/persons/{pid}/dues", "/persons/{pid}/ledger",
+// "/persons/{pid}/mandate" and "/persons/{pid}/invoices".
+func (s *Server) handlePerson(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/persons/"), "/")
+	pid := parts[0]
+	if pid == "" || len(parts) > 2 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if len(parts) == 2 {
+		switch parts[1] {
+		case "restore":
+			s.handlePersonRestore(w, r, pid)
+		case "dues":
+			s.handlePersonDues(w, r, pid)
+		case "ledger":
+			s.handlePersonLedger(w, r, pid)
+		case "mandate":
+			s.handlePersonMandate(w, r, pid)
+		case "invoices":
+			s.handlePersonInvoices(w, r, pid)
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handlePersonView(w, r, pid)
+	case http.MethodPut:
+		s.handlePersonUpdate(w, r, pid)
+	case http.MethodPatch:
+		s.handlePersonPatch(w, r, pid)
+	case http.MethodDelete:
+		s.handlePersonDelete(w, r, pid)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+// handlePersonIndex lists the persons matching the filter given in the query
+// string, see parsePersonFilter. The total number of matching persons is sent
+// in the X-Total-Count header.
+func (s *Server) handlePersonIndex(w http.ResponseWriter, r *http.Request) {
+	filter, err := parsePersonFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	persons, n, err := s.PersonRepository.FindMany(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(n))
+
+	resp := []personResponse{}
+	for _, p := range persons {
+		resp = append(resp, newPersonResponse(p))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlePersonView(w http.ResponseWriter, r *http.Request, pid string) {
+	person, found, err := s.PersonRepository.Find(r.Context(), pid)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "person not found")
+		return
+	}
+
+	setETag(w, person.Version)
+	writeJSON(w, http.StatusOK, newPersonResponse(person))
+}
+
+func (s *Server) handlePersonCreate(w http.ResponseWriter, r *http.Request) {
+	var req personRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	data, err := req.toCreateData()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	person, err := s.PersonRepository.Create(r.Context(), data)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/persons/"+person.PID)
+	setETag(w, person.Version)
+	writeJSON(w, http.StatusCreated, newPersonResponse(person))
+}
+
+// handlePersonUpdate overwrites the data of a person. The If-Match header has
+// to contain the entity tag of the version the changes are based on, or "*" to
+// overwrite whatever version is current.
+func (s *Server) handlePersonUpdate(w http.ResponseWriter, r *http.Request, pid string) {
+	version, matchAny, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req personRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	data, err := req.toUpdateData()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data.Version = version
+
+	if current, found, err := s.PersonRepository.Find(r.Context(), pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "person not found")
+		return
+	} else if matchAny {
+		data.Version = current.Version
+	}
+
+	if err := s.PersonRepository.Update(r.Context(), pid, data); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	person, _, err := s.PersonRepository.Find(r.Context(), pid)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, person.Version)
+	writeJSON(w, http.StatusOK, newPersonResponse(person))
+}
+
+// handlePersonPatch changes only the fields contained in the request. The
+// If-Match header is optional: without it, the changes are applied to the
+// current version of the person.
+func (s *Server) handlePersonPatch(w http.ResponseWriter, r *http.Request, pid string) {
+	// "*" matches any version just like a request without If-Match, so the
+	// version check is skipped for both.
+	var version int
+	if r.Header.Get("If-Match") != "" {
+		v, matchAny, ok := ifMatch(w, r)
+		if !ok {
+			return
+		}
+		if !matchAny {
+			version = v
+		}
+	}
+
+	var req personPatchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	data, err := req.toPatchData()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data.Version = version
+
+	if err := s.PersonRepository.Patch(r.Context(), pid, data); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	person, _, err := s.PersonRepository.Find(r.Context(), pid)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, person.Version)
+	writeJSON(w, http.StatusOK, newPersonResponse(person))
+}
+
+func (s *Server) handlePersonDelete(w http.ResponseWriter, r *http.Request, pid string) {
+	if _, found, err := s.PersonRepository.Find(r.Context(), pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "person not found")
+		return
+	}
+
+	if err := s.PersonRepository.Delete(r.Context(), pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parsePersonFilter reads a person filter from the query parameters q,
+// membershipTypeId, minAge, maxAge, effectiveFrom, effectiveUntil, archived,
+// sort, order (asc or desc), limit and offset.
+func parsePersonFilter(query url.Values) (xone.PersonFilter, error) {
+	filter := xone.PersonFilter{
+		Search: query.Get("q"),
+		SortBy: xone.PersonSortField(query.Get("sort")),
+	}
+
+	if archived := query.Get("archived"); archived != "" {
+		var err error
+		if filter.Archived, err = strconv.ParseBool(archived); err != nil {
+			return xone.PersonFilter{}, fmt.Errorf("invalid archived: %s", archived)
+		}
+	}
+
+	if !filter.SortBy.Valid() {
+		return xone.PersonFilter{}, fmt.Errorf("invalid sort: %s", filter.SortBy)
+	}
+
+	switch order := query.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return xone.PersonFilter{}, fmt.Errorf("invalid order: %s", order)
+	}
+
+	ints := map[string]*int{
+		"membershipTypeId": &filter.MembershipTypeID,
+		"minAge":           &filter.MinAge,
+		"maxAge":           &filter.MaxAge,
+		"limit":            &filter.Limit,
+		"offset":           &filter.Offset,
+	}
+	for name, v := range ints {
+		s := query.Get(name)
+		if s == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return xone.PersonFilter{}, fmt.Errorf("invalid %s: %s", name, s)
+		}
+		*v = n
+	}
+
+	dates := map[string]*time.Time{
+		"effectiveFrom":  &filter.EffectiveFrom,
+		"effectiveUntil": &filter.EffectiveUntil,
+	}
+	for name, v := range dates {
+		t, err := parseDate(query.Get(name))
+		if err != nil {
+			return xone.PersonFilter{}, fmt.Errorf("invalid %s: %s", name, query.Get(name))
+		}
+		*v = t
+	}
+
+	return filter, nil
+}
+
+// handlePersonRestore brings back a deleted person.
+func (s *Server) handlePersonRestore(w http.ResponseWriter, r *http.Request, pid string) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	if err := s.PersonRepository.Restore(r.Context(), pid); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	person, found, err := s.PersonRepository.Find(r.Context(), pid)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "person not found")
+		return
+	}
+
+	setETag(w, person.Version)
+	writeJSON(w, http.StatusOK, newPersonResponse(person))
+}
+
+// formatDate formats a date for use in a JSON payload. Zero dates are
+// represented by an empty string.
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(xone.FormatDateOfBirth)
+}
+
+// parseDate is the counterpart to formatDate.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(xone.FormatDateOfBirth, s)
+}
@@ -0,0 +1,247 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/invoice"
+)
+
+// ShutdownTimeout is the time given for outstanding requests to finish before
+// the server is shut down.
+const ShutdownTimeout = 5 * time.Second
+
+// Server is an HTTP server which exposes the xone services as a JSON API.
+type Server struct {
+	ln     net.Listener
+	server *http.Server
+	mux    *http.ServeMux
+
+	// Addr is the address the server listens on, e.g. ":8080".
+	Addr string
+
+	PersonRepository  xone.PersonRepository
+	MembershipService xone.MembershipService
+	FeeService        xone.FeeService
+	PaymentService    xone.PaymentService
+	MandateService    xone.MandateService
+	InvoiceService    xone.InvoiceService
+	HouseholdService  xone.HouseholdService
+	FieldService      xone.FieldService
+
+	// Issuer is printed on rendered invoices and receipts.
+	Issuer invoice.Issuer
+
+	// UserService and SessionService are used to log users in. If no
+	// SessionService is set, all endpoints can be accessed anonymously. Unless
+	// both are set, "/login" and "/logout" respond with 404 Not Found.
+	UserService    xone.UserService
+	SessionService xone.SessionService
+}
+
+func NewServer() *Server {
+	s := Server{
+		server: &http.Server{},
+		mux:    http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/persons", s.handlePersons)
+	s.mux.HandleFunc("/persons/", s.handlePerson)
+	s.mux.HandleFunc("/membership-types", s.handleMembershipTypes)
+	s.mux.HandleFunc("/membership-types/", s.handleMembershipTypeFees)
+	s.mux.HandleFunc("/fees/", s.handleFee)
+	s.mux.HandleFunc("/arrears", s.handleArrears)
+	s.mux.HandleFunc("/invoices/", s.handleInvoice)
+	s.mux.HandleFunc("/households", s.handleHouseholds)
+	s.mux.HandleFunc("/households/", s.handleHousehold)
+	s.mux.HandleFunc("/fields", s.handleFields)
+	s.mux.HandleFunc("/fields/", s.handleField)
+	s.mux.HandleFunc("/memberships/", s.handleMembership)
+	s.mux.HandleFunc("/login", s.handleLogin)
+	s.mux.HandleFunc("/logout", s.handleLogout)
+
+	s.server.Handler = &s
+
+	return &s
+}
+
+// Open starts listening on the configured address and serves requests in the
+// background.
+func (s *Server) Open() (err error) {
+	if s.ln, err = net.Listen("tcp", s.Addr); err != nil {
+		return err
+	}
+
+	go s.server.Serve(s.ln)
+
+	return nil
+}
+
+// Close gracefully shuts down the server. Requests which are still in flight
+// are given ShutdownTimeout to complete.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	return s.server.Shutdown(ctx)
+}
+
+// URL returns the base URL of the running server.
+func (s *Server) URL() string {
+	if s.ln == nil {
+		return ""
+	}
+
+	return "http://" + s.ln.Addr().String()
+}
+
+// ReasonHeader is the request header which carries the reason for a change.
+// It is recorded in the audit log.
+const ReasonHeader = "X-Change-Reason"
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.SessionService != nil && r.URL.Path != "/login" {
+		session, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		r = r.WithContext(xone.NewContextWithUser(r.Context(), session.User))
+	}
+
+	if reason := r.Header.Get(ReasonHeader); reason != "" {
+		r = r.WithContext(xone.NewContextWithReason(r.Context(), reason))
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// pathParam returns the path segment following the given prefix, e.g. the
+// PID in "/persons/{pid}". An empty string is returned if there is no such
+// segment or if the path contains further segments.
+func pathParam(r *http.Request, prefix string) string {
+	param := strings.TrimPrefix(r.URL.Path, prefix)
+	if strings.Contains(param, "/") {
+		return ""
+	}
+
+	return param
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func decodeJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	return dec.Decode(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("http: cannot encode response: %v", err)
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+
+	// Fields lists the invalid fields of a rejected request, see
+	// xone.ValidationError.
+	Fields []fieldErrorResponse `json:"fields,omitempty"`
+}
+
+type fieldErrorResponse struct {
+	Field string `json:"field"`
+	Code  string `json:"code"`
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+// setETag sends the version of an entity as its entity tag, e.g. "3".
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatch returns the version named by the If-Match header of an update. The
+// header is required and has to contain an entity tag sent by setETag or "*".
+// Weak entity tags are accepted as well, as the version changes with every
+// change of the resource. For "*", which matches whatever version is current,
+// matchAny is true and the handler has to look up the current version itself.
+// If the header is missing or cannot match any version, an error response is
+// written and ok is false.
+func ifMatch(w http.ResponseWriter, r *http.Request) (version int, matchAny bool, ok bool) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" {
+		writeError(w, http.StatusPreconditionRequired, "If-Match header required")
+		return 0, false, false
+	}
+	if tag == "*" {
+		return 0, true, true
+	}
+
+	s, err := strconv.Unquote(strings.TrimPrefix(tag, "W/"))
+	if err != nil {
+		writeError(w, http.StatusPreconditionFailed, "entity tag does not match")
+		return 0, false, false
+	}
+	version, err = strconv.Atoi(s)
+	if err != nil || version < 1 {
+		writeError(w, http.StatusPreconditionFailed, "entity tag does not match")
+		return 0, false, false
+	}
+
+	return version, false, true
+}
+
+// writeServiceError translates an error returned by one of the services into
+// an appropriate HTTP response. The specific errors of the xone package are
+// matched by their kind, e.g. every *xone.ErrConflict results in a conflict.
+// A stale version is reported as a failed precondition because the version is
+// sent in the If-Match header, see ifMatch.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var forbidden *xone.ErrForbidden
+	var invalidData *xone.ValidationError
+	var notFound *xone.ErrNotFound
+	var stale *xone.ErrStaleVersion
+	var conflict *xone.ErrConflict
+	var invalid *xone.ErrInvalid
+
+	switch {
+	case errors.As(err, &forbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.As(err, &invalidData):
+		resp := errorResponse{Error: err.Error()}
+		for _, fe := range invalidData.Errors {
+			resp.Fields = append(resp.Fields, fieldErrorResponse{Field: fe.Field, Code: string(fe.Code)})
+		}
+		writeJSON(w, http.StatusBadRequest, resp)
+	case errors.As(err, &notFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &stale):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.As(err, &conflict):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.As(err, &invalid):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("http: %s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
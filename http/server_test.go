@@ -0,0 +1,683 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stillwondering/xone/http"
+	"github.com/stillwondering/xone/sqlite"
+)
+
+// MustOpenServer returns a server which is backed by a fresh sqlite database.
+func MustOpenServer(tb testing.TB) *http.Server {
+	tb.Helper()
+
+	db, err := sqlite.Open(filepath.Join(tb.TempDir(), "xone.db"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		db.Close()
+	})
+
+	s := http.NewServer()
+	s.PersonRepository = sqlite.NewPersonService(db)
+	s.MembershipService = sqlite.NewMembershipService(db)
+	s.FeeService = sqlite.NewFeeService(db)
+	s.PaymentService = sqlite.NewPaymentService(db)
+	s.MandateService = sqlite.NewMandateService(db)
+	s.InvoiceService = sqlite.NewInvoiceService(db)
+	s.HouseholdService = sqlite.NewHouseholdService(db)
+	s.FieldService = sqlite.NewFieldService(db)
+
+	return s
+}
+
+// do sends a request to the server and decodes the JSON response into v,
+// unless v is nil. The status code of the response is returned.
+func do(tb testing.TB, s *http.Server, method, path string, body interface{}, v interface{}) int {
+	tb.Helper()
+
+	code, _ := doRequest(tb, s, method, path, nil, body, v)
+
+	return code
+}
+
+// doRequest is like do but sends the given headers along with the request and
+// returns the headers of the response as well.
+func doRequest(tb testing.TB, s *http.Server, method, path string, header nethttp.Header, body interface{}, v interface{}) (int, nethttp.Header) {
+	tb.Helper()
+
+	var r io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		r = bytes.NewReader(buf)
+	}
+
+	req := httptest.NewRequest(method, path, r)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	if v != nil {
+		if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+			tb.Fatalf("%s %s: cannot decode response: %v", method, path, err)
+		}
+	}
+
+	return w.Code, w.Header()
+}
+
+func TestServer_OpenClose(t *testing.T) {
+	s := MustOpenServer(t)
+	s.Addr = "127.0.0.1:0"
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := nethttp.Get(s.URL() + "/persons")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusOK {
+		t.Errorf("GET /persons status = %v, want %v", resp.StatusCode, nethttp.StatusOK)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_Persons(t *testing.T) {
+	s := MustOpenServer(t)
+
+	var mt map[string]interface{}
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "active"}, &mt); code != nethttp.StatusCreated {
+		t.Fatalf("POST /membership-types status = %v, want %v", code, nethttp.StatusCreated)
+	}
+
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "active"}, nil); code != nethttp.StatusConflict {
+		t.Errorf("POST /membership-types status = %v, want %v", code, nethttp.StatusConflict)
+	}
+
+	var created map[string]interface{}
+	code := do(t, s, "POST", "/persons", map[string]interface{}{
+		"firstName":        "Harry",
+		"lastName":         "Potter",
+		"dateOfBirth":      "1980-07-31",
+		"membershipTypeId": mt["id"],
+		"effectiveFrom":    "1998-07-31",
+	}, &created)
+	if code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	pid := created["pid"].(string)
+
+	var invalid struct {
+		Fields []struct{ Field, Code string }
+	}
+	code = do(t, s, "POST", "/persons", map[string]interface{}{
+		"firstName":        "Ron",
+		"email":            "ron.weasley",
+		"membershipTypeId": mt["id"],
+	}, &invalid)
+	if code != nethttp.StatusBadRequest || len(invalid.Fields) != 2 || invalid.Fields[0].Field != "last_name" || invalid.Fields[1].Code != "invalid_format" {
+		t.Errorf("POST /persons with invalid data = %v, %+v, want the last name and the email to be reported", code, invalid)
+	}
+
+	var found map[string]interface{}
+	code, header := doRequest(t, s, "GET", "/persons/"+pid, nil, nil, &found)
+	if code != nethttp.StatusOK {
+		t.Fatalf("GET /persons/{pid} status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if found["dateOfBirth"] != "1980-07-31" {
+		t.Errorf("GET /persons/{pid} dateOfBirth = %v, want %v", found["dateOfBirth"], "1980-07-31")
+	}
+	etag := header.Get("ETag")
+	if etag != `"1"` {
+		t.Errorf("GET /persons/{pid} ETag = %v, want %v", etag, `"1"`)
+	}
+
+	var updated map[string]interface{}
+	update := map[string]interface{}{
+		"firstName":   "Harry",
+		"lastName":    "Potter",
+		"dateOfBirth": "1980-07-31",
+		"email":       "harry.potter@hogwarts.co.uk",
+	}
+	code, header = doRequest(t, s, "PUT", "/persons/"+pid, nethttp.Header{"If-Match": {etag}}, update, &updated)
+	if code != nethttp.StatusOK {
+		t.Fatalf("PUT /persons/{pid} status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if updated["email"] != "harry.potter@hogwarts.co.uk" {
+		t.Errorf("PUT /persons/{pid} email = %v, want %v", updated["email"], "harry.potter@hogwarts.co.uk")
+	}
+	if got := header.Get("ETag"); got != `"2"` {
+		t.Errorf("PUT /persons/{pid} ETag = %v, want %v", got, `"2"`)
+	}
+	if code, _ := doRequest(t, s, "PUT", "/persons/"+pid, nethttp.Header{"If-Match": {etag}}, update, nil); code != nethttp.StatusPreconditionFailed {
+		t.Errorf("PUT /persons/{pid} with a stale ETag status = %v, want %v", code, nethttp.StatusPreconditionFailed)
+	}
+
+	var patched map[string]interface{}
+	code, header = doRequest(t, s, "PATCH", "/persons/"+pid, nil, map[string]string{"phone": "1234"}, &patched)
+	if code != nethttp.StatusOK {
+		t.Fatalf("PATCH /persons/{pid} status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if patched["phone"] != "1234" || patched["email"] != "harry.potter@hogwarts.co.uk" {
+		t.Errorf("PATCH /persons/{pid} = %v, want only the phone number to be changed", patched)
+	}
+	if got := header.Get("ETag"); got != `"3"` {
+		t.Errorf("PATCH /persons/{pid} ETag = %v, want %v", got, `"3"`)
+	}
+	if code, _ := doRequest(t, s, "PATCH", "/persons/"+pid, nethttp.Header{"If-Match": {`"2"`}}, map[string]string{"phone": ""}, nil); code != nethttp.StatusPreconditionFailed {
+		t.Errorf("PATCH /persons/{pid} with a stale ETag status = %v, want %v", code, nethttp.StatusPreconditionFailed)
+	}
+	if code := do(t, s, "PATCH", "/persons/"+pid, map[string]string{"dateOfBirth": "31.07.1980"}, nil); code != nethttp.StatusBadRequest {
+		t.Errorf("PATCH /persons/{pid} with an invalid date status = %v, want %v", code, nethttp.StatusBadRequest)
+	}
+
+	var persons []interface{}
+	if code := do(t, s, "GET", "/persons", nil, &persons); code != nethttp.StatusOK {
+		t.Fatalf("GET /persons status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if len(persons) != 1 {
+		t.Errorf("GET /persons len = %v, want %v", len(persons), 1)
+	}
+	if code := do(t, s, "GET", "/persons?q=weasley", nil, &persons); code != nethttp.StatusOK {
+		t.Fatalf("GET /persons?q=weasley status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if len(persons) != 0 {
+		t.Errorf("GET /persons?q=weasley len = %v, want %v", len(persons), 0)
+	}
+
+	if code := do(t, s, "DELETE", "/persons/"+pid, nil, nil); code != nethttp.StatusNoContent {
+		t.Errorf("DELETE /persons/{pid} status = %v, want %v", code, nethttp.StatusNoContent)
+	}
+
+	if code := do(t, s, "GET", "/persons/"+pid, nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("GET /persons/{pid} status = %v, want %v", code, nethttp.StatusNotFound)
+	}
+	if code, _ := doRequest(t, s, "PUT", "/persons/"+pid, nethttp.Header{"If-Match": {`"3"`}}, map[string]string{"firstName": "Harry"}, nil); code != nethttp.StatusNotFound {
+		t.Errorf("PUT /persons/{pid} status = %v, want %v", code, nethttp.StatusNotFound)
+	}
+	if code := do(t, s, "DELETE", "/persons/"+pid, nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("DELETE /persons/{pid} status = %v, want %v", code, nethttp.StatusNotFound)
+	}
+
+	if code := do(t, s, "GET", "/persons?archived=true", nil, &persons); code != nethttp.StatusOK {
+		t.Fatalf("GET /persons?archived=true status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if len(persons) != 1 {
+		t.Errorf("GET /persons?archived=true len = %v, want %v", len(persons), 1)
+	}
+	if code := do(t, s, "POST", "/persons/"+pid+"/restore", nil, nil); code != nethttp.StatusOK {
+		t.Errorf("POST /persons/{pid}/restore status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if code := do(t, s, "GET", "/persons/"+pid, nil, nil); code != nethttp.StatusOK {
+		t.Errorf("GET /persons/{pid} after restore status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if code := do(t, s, "POST", "/persons/unknown/restore", nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("POST /persons/unknown/restore status = %v, want %v", code, nethttp.StatusNotFound)
+	}
+
+	mid := fmt.Sprint(created["memberships"].([]interface{})[0].(map[string]interface{})["id"])
+	membership := map[string]interface{}{"membershipTypeId": mt["id"], "effectiveFrom": "1998-08-01"}
+	if code, _ := doRequest(t, s, "PUT", "/memberships/"+mid, nethttp.Header{"If-Match": {`"1"`}}, membership, nil); code != nethttp.StatusNoContent {
+		t.Errorf("PUT /memberships/{id} status = %v, want %v", code, nethttp.StatusNoContent)
+	}
+	if code, _ := doRequest(t, s, "PUT", "/memberships/"+mid, nethttp.Header{"If-Match": {`"1"`}}, membership, nil); code != nethttp.StatusPreconditionFailed {
+		t.Errorf("PUT /memberships/{id} with a stale ETag status = %v, want %v", code, nethttp.StatusPreconditionFailed)
+	}
+	if code, _ := doRequest(t, s, "PUT", "/memberships/4242", nethttp.Header{"If-Match": {`"1"`}}, membership, nil); code != nethttp.StatusNotFound {
+		t.Errorf("PUT /memberships/{id} of an unknown membership status = %v, want %v", code, nethttp.StatusNotFound)
+	}
+	if code := do(t, s, "POST", "/memberships/"+mid+"/terminate", map[string]string{"endDate": "2000-12-31", "reason": "resignation"}, nil); code != nethttp.StatusNoContent {
+		t.Errorf("POST /memberships/{id}/terminate status = %v, want %v", code, nethttp.StatusNoContent)
+	}
+	if code := do(t, s, "GET", "/persons/"+pid, nil, &found); code != nethttp.StatusOK {
+		t.Fatalf("GET /persons/{pid} status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if m := found["memberships"].([]interface{})[0].(map[string]interface{}); m["endDate"] != "2000-12-31" || m["terminationReason"] != "resignation" {
+		t.Errorf("GET /persons/{pid} membership = %v, want end date and reason", m)
+	}
+	if code := do(t, s, "POST", "/memberships/"+mid+"/terminate", map[string]string{"endDate": "2000-12-31", "reason": "boredom"}, nil); code != nethttp.StatusBadRequest {
+		t.Errorf("POST /memberships/{id}/terminate status = %v, want %v", code, nethttp.StatusBadRequest)
+	}
+	if code := do(t, s, "POST", "/memberships/"+mid+"/reinstate", nil, nil); code != nethttp.StatusNoContent {
+		t.Errorf("POST /memberships/{id}/reinstate status = %v, want %v", code, nethttp.StatusNoContent)
+	}
+	if code := do(t, s, "GET", "/persons/"+pid, nil, &found); code != nethttp.StatusOK {
+		t.Fatalf("GET /persons/{pid} status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if m := found["memberships"].([]interface{})[0].(map[string]interface{}); m["endDate"] != nil {
+		t.Errorf("GET /persons/{pid} membership endDate = %v, want none", m["endDate"])
+	}
+}
+
+func TestServer_IfMatch(t *testing.T) {
+	s := MustOpenServer(t)
+
+	var mt map[string]interface{}
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "active"}, &mt); code != nethttp.StatusCreated {
+		t.Fatalf("POST /membership-types status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	var created map[string]interface{}
+	if code := do(t, s, "POST", "/persons", map[string]interface{}{"firstName": "Harry", "lastName": "Potter", "membershipTypeId": mt["id"]}, &created); code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	path := "/persons/" + created["pid"].(string)
+	update := map[string]interface{}{"firstName": "Harry", "lastName": "Potter"}
+
+	tests := []struct {
+		name     string
+		ifMatch  string
+		want     int
+		wantETag string
+	}{
+		{name: "Weak entity tag", ifMatch: `W/"1"`, want: nethttp.StatusOK, wantETag: `"2"`},
+		{name: "Any version", ifMatch: "*", want: nethttp.StatusOK, wantETag: `"3"`},
+		{name: "Stale weak entity tag", ifMatch: `W/"1"`, want: nethttp.StatusPreconditionFailed},
+		{name: "Version zero", ifMatch: `"0"`, want: nethttp.StatusPreconditionFailed},
+		{name: "Malformed entity tag", ifMatch: `W/3`, want: nethttp.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		code, header := doRequest(t, s, "PUT", path, nethttp.Header{"If-Match": {tt.ifMatch}}, update, nil)
+		if code != tt.want {
+			t.Errorf("%s: PUT /persons/{pid} status = %v, want %v", tt.name, code, tt.want)
+		}
+		if got := header.Get("ETag"); tt.wantETag != "" && got != tt.wantETag {
+			t.Errorf("%s: PUT /persons/{pid} ETag = %v, want %v", tt.name, got, tt.wantETag)
+		}
+	}
+
+	if code, header := doRequest(t, s, "PATCH", path, nethttp.Header{"If-Match": {`W/"3"`}}, map[string]string{"phone": "1234"}, nil); code != nethttp.StatusOK || header.Get("ETag") != `"4"` {
+		t.Errorf("PATCH /persons/{pid} with a weak entity tag = %v, %v, want %v", code, header.Get("ETag"), nethttp.StatusOK)
+	}
+
+	mid := fmt.Sprint(created["memberships"].([]interface{})[0].(map[string]interface{})["id"])
+	membership := map[string]interface{}{"membershipTypeId": mt["id"], "effectiveFrom": "1998-08-01"}
+	for i := 0; i < 2; i++ {
+		if code, _ := doRequest(t, s, "PUT", "/memberships/"+mid, nethttp.Header{"If-Match": {"*"}}, membership, nil); code != nethttp.StatusNoContent {
+			t.Errorf("PUT /memberships/{id} with any version status = %v, want %v", code, nethttp.StatusNoContent)
+		}
+	}
+	if code, _ := doRequest(t, s, "PUT", "/memberships/"+mid, nethttp.Header{"If-Match": {`W/"3"`}}, membership, nil); code != nethttp.StatusNoContent {
+		t.Errorf("PUT /memberships/{id} with a weak entity tag status = %v, want %v", code, nethttp.StatusNoContent)
+	}
+	if code, _ := doRequest(t, s, "PUT", "/memberships/4242", nethttp.Header{"If-Match": {"*"}}, membership, nil); code != nethttp.StatusNotFound {
+		t.Errorf("PUT /memberships/{id} unknown membership with any version status = %v, want %v", code, nethttp.StatusNotFound)
+	}
+}
+
+func TestServer_Fees(t *testing.T) {
+	s := MustOpenServer(t)
+
+	var mt map[string]interface{}
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "adult"}, &mt); code != nethttp.StatusCreated {
+		t.Fatalf("POST /membership-types status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	path := fmt.Sprintf("/membership-types/%v/fees", mt["id"])
+
+	fee := map[string]interface{}{"amount": 12000, "period": "annual", "validFrom": "2022-01-01"}
+	var created map[string]interface{}
+	if code := do(t, s, "POST", path, fee, &created); code != nethttp.StatusCreated {
+		t.Fatalf("POST %s status = %v, want %v", path, code, nethttp.StatusCreated)
+	}
+	if code := do(t, s, "POST", path, fee, nil); code != nethttp.StatusConflict {
+		t.Errorf("POST %s status = %v, want %v", path, code, nethttp.StatusConflict)
+	}
+	if code := do(t, s, "POST", path, map[string]interface{}{"amount": 100, "period": "weekly", "validFrom": "2030-01-01"}, nil); code != nethttp.StatusBadRequest {
+		t.Errorf("POST %s status = %v, want %v", path, code, nethttp.StatusBadRequest)
+	}
+
+	var types []map[string]interface{}
+	if code := do(t, s, "GET", "/membership-types", nil, &types); code != nethttp.StatusOK {
+		t.Fatalf("GET /membership-types status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if fees, _ := types[0]["fees"].([]interface{}); len(fees) != 1 {
+		t.Errorf("GET /membership-types fees = %v, want one fee", types[0]["fees"])
+	}
+
+	var person map[string]interface{}
+	if code := do(t, s, "POST", "/persons", map[string]interface{}{"firstName": "Harry", "lastName": "Potter", "membershipTypeId": mt["id"], "effectiveFrom": "2022-07-01"}, &person); code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+
+	var dues map[string]interface{}
+	if code := do(t, s, "GET", fmt.Sprintf("/persons/%v/dues?from=2022-01-01&until=2022-12-31", person["pid"]), nil, &dues); code != nethttp.StatusOK {
+		t.Fatalf("GET /persons/{pid}/dues status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if dues["total"] != float64(6049) {
+		t.Errorf("GET /persons/{pid}/dues total = %v, want %v", dues["total"], 6049)
+	}
+	if code := do(t, s, "GET", "/persons/unknown/dues", nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("GET /persons/unknown/dues status = %v, want %v", code, nethttp.StatusNotFound)
+	}
+
+	if code := do(t, s, "DELETE", fmt.Sprintf("/fees/%v", created["id"]), nil, nil); code != nethttp.StatusNoContent {
+		t.Errorf("DELETE /fees/{id} status = %v, want %v", code, nethttp.StatusNoContent)
+	}
+	var fees []interface{}
+	if code := do(t, s, "GET", path, nil, &fees); code != nethttp.StatusOK || len(fees) != 0 {
+		t.Errorf("GET %s = %v, %v, want no fees", path, code, fees)
+	}
+}
+
+func TestServer_Ledger(t *testing.T) {
+	s := MustOpenServer(t)
+
+	var mt map[string]interface{}
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "active"}, &mt); code != nethttp.StatusCreated {
+		t.Fatalf("POST /membership-types status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	var person map[string]interface{}
+	if code := do(t, s, "POST", "/persons", map[string]interface{}{"firstName": "Harry", "lastName": "Potter", "membershipTypeId": mt["id"]}, &person); code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	path := fmt.Sprintf("/persons/%v/ledger", person["pid"])
+
+	if code := do(t, s, "POST", path, map[string]interface{}{"kind": "charge", "date": "2022-01-01", "amount": 12000, "description": "Dues 2022"}, nil); code != nethttp.StatusCreated {
+		t.Fatalf("POST %s status = %v, want %v", path, code, nethttp.StatusCreated)
+	}
+	if code := do(t, s, "POST", path, map[string]interface{}{"kind": "payment", "date": "2022-02-01", "amount": 5000, "method": "cheque"}, nil); code != nethttp.StatusBadRequest {
+		t.Errorf("POST %s status = %v, want %v", path, code, nethttp.StatusBadRequest)
+	}
+	if code := do(t, s, "POST", path, map[string]interface{}{"kind": "payment", "date": "2022-02-01", "amount": 5000, "method": "bank_transfer"}, nil); code != nethttp.StatusCreated {
+		t.Fatalf("POST %s status = %v, want %v", path, code, nethttp.StatusCreated)
+	}
+
+	var ledger map[string]interface{}
+	if code := do(t, s, "GET", path, nil, &ledger); code != nethttp.StatusOK {
+		t.Fatalf("GET %s status = %v, want %v", path, code, nethttp.StatusOK)
+	}
+	if ledger["balance"] != float64(7000) {
+		t.Errorf("GET %s balance = %v, want %v", path, ledger["balance"], 7000)
+	}
+
+	var arrears []map[string]interface{}
+	if code := do(t, s, "GET", "/arrears?date=2022-12-31", nil, &arrears); code != nethttp.StatusOK {
+		t.Fatalf("GET /arrears status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if len(arrears) != 1 || arrears[0]["pid"] != person["pid"] {
+		t.Errorf("GET /arrears = %v, want Harry", arrears)
+	}
+
+	if code := do(t, s, "DELETE", fmt.Sprintf("/persons/%v", person["pid"]), nil, nil); code != nethttp.StatusConflict {
+		t.Errorf("DELETE /persons/{pid} status = %v, want %v", code, nethttp.StatusConflict)
+	}
+}
+
+func TestServer_Mandate(t *testing.T) {
+	s := MustOpenServer(t)
+
+	var mt map[string]interface{}
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "active"}, &mt); code != nethttp.StatusCreated {
+		t.Fatalf("POST /membership-types status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	var person map[string]interface{}
+	if code := do(t, s, "POST", "/persons", map[string]interface{}{"firstName": "Harry", "lastName": "Potter", "membershipTypeId": mt["id"]}, &person); code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	path := fmt.Sprintf("/persons/%v/mandate", person["pid"])
+
+	if code := do(t, s, "GET", path, nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("GET %s status = %v, want %v", path, code, nethttp.StatusNotFound)
+	}
+	if code := do(t, s, "PUT", path, map[string]string{"iban": "DE89370400440532013001", "reference": "M-1", "signedOn": "2022-01-01"}, nil); code != nethttp.StatusBadRequest {
+		t.Errorf("PUT %s with invalid IBAN status = %v, want %v", path, code, nethttp.StatusBadRequest)
+	}
+
+	var mandate map[string]interface{}
+	if code := do(t, s, "PUT", path, map[string]string{"iban": "DE89 3704 0044 0532 0130 00", "reference": "M-1", "signedOn": "2022-01-01"}, &mandate); code != nethttp.StatusOK {
+		t.Fatalf("PUT %s status = %v, want %v", path, code, nethttp.StatusOK)
+	}
+	if mandate["iban"] != "DE89370400440532013000" || mandate["sequenceType"] != "FRST" {
+		t.Errorf("PUT %s = %v, want normalized IBAN and FRST", path, mandate)
+	}
+
+	if code := do(t, s, "DELETE", path, nil, nil); code != nethttp.StatusNoContent {
+		t.Errorf("DELETE %s status = %v, want %v", path, code, nethttp.StatusNoContent)
+	}
+	if code := do(t, s, "GET", path, nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("GET %s after delete status = %v, want %v", path, code, nethttp.StatusNotFound)
+	}
+}
+
+func TestServer_Invoices(t *testing.T) {
+	s := MustOpenServer(t)
+
+	var mt map[string]interface{}
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "active"}, &mt); code != nethttp.StatusCreated {
+		t.Fatalf("POST /membership-types status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	var person map[string]interface{}
+	if code := do(t, s, "POST", "/persons", map[string]interface{}{"firstName": "Harry", "lastName": "Potter", "city": "Little Whinging", "membershipTypeId": mt["id"]}, &person); code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	path := fmt.Sprintf("/persons/%v/invoices", person["pid"])
+
+	body := map[string]interface{}{"kind": "invoice", "issuedOn": "2022-01-03", "items": []map[string]interface{}{{"description": "Membership fee 2022", "amount": 12000}}}
+	for _, want := range []string{"INV-2022-0001", "INV-2022-0002"} {
+		var invoice map[string]interface{}
+		if code := do(t, s, "POST", path, body, &invoice); code != nethttp.StatusCreated {
+			t.Fatalf("POST %s status = %v, want %v", path, code, nethttp.StatusCreated)
+		}
+		if invoice["number"] != want || invoice["total"] != float64(12000) {
+			t.Errorf("POST %s = %v, want number %s", path, invoice, want)
+		}
+	}
+
+	var invoices []map[string]interface{}
+	if code := do(t, s, "GET", path, nil, &invoices); code != nethttp.StatusOK {
+		t.Fatalf("GET %s status = %v, want %v", path, code, nethttp.StatusOK)
+	}
+	if len(invoices) != 2 {
+		t.Errorf("GET %s = %v, want two invoices", path, invoices)
+	}
+
+	tests := []struct {
+		format          string
+		wantContentType string
+		wantBody        string
+	}{
+		{format: "html", wantContentType: "text/html; charset=utf-8", wantBody: "Little Whinging"},
+		{format: "pdf", wantContentType: "application/pdf", wantBody: "%PDF-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest("GET", "/invoices/INV-2022-0001?format="+tt.format, nil))
+			if w.Code != nethttp.StatusOK {
+				t.Fatalf("GET /invoices/INV-2022-0001 status = %v, want %v", w.Code, nethttp.StatusOK)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("GET /invoices/INV-2022-0001 Content-Type = %v, want %v", got, tt.wantContentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("GET /invoices/INV-2022-0001 = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if code := do(t, s, "GET", "/invoices/INV-2022-0003", nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("GET /invoices/INV-2022-0003 status = %v, want %v", code, nethttp.StatusNotFound)
+	}
+}
+
+func TestServer_Households(t *testing.T) {
+	s := MustOpenServer(t)
+
+	var mt map[string]interface{}
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "family"}, &mt); code != nethttp.StatusCreated {
+		t.Fatalf("POST /membership-types status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	var arthur, molly map[string]interface{}
+	if code := do(t, s, "POST", "/persons", map[string]interface{}{"firstName": "Arthur", "lastName": "Weasley", "street": "The Burrow", "membershipTypeId": mt["id"]}, &arthur); code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	if code := do(t, s, "POST", "/persons", map[string]interface{}{"firstName": "Molly", "lastName": "Weasley", "membershipTypeId": mt["id"]}, &molly); code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+
+	var household map[string]interface{}
+	if code := do(t, s, "POST", "/households", map[string]interface{}{"primaryContact": arthur["pid"]}, &household); code != nethttp.StatusCreated {
+		t.Fatalf("POST /households status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	if household["name"] != "Weasley" || household["street"] != "The Burrow" {
+		t.Errorf("POST /households = %v, want the name and address of the primary contact", household)
+	}
+	path := fmt.Sprintf("/households/%v", household["id"])
+
+	if code := do(t, s, "POST", path+"/members", map[string]interface{}{"pid": molly["pid"]}, nil); code != nethttp.StatusNoContent {
+		t.Fatalf("POST %s/members status = %v, want %v", path, code, nethttp.StatusNoContent)
+	}
+	if code := do(t, s, "POST", "/households", map[string]interface{}{"primaryContact": molly["pid"]}, nil); code != nethttp.StatusConflict {
+		t.Errorf("POST /households with a member of another household status = %v, want %v", code, nethttp.StatusConflict)
+	}
+
+	body := map[string]interface{}{"name": "Weasley", "primaryContact": molly["pid"], "street": "The Burrow", "city": "Ottery St Catchpole", "propagateAddress": true}
+	if code := do(t, s, "PUT", path, body, &household); code != nethttp.StatusOK {
+		t.Fatalf("PUT %s status = %v, want %v", path, code, nethttp.StatusOK)
+	}
+	var person map[string]interface{}
+	if code := do(t, s, "GET", fmt.Sprintf("/persons/%v", molly["pid"]), nil, &person); code != nethttp.StatusOK || person["city"] != "Ottery St Catchpole" {
+		t.Errorf("GET /persons/{pid} = %v, %v, want the propagated address", code, person)
+	}
+
+	memberPath := fmt.Sprintf("%s/members/%v", path, molly["pid"])
+	if code := do(t, s, "DELETE", memberPath, nil, nil); code != nethttp.StatusConflict {
+		t.Errorf("DELETE %s status = %v, want %v for the primary contact", memberPath, code, nethttp.StatusConflict)
+	}
+	memberPath = fmt.Sprintf("%s/members/%v", path, arthur["pid"])
+	if code := do(t, s, "DELETE", memberPath, nil, nil); code != nethttp.StatusNoContent {
+		t.Errorf("DELETE %s status = %v, want %v", memberPath, code, nethttp.StatusNoContent)
+	}
+
+	var households []map[string]interface{}
+	if code := do(t, s, "GET", "/households", nil, &households); code != nethttp.StatusOK {
+		t.Fatalf("GET /households status = %v, want %v", code, nethttp.StatusOK)
+	}
+	if len(households) != 1 || len(households[0]["members"].([]interface{})) != 1 {
+		t.Errorf("GET /households = %v, want one household with one member", households)
+	}
+
+	if code := do(t, s, "DELETE", path, nil, nil); code != nethttp.StatusNoContent {
+		t.Errorf("DELETE %s status = %v, want %v", path, code, nethttp.StatusNoContent)
+	}
+	if code := do(t, s, "GET", path, nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("GET %s status = %v, want %v", path, code, nethttp.StatusNotFound)
+	}
+}
+
+func TestServer_BadRequests(t *testing.T) {
+	s := MustOpenServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		want   int
+	}{
+		{name: "Invalid date of birth", method: "POST", path: "/persons", body: map[string]string{"dateOfBirth": "31.07.1980"}, want: nethttp.StatusBadRequest},
+		{name: "Unknown field", method: "POST", path: "/persons", body: map[string]string{"unknown": "field"}, want: nethttp.StatusBadRequest},
+		{name: "Empty membership type name", method: "POST", path: "/membership-types", body: map[string]string{}, want: nethttp.StatusBadRequest},
+		{name: "Invalid sort", method: "GET", path: "/persons?sort=password", want: nethttp.StatusBadRequest},
+		{name: "Invalid limit", method: "GET", path: "/persons?limit=-1", want: nethttp.StatusBadRequest},
+		{name: "Method not allowed", method: "PATCH", path: "/persons", want: nethttp.StatusMethodNotAllowed},
+		{name: "Update without If-Match", method: "PUT", path: "/persons/pid", body: map[string]string{"firstName": "Harry"}, want: nethttp.StatusPreconditionRequired},
+		{name: "Invalid membership ID", method: "PUT", path: "/memberships/abc", body: map[string]string{}, want: nethttp.StatusNotFound},
+		{name: "Login without user service", method: "POST", path: "/login", body: map[string]string{"email": "harry@hogwarts.co.uk", "password": "x"}, want: nethttp.StatusNotFound},
+		{name: "Logout without session service", method: "POST", path: "/logout", want: nethttp.StatusNotFound},
+		{name: "Missing end date", method: "POST", path: "/memberships/1/terminate", body: map[string]string{"reason": "death"}, want: nethttp.StatusBadRequest},
+		{name: "Fee without valid from date", method: "POST", path: "/membership-types/1/fees", body: map[string]interface{}{"amount": 100, "period": "annual"}, want: nethttp.StatusBadRequest},
+		{name: "Invalid dues range", method: "GET", path: "/persons/pid/dues?from=2022-12-31&until=2022-01-01", want: nethttp.StatusBadRequest},
+		{name: "Ledger of unknown person", method: "POST", path: "/persons/unknown/ledger", body: map[string]interface{}{"kind": "refund", "amount": 100}, want: nethttp.StatusNotFound},
+		{name: "Invalid arrears date", method: "GET", path: "/arrears?date=tomorrow", want: nethttp.StatusBadRequest},
+		{name: "Invalid invoice format", method: "GET", path: "/invoices/INV-2022-0001?format=docx", want: nethttp.StatusBadRequest},
+		{name: "Household without primary contact", method: "POST", path: "/households", body: map[string]string{"name": "Weasley"}, want: nethttp.StatusBadRequest},
+		{name: "Unknown household", method: "GET", path: "/households/42", want: nethttp.StatusNotFound},
+		{name: "Terminate with GET", method: "GET", path: "/memberships/1/terminate", want: nethttp.StatusMethodNotAllowed},
+		{name: "PID named like an action", method: "GET", path: "/persons/restore", want: nethttp.StatusNotFound},
+		{name: "Restore with trailing slash", method: "POST", path: "/persons/pid/restore/", want: nethttp.StatusNotFound},
+		{name: "Unknown person action", method: "GET", path: "/persons/pid/unknown", want: nethttp.StatusNotFound},
+		{name: "Terminate with trailing slash", method: "POST", path: "/memberships/1/terminate/", body: map[string]string{"reason": "death"}, want: nethttp.StatusNotFound},
+		{name: "Unknown membership action", method: "POST", path: "/memberships/1/resume", want: nethttp.StatusNotFound},
+		{name: "Fees with trailing slash", method: "GET", path: "/membership-types/1/fees/", want: nethttp.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := do(t, s, tt.method, tt.path, tt.body, nil); got != tt.want {
+				t.Errorf("%s %s status = %v, want %v", tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Fields(t *testing.T) {
+	s := MustOpenServer(t)
+
+	var mt map[string]interface{}
+	if code := do(t, s, "POST", "/membership-types", map[string]string{"name": "active"}, &mt); code != nethttp.StatusCreated {
+		t.Fatalf("POST /membership-types status = %v, want %v", code, nethttp.StatusCreated)
+	}
+
+	var field map[string]interface{}
+	body := map[string]interface{}{"name": "shirt_size", "type": "enum", "required": true, "options": []string{"S", "M", "L"}}
+	if code := do(t, s, "POST", "/fields", body, &field); code != nethttp.StatusCreated {
+		t.Fatalf("POST /fields status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	if code := do(t, s, "POST", "/fields", body, nil); code != nethttp.StatusConflict {
+		t.Errorf("POST /fields with an existing name status = %v, want %v", code, nethttp.StatusConflict)
+	}
+	if code := do(t, s, "POST", "/fields", map[string]interface{}{"name": "diet", "type": "list"}, nil); code != nethttp.StatusBadRequest {
+		t.Errorf("POST /fields with an invalid type status = %v, want %v", code, nethttp.StatusBadRequest)
+	}
+	var fields []map[string]interface{}
+	if code := do(t, s, "GET", "/fields", nil, &fields); code != nethttp.StatusOK || len(fields) != 1 {
+		t.Errorf("GET /fields = %v, %v, want one field", code, fields)
+	}
+
+	person := map[string]interface{}{"firstName": "Harry", "lastName": "Potter", "membershipTypeId": mt["id"]}
+	if code := do(t, s, "POST", "/persons", person, nil); code != nethttp.StatusBadRequest {
+		t.Errorf("POST /persons without a required field status = %v, want %v", code, nethttp.StatusBadRequest)
+	}
+	person["customFields"] = map[string]string{"shirt_size": "m"}
+	var created map[string]interface{}
+	if code := do(t, s, "POST", "/persons", person, &created); code != nethttp.StatusCreated {
+		t.Fatalf("POST /persons status = %v, want %v", code, nethttp.StatusCreated)
+	}
+	if got := created["customFields"]; fmt.Sprint(got) != "map[shirt_size:M]" {
+		t.Errorf("POST /persons custom fields = %v, want the normalized shirt size", got)
+	}
+
+	path := fmt.Sprintf("/persons/%v", created["pid"])
+	update := map[string]interface{}{"firstName": "Harry", "lastName": "Potter", "customFields": map[string]string{"shirt_size": "XL"}}
+	if code, _ := doRequest(t, s, "PUT", path, nethttp.Header{"If-Match": {fmt.Sprintf(`"%v"`, created["version"])}}, update, nil); code != nethttp.StatusBadRequest {
+		t.Errorf("PUT %s with an invalid value status = %v, want %v", path, code, nethttp.StatusBadRequest)
+	}
+
+	fieldPath := fmt.Sprintf("/fields/%v", field["id"])
+	if code := do(t, s, "DELETE", fieldPath, nil, nil); code != nethttp.StatusNoContent {
+		t.Errorf("DELETE %s status = %v, want %v", fieldPath, code, nethttp.StatusNoContent)
+	}
+	if code := do(t, s, "DELETE", fieldPath, nil, nil); code != nethttp.StatusNotFound {
+		t.Errorf("DELETE %s of a deleted field status = %v, want %v", fieldPath, code, nethttp.StatusNotFound)
+	}
+}
@@ -0,0 +1,43 @@
+package xone
+
+import "time"
+
+// ImportOptions controls how a list of persons is imported.
+type ImportOptions struct {
+	// DryRun validates all persons and reports the outcome without storing
+	// anything.
+	DryRun bool
+
+	// DefaultMembershipType is the name of the membership type assigned to
+	// persons who do not have a membership of their own.
+	DefaultMembershipType string
+
+	// DefaultEffectiveFrom is used for memberships without a start date.
+	DefaultEffectiveFrom time.Time
+}
+
+// ImportReport describes the outcome of an import. Either all persons have
+// been imported or none at all.
+type ImportReport struct {
+	Rows      []ImportRow
+	Committed bool
+}
+
+// ImportRow is the outcome of importing a single person. Index refers to the
+// position of the person in the imported list.
+type ImportRow struct {
+	Index  int
+	Person Person
+	Errors []error
+}
+
+// Failed reports whether at least one person could not be imported.
+func (r ImportReport) Failed() bool {
+	for _, row := range r.Rows {
+		if len(row.Errors) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,149 @@
+package xone
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InvoiceService issues invoices and receipts. Issued documents cannot be
+// changed or deleted, so that a document can always be reprinted exactly as
+// it has been sent.
+type InvoiceService interface {
+	// FindInvoices returns all documents issued to the person with the given
+	// PID, the most recent first.
+	FindInvoices(context.Context, string) ([]Invoice, error)
+
+	// FindInvoice returns the document with the given number.
+	FindInvoice(context.Context, string) (Invoice, bool, error)
+
+	// CreateInvoice issues a new document with the next free number of its
+	// kind. Numbers are never reused.
+	CreateInvoice(context.Context, CreateInvoiceData) (Invoice, error)
+}
+
+// InvoiceKind distinguishes invoices for membership fees from receipts for
+// donations.
+type InvoiceKind string
+
+const (
+	InvoiceMembershipFees  InvoiceKind = "invoice"
+	InvoiceDonationReceipt InvoiceKind = "receipt"
+)
+
+// Valid reports whether k is one of the known kinds of documents.
+func (k InvoiceKind) Valid() bool {
+	return k == InvoiceMembershipFees || k == InvoiceDonationReceipt
+}
+
+// FormatInvoiceNumber returns the number of the n-th document of a kind which
+// has been issued in the given year, e.g. "INV-2022-0001". Every kind and year
+// has its own sequence.
+func FormatInvoiceNumber(kind InvoiceKind, year, n int) string {
+	prefix := "INV"
+	if kind == InvoiceDonationReceipt {
+		prefix = "REC"
+	}
+
+	return fmt.Sprintf("%s-%d-%04d", prefix, year, n)
+}
+
+// Invoice is an invoice or a receipt which has been issued to a person.
+type Invoice struct {
+	ID     int
+	Number string
+	Kind   InvoiceKind
+
+	// PID is empty if the person has been purged since.
+	PID      string
+	IssuedOn time.Time
+
+	// Recipient and MembershipType are copied from the person when the
+	// document is issued. Later changes of the person do not affect them.
+	Recipient      InvoiceRecipient
+	MembershipType string
+
+	Items     []InvoiceItem
+	CreatedAt time.Time
+}
+
+// Total returns the sum of all items in cents.
+func (i Invoice) Total() int64 {
+	var total int64
+	for _, item := range i.Items {
+		total += item.Amount
+	}
+
+	return total
+}
+
+// InvoiceRecipient is the name and the postal address of the person a
+// document has been issued to.
+type InvoiceRecipient struct {
+	Name        string
+	Street      string
+	HouseNumber string
+	ZipCode     string
+	City        string
+}
+
+// NewInvoiceRecipient returns the name and the address of p.
+func NewInvoiceRecipient(p Person) InvoiceRecipient {
+	return InvoiceRecipient{
+		Name:        strings.TrimSpace(p.FirstName + " " + p.LastName),
+		Street:      p.Street,
+		HouseNumber: p.HouseNumber,
+		ZipCode:     p.ZipCode,
+		City:        p.City,
+	}
+}
+
+// AddressLines returns the recipient's address as it is printed on an
+// envelope, omitting empty lines.
+func (r InvoiceRecipient) AddressLines() []string {
+	var lines []string
+	for _, line := range []string{
+		r.Name,
+		strings.TrimSpace(r.Street + " " + r.HouseNumber),
+		strings.TrimSpace(r.ZipCode + " " + r.City),
+	} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// InvoiceItem is a single line of a document.
+type InvoiceItem struct {
+	Description string
+
+	// Amount is given in cents.
+	Amount int64
+}
+
+// CreateInvoiceData contains the data needed to issue a document.
+type CreateInvoiceData struct {
+	PID  string
+	Kind InvoiceKind
+
+	// IssuedOn defaults to today. It determines the year of the number and
+	// the membership type which is printed on the document.
+	IssuedOn time.Time
+	Items    []InvoiceItem
+}
+
+// NewDuesInvoiceItems turns calculated dues into the items of an invoice.
+func NewDuesInvoiceItems(dues Dues) []InvoiceItem {
+	items := make([]InvoiceItem, 0, len(dues.Items))
+	for _, item := range dues.Items {
+		items = append(items, InvoiceItem{
+			Description: fmt.Sprintf("Membership fee %s, %s to %s", item.MembershipType, item.From.Format(FormatDateOfBirth), item.Until.Format(FormatDateOfBirth)),
+			Amount:      item.Amount,
+		})
+	}
+
+	return items
+}
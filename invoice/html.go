@@ -0,0 +1,20 @@
+package invoice
+
+import (
+	"embed"
+	"html/template"
+	"io"
+
+	"github.com/stillwondering/xone"
+)
+
+//go:embed template/invoice.html
+var templates embed.FS
+
+var htmlTemplate = template.Must(template.ParseFS(templates, "template/invoice.html"))
+
+// WriteHTML writes the document as a standalone HTML page, which can be
+// printed from a browser or sent by email.
+func WriteHTML(w io.Writer, inv xone.Invoice, issuer Issuer) error {
+	return htmlTemplate.Execute(w, newDocument(inv, issuer))
+}
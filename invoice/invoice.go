@@ -0,0 +1,89 @@
+// Package invoice renders the invoices and receipts which are issued to
+// persons, either as HTML pages or as PDF files. Both formats contain the same
+// text, which is laid out by newDocument.
+package invoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stillwondering/xone"
+)
+
+// Issuer is the club which issues the documents. Its name and address are
+// printed in the letterhead, its bank account below the total of an invoice.
+type Issuer struct {
+	Name    string
+	Address string
+	IBAN    string
+	BIC     string
+	Email   string
+}
+
+// document is the text of an invoice or a receipt, independent of the output
+// format.
+type document struct {
+	Issuer    Issuer
+	Title     string
+	Number    string
+	Date      string
+	Recipient []string
+
+	// MembershipType is empty for persons without a membership.
+	MembershipType string
+
+	Items []documentItem
+	Total string
+
+	// Note explains how to pay an invoice or thanks for a donation.
+	Note string
+}
+
+type documentItem struct {
+	Description string
+	Amount      string
+}
+
+func newDocument(inv xone.Invoice, issuer Issuer) document {
+	d := document{
+		Issuer:         issuer,
+		Title:          "Invoice",
+		Number:         inv.Number,
+		Date:           inv.IssuedOn.Format(xone.FormatDateOfBirth),
+		Recipient:      inv.Recipient.AddressLines(),
+		MembershipType: inv.MembershipType,
+		Total:          formatAmount(inv.Total()),
+	}
+
+	for _, item := range inv.Items {
+		d.Items = append(d.Items, documentItem{
+			Description: item.Description,
+			Amount:      formatAmount(item.Amount),
+		})
+	}
+
+	switch inv.Kind {
+	case xone.InvoiceDonationReceipt:
+		d.Title = "Donation receipt"
+		d.Note = fmt.Sprintf("We confirm the receipt of your donation and thank you for supporting %s.", issuer.Name)
+	default:
+		var account []string
+		if issuer.IBAN != "" {
+			account = append(account, "IBAN "+issuer.IBAN)
+		}
+		if issuer.BIC != "" {
+			account = append(account, "BIC "+issuer.BIC)
+		}
+
+		d.Note = fmt.Sprintf("Please transfer the total within 14 days, stating the invoice number %s.", inv.Number)
+		if len(account) > 0 {
+			d.Note = fmt.Sprintf("Please transfer the total within 14 days to %s, stating the invoice number %s.", strings.Join(account, ", "), inv.Number)
+		}
+	}
+
+	return d
+}
+
+func formatAmount(cents int64) string {
+	return xone.FormatAmount(cents) + " EUR"
+}
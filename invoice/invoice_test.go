@@ -0,0 +1,144 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var testIssuer = Issuer{
+	Name:    "Quidditch Club Hogwarts",
+	Address: "Hogwarts Castle, Highlands",
+	IBAN:    "DE02120300000000202051",
+	Email:   "treasurer@hogwarts.co.uk",
+}
+
+var testInvoice = xone.Invoice{
+	Number:   "INV-2022-0001",
+	Kind:     xone.InvoiceMembershipFees,
+	IssuedOn: time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC),
+	Recipient: xone.InvoiceRecipient{
+		Name:        "Harry Potter",
+		Street:      "Privet Drive",
+		HouseNumber: "4",
+		ZipCode:     "RG12",
+		City:        "Little Whinging",
+	},
+	MembershipType: "active",
+	Items: []xone.InvoiceItem{
+		{Description: "Membership fee active, 2022-01-01 to 2022-12-31", Amount: 12000},
+		{Description: "Broom storage <Nimbus 2000>", Amount: 2500},
+	},
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, testInvoice, testIssuer); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+
+	want, err := os.ReadFile("testdata/invoice.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != string(want) {
+		t.Errorf("WriteHTML() = %s, want %s", got, want)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	receipt := testInvoice
+	receipt.Kind = xone.InvoiceDonationReceipt
+	receipt.Number = "REC-2022-0001"
+	receipt.Items = nil
+	for i := 0; i < 60; i++ {
+		receipt.Items = append(receipt.Items, xone.InvoiceItem{Description: fmt.Sprintf("Donation %d", i+1), Amount: 100})
+	}
+
+	tests := []struct {
+		name      string
+		invoice   xone.Invoice
+		wantPages int
+		wantText  []string
+	}{
+		{
+			name:      "Invoice",
+			invoice:   testInvoice,
+			wantPages: 1,
+			wantText:  []string{"(Invoice INV-2022-0001) Tj", "(145.00 EUR) Tj", "(Membership: active) Tj", "IBAN DE02120300000000202051"},
+		},
+		{
+			name:      "Receipt spanning two pages",
+			invoice:   receipt,
+			wantPages: 2,
+			wantText:  []string{"(Donation receipt REC-2022-0001) Tj", "(60.00 EUR) Tj"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WritePDF(&buf, tt.invoice, testIssuer); err != nil {
+				t.Fatalf("WritePDF() error = %v", err)
+			}
+			got := buf.String()
+
+			if !strings.HasPrefix(got, "%PDF-1.4\n") || !strings.HasSuffix(got, "%%EOF\n") {
+				t.Fatalf("WritePDF() = %q, want a PDF file", got)
+			}
+			if want := fmt.Sprintf("/Count %d", tt.wantPages); !strings.Contains(got, want) {
+				t.Errorf("WritePDF() does not contain %q", want)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(got, want) {
+					t.Errorf("WritePDF() does not contain %q", want)
+				}
+			}
+
+			// Every entry of the cross-reference table must point to the
+			// beginning of its object.
+			m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(got)
+			if m == nil {
+				t.Fatal("WritePDF() has no startxref")
+			}
+			xref, _ := strconv.Atoi(m[1])
+			if !strings.HasPrefix(got[xref:], "xref\n") {
+				t.Fatalf("startxref %d does not point to the cross-reference table", xref)
+			}
+			entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(got[xref:], -1)
+			for i, e := range entries {
+				offset, _ := strconv.Atoi(e[1])
+				if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(got[offset:], want) {
+					t.Errorf("offset of object %d = %d, want it to point to %q", i+1, offset, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_escape(t *testing.T) {
+	if got, want := escape(`Müller (Jr.) \ 5 € ✓`), "M\xfcller \\(Jr.\\) \\\\ 5 \x80 ?"; got != want {
+		t.Errorf("escape() = %q, want %q", got, want)
+	}
+}
+
+func Test_wrap(t *testing.T) {
+	got := wrap("Please transfer the total within 14 days", 10, 100)
+	for _, line := range got {
+		if w := textWidth(line, 10); w > 100 {
+			t.Errorf("wrap() line %q is %v points wide, want at most 100", line, w)
+		}
+	}
+	if strings.Join(got, " ") != "Please transfer the total within 14 days" {
+		t.Errorf("wrap() = %q, want all words", got)
+	}
+	if len(got) < 2 {
+		t.Errorf("wrap() = %q, want several lines", got)
+	}
+}
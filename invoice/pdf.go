@@ -0,0 +1,242 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/stillwondering/xone"
+)
+
+// The layout of an A4 page in points.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	marginLeft   = 57
+	marginRight  = pageWidth - 57
+	marginTop    = pageHeight - 57
+	marginBottom = 70
+)
+
+// WritePDF writes the document as a PDF file. The file only uses the standard
+// fonts Helvetica and Helvetica-Bold, which every PDF viewer provides, so no
+// fonts need to be embedded.
+func WritePDF(w io.Writer, inv xone.Invoice, issuer Issuer) error {
+	d := newDocument(inv, issuer)
+
+	p := &pdf{}
+	p.newPage()
+
+	p.text(marginLeft, fontBold, 12, d.Issuer.Name)
+	var header []string
+	for _, s := range []string{d.Issuer.Address, d.Issuer.Email} {
+		if s != "" {
+			header = append(header, s)
+		}
+	}
+	if len(header) > 0 {
+		p.advance(14)
+		p.text(marginLeft, fontRegular, 9, strings.Join(header, " - "))
+	}
+	p.advance(8)
+	p.rule()
+
+	p.advance(50)
+	for _, line := range d.Recipient {
+		p.text(marginLeft, fontRegular, 10, line)
+		p.advance(14)
+	}
+
+	p.advance(50)
+	p.text(marginLeft, fontBold, 16, d.Title+" "+d.Number)
+	p.advance(24)
+	p.text(marginLeft, fontRegular, 10, "Date: "+d.Date)
+	if d.MembershipType != "" {
+		p.advance(14)
+		p.text(marginLeft, fontRegular, 10, "Membership: "+d.MembershipType)
+	}
+
+	p.advance(34)
+	p.text(marginLeft, fontBold, 10, "Description")
+	p.textRight(marginRight, fontBold, 10, "Amount")
+	p.advance(6)
+	p.rule()
+	for _, item := range d.Items {
+		p.advance(16)
+		p.textRight(marginRight, fontRegular, 10, item.Amount)
+		for i, line := range wrap(item.Description, 10, marginRight-marginLeft-100) {
+			if i > 0 {
+				p.advance(12)
+			}
+			p.text(marginLeft, fontRegular, 10, line)
+		}
+	}
+	p.advance(8)
+	p.rule()
+	p.advance(16)
+	p.text(marginLeft, fontBold, 10, "Total")
+	p.textRight(marginRight, fontBold, 10, d.Total)
+
+	p.advance(34)
+	for _, line := range wrap(d.Note, 10, marginRight-marginLeft) {
+		p.text(marginLeft, fontRegular, 10, line)
+		p.advance(14)
+	}
+
+	return p.writeTo(w)
+}
+
+type font string
+
+const (
+	fontRegular font = "F1"
+	fontBold    font = "F2"
+)
+
+// pdf lays out lines of text from the top to the bottom of a page, starting a
+// new page when the bottom margin is reached.
+type pdf struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (p *pdf) newPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.y = marginTop
+}
+
+// advance moves down by dy points.
+func (p *pdf) advance(dy float64) {
+	p.y -= dy
+	if p.y < marginBottom {
+		p.newPage()
+	}
+}
+
+func (p *pdf) text(x float64, f font, size float64, s string) {
+	x = math.Round(x*100) / 100
+	fmt.Fprintf(p.pages[len(p.pages)-1], "BT /%s %g Tf %g %g Td (%s) Tj ET\n", f, size, x, p.y, escape(s))
+}
+
+// textRight draws s so that it ends at x.
+func (p *pdf) textRight(x float64, f font, size float64, s string) {
+	p.text(x-textWidth(s, size), f, size, s)
+}
+
+// rule draws a horizontal line across the page.
+func (p *pdf) rule() {
+	fmt.Fprintf(p.pages[len(p.pages)-1], "0.5 w %d %g m %d %g l S\n", marginLeft, p.y, marginRight, p.y)
+}
+
+// writeTo writes the file. The object numbers are fixed: the catalog, the page
+// tree and the two fonts come first, followed by a page and its content
+// stream for every page.
+func (p *pdf) writeTo(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(format string, args ...interface{}) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&buf, format, args...)
+		buf.WriteString("\nendobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	var kids []string
+	for i := range p.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range p.pages {
+		obj("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i)
+		obj("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.Bytes())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := buf.WriteTo(w)
+
+	return err
+}
+
+// escape encodes s in WinAnsiEncoding and escapes it for a PDF string.
+// Characters which cannot be encoded are replaced by a question mark.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '€':
+			b.WriteByte(0x80)
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	return b.String()
+}
+
+// helveticaWidths are the widths of the printable ASCII characters in
+// Helvetica, in thousandths of the font size.
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth returns the width of s in points. The widths of Helvetica-Bold
+// are close enough for the digits and capital letters which are aligned to
+// the right.
+func textWidth(s string, size float64) float64 {
+	var width int
+	for _, r := range s {
+		if r >= 0x20 && r < 0x7f {
+			width += helveticaWidths[r-0x20]
+		} else {
+			width += 556
+		}
+	}
+
+	return float64(width) * size / 1000
+}
+
+// wrap breaks s into lines which are at most maxWidth points wide.
+func wrap(s string, size, maxWidth float64) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		if line != "" && textWidth(line+" "+word, size) > maxWidth {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -0,0 +1,41 @@
+package xone
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	if got, want := FormatInvoiceNumber(InvoiceMembershipFees, 2022, 7), "INV-2022-0007"; got != want {
+		t.Errorf("FormatInvoiceNumber() = %v, want %v", got, want)
+	}
+	if got, want := FormatInvoiceNumber(InvoiceDonationReceipt, 2022, 12345), "REC-2022-12345"; got != want {
+		t.Errorf("FormatInvoiceNumber() = %v, want %v", got, want)
+	}
+}
+
+func TestInvoiceRecipient_AddressLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		recipient InvoiceRecipient
+		want      []string
+	}{
+		{
+			name:      "Full address",
+			recipient: InvoiceRecipient{Name: "Harry Potter", Street: "Privet Drive", HouseNumber: "4", ZipCode: "RG12", City: "Little Whinging"},
+			want:      []string{"Harry Potter", "Privet Drive 4", "RG12 Little Whinging"},
+		},
+		{
+			name:      "Without street",
+			recipient: InvoiceRecipient{Name: "Harry Potter", City: "Little Whinging"},
+			want:      []string{"Harry Potter", "Little Whinging"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.recipient.AddressLines(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AddressLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
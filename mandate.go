@@ -0,0 +1,159 @@
+package xone
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MandateService stores the SEPA direct debit mandates which persons have
+// signed. Every person has at most one mandate.
+type MandateService interface {
+	FindMandate(context.Context, string) (Mandate, bool, error)
+
+	// SaveMandate creates the mandate of the person with the given PID or
+	// replaces the existing one.
+	SaveMandate(context.Context, string, SaveMandateData) (Mandate, error)
+
+	DeleteMandate(context.Context, string) error
+
+	// FindDirectDebits returns what can be collected from persons with a
+	// mandate on the given collection date, i.e. their outstanding balance.
+	// Dues are only part of the balance once they have been booked as
+	// charges, see PaymentService.ChargeDues.
+	FindDirectDebits(context.Context, time.Time) ([]DirectDebit, error)
+
+	// BookDirectDebits records the direct debits as payments dated on the
+	// given collection date, so they are not collected twice.
+	BookDirectDebits(context.Context, time.Time, []DirectDebit) error
+}
+
+// Mandate authorizes the club to collect money from a bank account by SEPA
+// direct debit.
+type Mandate struct {
+	ID  int
+	PID string
+
+	// AccountHolder is the name of the debtor if it differs from the name of
+	// the person, e.g. a parent paying for their child.
+	AccountHolder string
+	IBAN          string
+	BIC           string
+
+	// Reference is the unique mandate reference which is communicated to the
+	// debtor.
+	Reference string
+	SignedOn  time.Time
+
+	// LastCollectedOn is the date of the most recent direct debit. It is zero
+	// until the mandate has been used for the first time.
+	LastCollectedOn time.Time
+}
+
+// SequenceType returns the SEPA sequence type of the next direct debit: "FRST"
+// for the first collection of a mandate and "RCUR" for all following ones.
+func (m Mandate) SequenceType() string {
+	if m.LastCollectedOn.IsZero() {
+		return "FRST"
+	}
+
+	return "RCUR"
+}
+
+// SaveMandateData contains the data needed to create or replace a mandate.
+type SaveMandateData struct {
+	AccountHolder string
+	IBAN          string
+	BIC           string
+	Reference     string
+	SignedOn      time.Time
+}
+
+// DirectDebit is an amount to be collected from a person by direct debit.
+type DirectDebit struct {
+	Person  Person
+	Mandate Mandate
+
+	// Amount is given in cents.
+	Amount int64
+
+	// EndToEndID identifies the direct debit throughout the whole process and
+	// is used as the reference of the booked payment.
+	EndToEndID string
+}
+
+// DebtorName returns the name of the account holder.
+func (d DirectDebit) DebtorName() string {
+	if d.Mandate.AccountHolder != "" {
+		return d.Mandate.AccountHolder
+	}
+
+	return strings.TrimSpace(d.Person.FirstName + " " + d.Person.LastName)
+}
+
+// ibanLengths are the lengths of the IBANs of all countries participating in
+// SEPA.
+var ibanLengths = map[string]int{
+	"AD": 24, "AT": 20, "BE": 16, "BG": 22, "CH": 21, "CY": 28, "CZ": 24,
+	"DE": 22, "DK": 18, "EE": 20, "ES": 24, "FI": 18, "FR": 27, "GB": 22,
+	"GI": 23, "GR": 27, "HR": 21, "HU": 28, "IE": 22, "IS": 26, "IT": 27,
+	"LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MT": 31, "NL": 18,
+	"NO": 15, "PL": 28, "PT": 25, "RO": 24, "SE": 24, "SI": 19, "SK": 24,
+	"SM": 27, "VA": 22,
+}
+
+// NormalizeIBAN removes all spaces from an IBAN and converts it to upper
+// case.
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// ValidIBAN reports whether iban is a valid IBAN of a SEPA country. The IBAN
+// must be normalized, see NormalizeIBAN.
+func ValidIBAN(iban string) bool {
+	if len(iban) < 4 {
+		return false
+	}
+	if n, ok := ibanLengths[iban[:2]]; !ok || len(iban) != n {
+		return false
+	}
+
+	return Mod97(iban[4:]+iban[:4]) == 1
+}
+
+var (
+	bicPattern              = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	mandateReferencePattern = regexp.MustCompile(`^[A-Za-z0-9+?/:().,' -]{1,35}$`)
+)
+
+// ValidBIC reports whether bic is formally a valid BIC with 8 or 11
+// characters.
+func ValidBIC(bic string) bool {
+	return bicPattern.MatchString(bic)
+}
+
+// ValidMandateReference reports whether ref consists of at most 35 characters
+// of the restricted SEPA character set.
+func ValidMandateReference(ref string) bool {
+	return mandateReferencePattern.MatchString(ref)
+}
+
+// Mod97 calculates the ISO 7064 MOD 97-10 remainder of s, where letters count
+// as two-digit numbers starting with A = 10. It returns -1 if s contains
+// anything but digits and upper case letters.
+func Mod97(s string) int {
+	remainder := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		default:
+			return -1
+		}
+	}
+
+	return remainder
+}
@@ -0,0 +1,49 @@
+package xone
+
+import "testing"
+
+func TestValidIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		want bool
+	}{
+		{iban: "DE89370400440532013000", want: true},
+		{iban: "GB29NWBK60161331926819", want: true},
+		{iban: "DE89370400440532013001", want: false},
+		{iban: "DE8937040044053201300", want: false},
+		{iban: "US89370400440532013000", want: false},
+		{iban: "de89370400440532013000", want: false},
+		{iban: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.iban, func(t *testing.T) {
+			if got := ValidIBAN(tt.iban); got != tt.want {
+				t.Errorf("ValidIBAN() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := NormalizeIBAN(" de89 3704 0044 0532 0130 00 "); got != "DE89370400440532013000" {
+		t.Errorf("NormalizeIBAN() = %v, want %v", got, "DE89370400440532013000")
+	}
+}
+
+func TestValidBIC(t *testing.T) {
+	tests := []struct {
+		bic  string
+		want bool
+	}{
+		{bic: "COBADEFF", want: true},
+		{bic: "COBADEFFXXX", want: true},
+		{bic: "COBADEF", want: false},
+		{bic: "COBADEFFXX", want: false},
+		{bic: "cobadeff", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.bic, func(t *testing.T) {
+			if got := ValidBIC(tt.bic); got != tt.want {
+				t.Errorf("ValidBIC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,11 +6,63 @@ type Membership struct {
 	ID            int
 	Type          MembershipType
 	EffectiveFrom time.Time
+
+	// EndDate is the last day of a terminated membership. It is zero as long
+	// as the membership has not been terminated.
+	EndDate           time.Time
+	TerminationReason TerminationReason
+
+	// Version is incremented whenever the membership is changed, see
+	// UpdateMembershipData.
+	Version int
 }
 
 type MembershipType struct {
 	ID   int
 	Name string
+
+	// Fees are only loaded by MembershipService.FindAllMembershipTypes and
+	// only for users who are allowed to read them.
+	Fees []Fee
+}
+
+// TerminationReason is the reason why a membership has been terminated.
+type TerminationReason string
+
+const (
+	TerminationResignation TerminationReason = "resignation"
+	TerminationDeath       TerminationReason = "death"
+	TerminationExclusion   TerminationReason = "exclusion"
+	TerminationOther       TerminationReason = "other"
+)
+
+// Valid reports whether r is one of the known termination reasons.
+func (r TerminationReason) Valid() bool {
+	switch r {
+	case TerminationResignation, TerminationDeath, TerminationExclusion, TerminationOther:
+		return true
+	}
+
+	return false
+}
+
+// Terminated reports whether the membership has been terminated, regardless
+// of whether the end date has already passed.
+func (m Membership) Terminated() bool {
+	return !m.EndDate.IsZero()
+}
+
+// Ended reports whether the membership has ended with respect to the given
+// date, i.e. the end date lies before it.
+func (m Membership) Ended(today time.Time) bool {
+	if !m.Terminated() {
+		return false
+	}
+
+	ty, tm, td := today.Date()
+	ey, em, ed := m.EndDate.Date()
+
+	return time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC).After(time.Date(ey, em, ed, 0, 0, 0, 0, time.UTC))
 }
 
 type CreateMembershipData struct {
@@ -20,12 +72,24 @@ type CreateMembershipData struct {
 }
 
 type UpdateMembershipData struct {
+	// Version is the version of the membership the changes are based on. The
+	// update fails with an *ErrStaleVersion if the membership has been changed
+	// in the meantime.
+	Version int
+
 	MembershipTypeID int
 	EffectiveFrom    time.Time
 }
 
+// TerminateMembershipData contains the data needed to terminate a membership.
+type TerminateMembershipData struct {
+	EndDate time.Time
+	Reason  TerminationReason
+}
+
 func (m Membership) ToUpdateData() UpdateMembershipData {
 	return UpdateMembershipData{
+		Version:          m.Version,
 		MembershipTypeID: m.Type.ID,
 		EffectiveFrom:    m.EffectiveFrom,
 	}
@@ -0,0 +1,124 @@
+package xone
+
+import (
+	"context"
+	"time"
+)
+
+// PaymentService keeps a ledger of the charges and payments of every person.
+// Ledger entries cannot be changed or deleted; mistakes are corrected by
+// recording an entry which offsets the wrong one.
+type PaymentService interface {
+	// FindLedgerEntries returns all entries of the person with the given PID,
+	// ordered by date.
+	FindLedgerEntries(context.Context, string) ([]LedgerEntry, error)
+
+	CreateLedgerEntry(context.Context, CreateLedgerEntryData) (LedgerEntry, error)
+
+	// Balance returns what the person with the given PID owes. A negative
+	// balance is a credit.
+	Balance(context.Context, string) (int64, error)
+
+	// FindArrears returns all persons who owe money as of the given date,
+	// the highest balance first. Entries dated later are not taken into
+	// account.
+	FindArrears(context.Context, time.Time) ([]Arrear, error)
+
+	// ChargeDues books the dues of every person for the given range of dates,
+	// both inclusive, as charges dated on its last day and returns them.
+	// Days a person has already been charged for, e.g. by an earlier run for
+	// an overlapping range, are left out, so it is safe to run it again. A
+	// person gets a charge for every gap between those days.
+	ChargeDues(ctx context.Context, from, until time.Time) ([]LedgerEntry, error)
+}
+
+// LedgerEntryKind distinguishes charges from payments.
+type LedgerEntryKind string
+
+const (
+	LedgerCharge  LedgerEntryKind = "charge"
+	LedgerPayment LedgerEntryKind = "payment"
+)
+
+// Valid reports whether k is one of the known kinds of ledger entries.
+func (k LedgerEntryKind) Valid() bool {
+	return k == LedgerCharge || k == LedgerPayment
+}
+
+// PaymentMethod is the way a payment has been made.
+type PaymentMethod string
+
+const (
+	PaymentCash         PaymentMethod = "cash"
+	PaymentBankTransfer PaymentMethod = "bank_transfer"
+	PaymentDirectDebit  PaymentMethod = "direct_debit"
+	PaymentCard         PaymentMethod = "card"
+)
+
+// Valid reports whether m is one of the known payment methods.
+func (m PaymentMethod) Valid() bool {
+	switch m {
+	case PaymentCash, PaymentBankTransfer, PaymentDirectDebit, PaymentCard:
+		return true
+	}
+
+	return false
+}
+
+// LedgerEntry is a single charge or payment.
+type LedgerEntry struct {
+	ID  int
+	PID string
+
+	Kind LedgerEntryKind
+	Date time.Time
+
+	// Amount is given in cents and is always positive.
+	Amount      int64
+	Description string
+
+	// Method and Reference are only set for payments. The reference is e.g.
+	// the purpose of a bank transfer or a receipt number.
+	Method    PaymentMethod
+	Reference string
+
+	CreatedAt time.Time
+}
+
+// Signed returns the amount by which the entry changes the balance: charges
+// increase it, payments decrease it.
+func (e LedgerEntry) Signed() int64 {
+	if e.Kind == LedgerPayment {
+		return -e.Amount
+	}
+
+	return e.Amount
+}
+
+// CreateLedgerEntryData contains the data needed to record a charge or a
+// payment.
+type CreateLedgerEntryData struct {
+	PID         string
+	Kind        LedgerEntryKind
+	Date        time.Time
+	Amount      int64
+	Description string
+	Method      PaymentMethod
+	Reference   string
+}
+
+// Arrear is a person who owes money.
+type Arrear struct {
+	Person  Person
+	Balance int64
+}
+
+// Balance sums up the given ledger entries.
+func Balance(entries []LedgerEntry) int64 {
+	var balance int64
+	for _, e := range entries {
+		balance += e.Signed()
+	}
+
+	return balance
+}
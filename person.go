@@ -19,6 +19,24 @@ type Person struct {
 	ZipCode     string
 	City        string
 	Memberships []Membership
+
+	// CustomFields maps the names of custom fields to the person's values in
+	// their canonical form, see FieldDefinition.Normalize. Fields without a
+	// value are left out.
+	CustomFields map[string]string
+
+	// DeletedAt is set if the person has been deleted. Deleted persons are
+	// archived until they are purged.
+	DeletedAt time.Time
+
+	// Version is incremented whenever the person is changed. Updates have to
+	// name the version they are based on, see UpdatePersonData.
+	Version int
+}
+
+// Archived reports whether the person has been deleted.
+func (p Person) Archived() bool {
+	return !p.DeletedAt.IsZero()
 }
 
 func (p Person) CurrentAge() int {
@@ -60,29 +78,41 @@ func (p Person) CurrentMembership() *Membership {
 	return p.Membership(time.Now())
 }
 
+// Membership returns the membership which is in effect on the given date, i.e.
+// the one which became effective most recently on or before that date.
+// Memberships without an effective date are considered to be the oldest ones,
+// memberships becoming effective on the same date are ordered by their IDs.
+// It returns nil if there is no such membership or if it has ended. This is
+// the same definition the repositories use to filter by the current
+// membership, see PersonFilter.
 func (p Person) Membership(today time.Time) *Membership {
-	if len(p.Memberships) == 0 {
-		return nil
-	}
+	ty, tm, td := today.Date()
+	day := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
 
-	m := p.Memberships[0]
+	var current *Membership
+	for i := range p.Memberships {
+		m := &p.Memberships[i]
 
-	for i := 1; i < len(p.Memberships); i++ {
-		if p.Memberships[i].EffectiveFrom.IsZero() {
-			m = p.Memberships[i]
+		ey, em, ed := m.EffectiveFrom.Date()
+		effectiveFrom := time.Date(ey, em, ed, 0, 0, 0, 0, time.UTC)
+		if !m.EffectiveFrom.IsZero() && effectiveFrom.After(day) {
 			continue
 		}
 
-		if p.Memberships[i].EffectiveFrom.After(today) {
-			continue
+		if current == nil ||
+			m.EffectiveFrom.After(current.EffectiveFrom) ||
+			(m.EffectiveFrom.Equal(current.EffectiveFrom) && m.ID > current.ID) {
+			current = m
 		}
+	}
 
-		if p.Memberships[i].EffectiveFrom.After(m.EffectiveFrom) {
-			m = p.Memberships[i]
-		}
+	if current == nil || current.Ended(today) {
+		return nil
 	}
 
-	return &m
+	c := *current
+
+	return &c
 }
 
 // CreatePersonData contains all data which is necessary to create a new Person entry
@@ -100,10 +130,16 @@ type CreatePersonData struct {
 	City             string
 	MembershipTypeID int
 	EffectiveFrom    time.Time
+	CustomFields     map[string]string
 }
 
 // UpdatePersonData contains a person's data points which can be updated.
 type UpdatePersonData struct {
+	// Version is the version of the person the changes are based on. The
+	// update fails with an *ErrStaleVersion if the person has been changed in
+	// the meantime.
+	Version int
+
 	FirstName   string
 	LastName    string
 	DateOfBirth time.Time
@@ -114,21 +150,74 @@ type UpdatePersonData struct {
 	HouseNumber string
 	ZipCode     string
 	City        string
+
+	// CustomFields contains the values of the custom fields which are changed.
+	// An empty value removes the value, fields which are not contained are
+	// left unchanged.
+	CustomFields map[string]string
+}
+
+// PatchPersonData contains the changes of a partial update of a person. Only
+// the fields which are not nil are written, all others are left unchanged. An
+// empty string or a zero date clears a field.
+type PatchPersonData struct {
+	// Version is the version of the person the changes are based on, see
+	// UpdatePersonData. If it is zero, the changes are applied to whatever
+	// version is current.
+	Version int
+
+	FirstName   *string
+	LastName    *string
+	DateOfBirth *time.Time
+	Email       *string
+	Phone       *string
+	Mobile      *string
+	Street      *string
+	HouseNumber *string
+	ZipCode     *string
+	City        *string
+
+	// CustomFields contains the values of the custom fields which are changed,
+	// see UpdatePersonData.
+	CustomFields map[string]string
+}
+
+// Empty reports whether the patch does not change anything.
+func (d PatchPersonData) Empty() bool {
+	return d.FirstName == nil && d.LastName == nil && d.DateOfBirth == nil &&
+		d.Email == nil && d.Phone == nil && d.Mobile == nil &&
+		d.Street == nil && d.HouseNumber == nil && d.ZipCode == nil && d.City == nil &&
+		len(d.CustomFields) == 0
 }
 
 // ToUpdateData returns a struct that can be used as a starting point when
 // updating a person's data.
 func (p Person) ToUpdateData() UpdatePersonData {
 	return UpdatePersonData{
-		FirstName:   p.FirstName,
-		LastName:    p.LastName,
-		DateOfBirth: p.DateOfBirth,
-		Email:       p.Email,
-		Phone:       p.Phone,
-		Mobile:      p.Mobile,
-		Street:      p.Street,
-		HouseNumber: p.HouseNumber,
-		ZipCode:     p.ZipCode,
-		City:        p.City,
+		Version:      p.Version,
+		FirstName:    p.FirstName,
+		LastName:     p.LastName,
+		DateOfBirth:  p.DateOfBirth,
+		Email:        p.Email,
+		Phone:        p.Phone,
+		Mobile:       p.Mobile,
+		Street:       p.Street,
+		HouseNumber:  p.HouseNumber,
+		ZipCode:      p.ZipCode,
+		City:         p.City,
+		CustomFields: copyFieldValues(p.CustomFields),
 	}
 }
+
+func copyFieldValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+
+	c := make(map[string]string, len(values))
+	for name, value := range values {
+		c[name] = value
+	}
+
+	return c
+}
@@ -129,6 +129,86 @@ func TestPerson_Membership(t *testing.T) {
 				EffectiveFrom: time.Time{},
 			},
 		},
+		{
+			name: "Only membership in the future",
+			fields: fields{
+				Memberships: []Membership{
+					{ID: 1, Type: MembershipType{ID: 1, Name: "active"}, EffectiveFrom: time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)},
+				},
+			},
+			args: args{
+				today: time.Date(2022, time.February, 23, 12, 0, 0, 0, time.UTC),
+			},
+			want: nil,
+		},
+		{
+			name: "Membership without effective from date after a dated one",
+			fields: fields{
+				Memberships: []Membership{
+					{ID: 1, Type: MembershipType{ID: 1, Name: "active"}, EffectiveFrom: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+					{ID: 2, Type: MembershipType{ID: 2, Name: "passive"}, EffectiveFrom: time.Time{}},
+				},
+			},
+			args: args{
+				today: time.Date(2022, time.February, 23, 12, 0, 0, 0, time.UTC),
+			},
+			want: &Membership{ID: 1, Type: MembershipType{ID: 1, Name: "active"}, EffectiveFrom: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "Two memberships effective on the same date",
+			fields: fields{
+				Memberships: []Membership{
+					{ID: 1, Type: MembershipType{ID: 1, Name: "active"}, EffectiveFrom: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+					{ID: 2, Type: MembershipType{ID: 2, Name: "passive"}, EffectiveFrom: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+				},
+			},
+			args: args{
+				today: time.Date(2022, time.February, 23, 12, 0, 0, 0, time.UTC),
+			},
+			want: &Membership{ID: 2, Type: MembershipType{ID: 2, Name: "passive"}, EffectiveFrom: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "Membership ends today",
+			fields: fields{
+				Memberships: []Membership{
+					{
+						ID:                1,
+						Type:              MembershipType{ID: 1, Name: "active"},
+						EffectiveFrom:     time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+						EndDate:           time.Date(2022, time.February, 23, 0, 0, 0, 0, time.UTC),
+						TerminationReason: TerminationResignation,
+					},
+				},
+			},
+			args: args{
+				today: time.Date(2022, time.February, 23, 23, 59, 0, 0, time.UTC),
+			},
+			want: &Membership{
+				ID:                1,
+				Type:              MembershipType{ID: 1, Name: "active"},
+				EffectiveFrom:     time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:           time.Date(2022, time.February, 23, 0, 0, 0, 0, time.UTC),
+				TerminationReason: TerminationResignation,
+			},
+		},
+		{
+			name: "Membership has ended",
+			fields: fields{
+				Memberships: []Membership{
+					{
+						ID:                1,
+						Type:              MembershipType{ID: 1, Name: "active"},
+						EffectiveFrom:     time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+						EndDate:           time.Date(2022, time.February, 22, 0, 0, 0, 0, time.UTC),
+						TerminationReason: TerminationResignation,
+					},
+				},
+			},
+			args: args{
+				today: time.Date(2022, time.February, 23, 0, 0, 0, 0, time.UTC),
+			},
+			want: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
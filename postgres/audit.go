@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+// formatTimestamp is the format used to store points in time. All timestamps
+// are stored in UTC so they can be compared as strings.
+const formatTimestamp = time.RFC3339
+
+// auditUser is the representation of a user in the audit log. It leaves out
+// the password hash.
+type auditUser struct {
+	ID    int
+	Email string
+	Role  xone.Role
+}
+
+// createAuditEntry records a change of an entity at the given time in the same
+// way as the sqlite package does. The actor and the reason are taken from the
+// context.
+func createAuditEntry(ctx context.Context, tx dbtx, now time.Time, op xone.AuditOperation, entity xone.AuditEntity, id string, before, after interface{}) error {
+	beforeJSON, err := marshalAuditValue(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalAuditValue(after)
+	if err != nil {
+		return err
+	}
+
+	var actorID sql.NullInt64
+	var actorEmail string
+	if user, ok := xone.UserFromContext(ctx); ok {
+		actorID = sql.NullInt64{Int64: int64(user.ID), Valid: true}
+		actorEmail = user.Email
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO audit_log (
+			created_at,
+			actor_id,
+			actor_email,
+			operation,
+			entity,
+			entity_id,
+			before,
+			after,
+			reason
+		) VALUES (
+			$1,
+			$2,
+			$3,
+			$4,
+			$5,
+			$6,
+			$7,
+			$8,
+			$9
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(
+		ctx,
+		now.UTC().Format(formatTimestamp),
+		actorID,
+		actorEmail,
+		op,
+		entity,
+		id,
+		beforeJSON,
+		afterJSON,
+		xone.ReasonFromContext(ctx),
+	)
+
+	return err
+}
+
+func marshalAuditValue(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
@@ -0,0 +1,52 @@
+//go:build embeddedpostgres
+
+package postgres_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// startEmbeddedPostgres starts a PostgreSQL server on a free port which lives
+// until the returned function is called. The binaries are downloaded on the
+// first run and cached in ~/.embedded-postgres-go.
+func startEmbeddedPostgres() (string, func() error, error) {
+	dir, err := os.MkdirTemp("", "xone-postgres-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The port is only reserved while the listener is open, so there is a
+	// small chance that another process takes it in the meantime.
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	port := uint32(l.Addr().(*net.TCPAddr).Port)
+	l.Close()
+
+	config := embeddedpostgres.DefaultConfig().
+		Port(port).
+		Database("xone_test").
+		RuntimePath(filepath.Join(dir, "runtime")).
+		DataPath(filepath.Join(dir, "data")).
+		Logger(io.Discard)
+	db := embeddedpostgres.NewDatabase(config)
+	if err := db.Start(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("start embedded postgres: %w", err)
+	}
+
+	stop := func() error {
+		defer os.RemoveAll(dir)
+		return db.Stop()
+	}
+
+	return config.GetConnectionURL() + "?sslmode=disable", stop, nil
+}
@@ -0,0 +1,465 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.MembershipService = (*MembershipService)(nil)
+
+type MembershipService struct {
+	db  *sql.DB
+	Now func() time.Time
+}
+
+func NewMembershipService(db *sql.DB) *MembershipService {
+	service := MembershipService{
+		db:  db,
+		Now: time.Now,
+	}
+
+	return &service
+}
+
+// FindAllMembershipTypes returns all membership types. Fees are not supported
+// by this package, so they are never loaded.
+func (s *MembershipService) FindAllMembershipTypes(ctx context.Context) ([]xone.MembershipType, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	membershipTypes, err := findAllMembershipTypes(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return membershipTypes, tx.Commit()
+}
+
+func (s *MembershipService) CreateMembershipType(ctx context.Context, name string) (xone.MembershipType, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.MembershipType{}, err
+	}
+	defer tx.Rollback()
+
+	if _, found, err := findMembershipTypeByName(ctx, tx, name); err != nil {
+		return xone.MembershipType{}, err
+	} else if found {
+		return xone.MembershipType{}, &xone.ErrMembershipTypeExists{Name: name}
+	}
+
+	membershipType, err := createMembershipType(ctx, tx, name)
+	if err != nil {
+		return xone.MembershipType{}, err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditCreate, xone.AuditMembershipType, strconv.Itoa(membershipType.ID), nil, membershipType); err != nil {
+		return xone.MembershipType{}, err
+	}
+
+	return membershipType, tx.Commit()
+}
+
+// FindMembership returns the membership with the given ID.
+func (s *MembershipService) FindMembership(ctx context.Context, id int) (xone.Membership, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Membership{}, false, err
+	}
+	defer tx.Rollback()
+
+	membership, found, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return xone.Membership{}, false, err
+	}
+
+	return membership, found, tx.Commit()
+}
+
+func (s *MembershipService) UpdateMembership(ctx context.Context, id int, data xone.UpdateMembershipData) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "membership", ID: strconv.Itoa(id)}
+	}
+
+	if err := updateMembership(ctx, tx, id, data); err != nil {
+		return err
+	}
+
+	after, _, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditUpdate, xone.AuditMembership, strconv.Itoa(id), before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TerminateMembership ends a membership on the given end date. The end date
+// must not lie before the date the membership became effective.
+func (s *MembershipService) TerminateMembership(ctx context.Context, id int, data xone.TerminateMembershipData) error {
+	if !data.Reason.Valid() {
+		return &xone.ErrInvalidTerminationReason{Reason: data.Reason}
+	}
+	if data.EndDate.IsZero() {
+		return &xone.ErrInvalid{Msg: "end date required"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "membership", ID: strconv.Itoa(id)}
+	}
+
+	if data.EndDate.Before(before.EffectiveFrom) {
+		return &xone.ErrInvalid{Msg: fmt.Sprintf("end date %s lies before the membership became effective", data.EndDate.Format(formatDate))}
+	}
+
+	if err := setMembershipEnd(ctx, tx, id, data.EndDate.Format(formatDate), data.Reason); err != nil {
+		return err
+	}
+
+	after, _, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditUpdate, xone.AuditMembership, strconv.Itoa(id), before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReinstateMembership revokes the termination of a membership.
+func (s *MembershipService) ReinstateMembership(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "membership", ID: strconv.Itoa(id)}
+	}
+
+	if err := setMembershipEnd(ctx, tx, id, "", ""); err != nil {
+		return err
+	}
+
+	after, _, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditUpdate, xone.AuditMembership, strconv.Itoa(id), before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func findAllMembershipTypes(ctx context.Context, db dbtx) ([]xone.MembershipType, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			id,
+			name
+		FROM
+			membership_type
+		ORDER BY
+			id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var membershipTypes []xone.MembershipType
+	for rows.Next() {
+		var mt xone.MembershipType
+
+		if err := rows.Scan(&mt.ID, &mt.Name); err != nil {
+			return nil, err
+		}
+
+		membershipTypes = append(membershipTypes, mt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return membershipTypes, nil
+}
+
+func findMembershipTypeByName(ctx context.Context, db dbtx, name string) (xone.MembershipType, bool, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		SELECT
+			id,
+			name
+		FROM
+			membership_type
+		WHERE
+			name = $1
+	`)
+	if err != nil {
+		return xone.MembershipType{}, false, err
+	}
+
+	mt := xone.MembershipType{}
+	row := stmt.QueryRowContext(ctx, name)
+	if err := row.Scan(&mt.ID, &mt.Name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return xone.MembershipType{}, false, nil
+		}
+
+		return xone.MembershipType{}, false, err
+	}
+
+	return mt, true, nil
+}
+
+func createMembershipType(ctx context.Context, db dbtx, name string) (xone.MembershipType, error) {
+	stmt, err := db.PrepareContext(ctx, `INSERT INTO membership_type (name) VALUES ($1) RETURNING id`)
+	if err != nil {
+		return xone.MembershipType{}, err
+	}
+
+	var id int
+	if err := stmt.QueryRowContext(ctx, name).Scan(&id); err != nil {
+		return xone.MembershipType{}, mapError(err)
+	}
+
+	return xone.MembershipType{
+		ID:   id,
+		Name: name,
+	}, nil
+}
+
+// findMembershipsByPersons returns the memberships of all persons with the
+// given IDs, keyed by person ID. Persons without memberships are not contained
+// in the result. Unlike SQLite, PostgreSQL takes the IDs as a single array, so
+// they need not be split into batches.
+func findMembershipsByPersons(ctx context.Context, db dbtx, personIDs []int) (map[int][]xone.Membership, error) {
+	ids := make([]int64, len(personIDs))
+	for i, id := range personIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			membership.person_id,
+			membership.id,
+			membership.effective_from,
+			membership_type.id,
+			membership_type.name,
+			membership.end_date,
+			membership.termination_reason,
+			membership.version
+		FROM
+			membership
+			JOIN membership_type ON membership.type_id = membership_type.id
+		WHERE
+			membership.person_id = ANY($1)
+		ORDER BY
+			membership.id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := map[int][]xone.Membership{}
+	for rows.Next() {
+		var personID int
+		membership := xone.Membership{}
+		var effectiveFromText, endDateText string
+
+		if err := rows.Scan(&personID, &membership.ID, &effectiveFromText, &membership.Type.ID, &membership.Type.Name, &endDateText, &membership.TerminationReason, &membership.Version); err != nil {
+			return nil, err
+		}
+
+		if membership.EffectiveFrom, err = parseOptionalDate(effectiveFromText); err != nil {
+			return nil, err
+		}
+		if membership.EndDate, err = parseOptionalDate(endDateText); err != nil {
+			return nil, err
+		}
+
+		memberships[personID] = append(memberships[personID], membership)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+func findMembership(ctx context.Context, db dbtx, id int) (xone.Membership, bool, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		SELECT
+			membership.id,
+			membership.effective_from,
+			membership_type.id,
+			membership_type.name,
+			membership.end_date,
+			membership.termination_reason,
+			membership.version
+		FROM
+			membership
+			JOIN membership_type ON membership.type_id = membership_type.id
+		WHERE
+			membership.id = $1
+	`)
+	if err != nil {
+		return xone.Membership{}, false, err
+	}
+
+	row := stmt.QueryRowContext(ctx, id)
+
+	membership := xone.Membership{}
+	var effectiveFromText, endDateText string
+
+	if err := row.Scan(&membership.ID, &effectiveFromText, &membership.Type.ID, &membership.Type.Name, &endDateText, &membership.TerminationReason, &membership.Version); err != nil {
+		if err == sql.ErrNoRows {
+			return xone.Membership{}, false, nil
+		}
+
+		return xone.Membership{}, false, err
+	}
+
+	if membership.EffectiveFrom, err = parseOptionalDate(effectiveFromText); err != nil {
+		return xone.Membership{}, true, err
+	}
+	if membership.EndDate, err = parseOptionalDate(endDateText); err != nil {
+		return xone.Membership{}, true, err
+	}
+
+	return membership, true, nil
+}
+
+func createMembership(ctx context.Context, db dbtx, data xone.CreateMembershipData) error {
+	stmt, err := db.PrepareContext(ctx, `
+		INSERT INTO membership (
+			person_id,
+			type_id,
+			effective_from
+		) VALUES (
+			$1,
+			$2,
+			$3
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	effectiveFrom := ""
+	if !data.EffectiveFrom.IsZero() {
+		effectiveFrom = data.EffectiveFrom.Format(formatDate)
+	}
+
+	_, err = stmt.ExecContext(ctx, data.PersonID, data.MembershipTypeID, effectiveFrom)
+
+	return mapError(err)
+}
+
+// updateMembership changes the type and the effective date of a membership and
+// increments its version. It fails with an *xone.ErrStaleVersion unless the
+// membership exists with the version named in data.
+func updateMembership(ctx context.Context, db dbtx, id int, data xone.UpdateMembershipData) error {
+	stmt, err := db.PrepareContext(ctx, `
+		UPDATE
+			membership
+		SET
+			type_id = $1,
+			effective_from = $2,
+			version = version + 1
+		WHERE
+			id = $3
+			AND version = $4
+	`)
+	if err != nil {
+		return err
+	}
+
+	effectiveFrom := ""
+	if !data.EffectiveFrom.IsZero() {
+		effectiveFrom = data.EffectiveFrom.Format(formatDate)
+	}
+
+	res, err := stmt.ExecContext(ctx, data.MembershipTypeID, effectiveFrom, id, data.Version)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if rows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if int(rows) != 1 {
+		return &xone.ErrStaleVersion{Entity: "membership", ID: strconv.Itoa(id), Version: data.Version}
+	}
+
+	return nil
+}
+
+// setMembershipEnd sets the end date and the termination reason of a
+// membership. Empty values revoke a termination.
+func setMembershipEnd(ctx context.Context, db dbtx, id int, endDate string, reason xone.TerminationReason) error {
+	stmt, err := db.PrepareContext(ctx, `
+		UPDATE
+			membership
+		SET
+			end_date = $1,
+			termination_reason = $2,
+			version = version + 1
+		WHERE
+			id = $3
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, endDate, reason, id)
+
+	return mapError(err)
+}
+
+// parseOptionalDate parses a date which is stored as an empty string if it is
+// not set.
+func parseOptionalDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(formatDate, s)
+}
@@ -0,0 +1,80 @@
+--
+-- Schema
+--
+-- The tables mirror the ones of the sqlite package as far as their entities
+-- are supported by this package, see sqlite/migration. Dates and timestamps
+-- are stored as text in the same formats so that both backends behave alike,
+-- e.g. an empty string is a date which is not set.
+--
+
+--
+-- Users
+--
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'read-only'
+        CHECK (role IN ('admin', 'board', 'treasurer', 'read-only'))
+);
+
+--
+-- Audit log
+--
+CREATE TABLE audit_log (
+    id SERIAL PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    actor_id INTEGER,
+    actor_email TEXT NOT NULL DEFAULT '',
+    operation TEXT NOT NULL CHECK (operation IN ('create', 'update', 'delete')),
+    entity TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    before TEXT NOT NULL DEFAULT '',
+    after TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_log_entity ON audit_log(entity, entity_id);
+
+--
+-- Persons
+--
+CREATE TABLE person (
+    id SERIAL PRIMARY KEY,
+    public_id TEXT NOT NULL UNIQUE,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    date_of_birth TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    mobile TEXT NOT NULL DEFAULT '',
+    street TEXT NOT NULL DEFAULT '',
+    house_number TEXT NOT NULL DEFAULT '',
+    zip_code TEXT NOT NULL DEFAULT '',
+    city TEXT NOT NULL DEFAULT '',
+    deleted_at TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX person_deleted_at ON person(deleted_at);
+
+--
+-- Memberships
+--
+CREATE TABLE membership_type (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE membership (
+    id SERIAL PRIMARY KEY,
+    type_id INTEGER NOT NULL REFERENCES membership_type(id) ON DELETE RESTRICT,
+    person_id INTEGER NOT NULL REFERENCES person(id) ON DELETE CASCADE,
+    effective_from TEXT NOT NULL DEFAULT to_char(CURRENT_DATE, 'YYYY-MM-DD'),
+    end_date TEXT NOT NULL DEFAULT '',
+    termination_reason TEXT NOT NULL DEFAULT ''
+        CHECK (termination_reason IN ('', 'resignation', 'death', 'exclusion', 'other')),
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX membership_person_id ON membership(person_id, effective_from);
@@ -0,0 +1,143 @@
+--
+-- History
+--
+-- Every version of a person, a membership type and a membership is written to
+-- a history table by triggers like in the sqlite package, see HistoryService.
+-- The timestamps have the format of datetime() in SQLite and are in UTC.
+--
+
+CREATE TABLE person_history (
+    id SERIAL PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    person_id INTEGER REFERENCES person(id) ON DELETE CASCADE,
+    public_id TEXT NOT NULL,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    date_of_birth TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    mobile TEXT NOT NULL DEFAULT '',
+    street TEXT NOT NULL DEFAULT '',
+    house_number TEXT NOT NULL DEFAULT '',
+    zip_code TEXT NOT NULL DEFAULT '',
+    city TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX person_history_public_id ON person_history(public_id, created_at);
+
+CREATE FUNCTION write_person_history() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO person_history (
+        created_at,
+        person_id,
+        public_id,
+        first_name,
+        last_name,
+        date_of_birth,
+        email,
+        phone,
+        mobile,
+        street,
+        house_number,
+        zip_code,
+        city
+    ) VALUES (
+        to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS'),
+        NEW.id,
+        NEW.public_id,
+        NEW.first_name,
+        NEW.last_name,
+        NEW.date_of_birth,
+        NEW.email,
+        NEW.phone,
+        NEW.mobile,
+        NEW.street,
+        NEW.house_number,
+        NEW.zip_code,
+        NEW.city
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_history_after_insert_person
+    AFTER INSERT ON person
+    FOR EACH ROW EXECUTE FUNCTION write_person_history();
+
+CREATE TRIGGER update_history_after_update_person
+    AFTER UPDATE ON person
+    FOR EACH ROW EXECUTE FUNCTION write_person_history();
+
+CREATE TABLE membership_type_history (
+    id SERIAL PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    membership_type_id INTEGER NOT NULL,
+    name TEXT NOT NULL
+);
+
+CREATE FUNCTION write_membership_type_history() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO membership_type_history (
+        created_at,
+        membership_type_id,
+        name
+    ) VALUES (
+        to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS'),
+        NEW.id,
+        NEW.name
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER membership_type_insert
+    AFTER INSERT ON membership_type
+    FOR EACH ROW EXECUTE FUNCTION write_membership_type_history();
+
+CREATE TRIGGER membership_type_update
+    AFTER UPDATE ON membership_type
+    FOR EACH ROW EXECUTE FUNCTION write_membership_type_history();
+
+CREATE TABLE membership_history (
+    id SERIAL PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    membership_id INTEGER NOT NULL REFERENCES membership(id) ON DELETE CASCADE,
+    type_id INTEGER NOT NULL,
+    person_id INTEGER NOT NULL,
+    effective_from TEXT NOT NULL,
+    end_date TEXT NOT NULL DEFAULT '',
+    termination_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX membership_history_person_id ON membership_history(person_id, created_at);
+
+CREATE FUNCTION write_membership_history() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO membership_history (
+        created_at,
+        membership_id,
+        type_id,
+        person_id,
+        effective_from,
+        end_date,
+        termination_reason
+    ) VALUES (
+        to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS'),
+        NEW.id,
+        NEW.type_id,
+        NEW.person_id,
+        NEW.effective_from,
+        NEW.end_date,
+        NEW.termination_reason
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER membership_insert
+    AFTER INSERT ON membership
+    FOR EACH ROW EXECUTE FUNCTION write_membership_history();
+
+CREATE TRIGGER membership_update
+    AFTER UPDATE ON membership
+    FOR EACH ROW EXECUTE FUNCTION write_membership_history();
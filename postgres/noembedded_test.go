@@ -0,0 +1,9 @@
+//go:build !embeddedpostgres
+
+package postgres_test
+
+// startEmbeddedPostgres does not start a server unless the tests are built
+// with the embeddedpostgres tag, see embedded_test.go.
+func startEmbeddedPostgres() (string, func() error, error) {
+	return "", nil, nil
+}
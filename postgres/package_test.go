@@ -0,0 +1,130 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone/postgres"
+	"github.com/stillwondering/xone/xonetest"
+)
+
+// dsn names the database the tests run against, see TestMain.
+var dsn string
+
+// schemaCounter makes the names of the schemas created by MustOpenDB unique
+// within a test run.
+var schemaCounter int64
+
+// TestMain runs the tests against the database named by the XONE_POSTGRES_DSN
+// environment variable, e.g. "postgres://xone@localhost/xone_test?sslmode=disable".
+// If it is not set and the tests are built with the embeddedpostgres tag, a
+// temporary server is started instead.
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	dsn = os.Getenv("XONE_POSTGRES_DSN")
+	if dsn == "" {
+		var stop func() error
+		var err error
+		if dsn, stop, err = startEmbeddedPostgres(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if stop != nil {
+			defer func() {
+				if err := stop(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}()
+		}
+	}
+
+	return m.Run()
+}
+
+// MustOpenDB opens the database the tests run against and skips the test if
+// there is none, see TestMain. Every test works in a schema of its own, which
+// is dropped once the test has finished.
+func MustOpenDB(tb testing.TB) *sql.DB {
+	tb.Helper()
+
+	if dsn == "" {
+		tb.Skip("XONE_POSTGRES_DSN not set and built without the embeddedpostgres tag")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("xone_test_%d_%d", time.Now().UnixNano(), atomic.AddInt64(&schemaCounter, 1))
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			tb.Error(err)
+		}
+	})
+
+	db, err := postgres.Open(withSearchPath(tb, dsn, schema))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// withSearchPath adds the search_path parameter to a DSN, which may be given
+// as a URL or as key/value pairs.
+func withSearchPath(tb testing.TB, dsn, schema string) string {
+	tb.Helper()
+
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " search_path=" + schema
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func TestContract(t *testing.T) {
+	xonetest.Run(t, func(t *testing.T) xonetest.Backend {
+		db := MustOpenDB(t)
+
+		userService, err := postgres.NewUserService(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return xonetest.Backend{
+			Persons:     postgres.NewPersonService(db),
+			Users:       userService,
+			Memberships: postgres.NewMembershipService(db),
+			History:     postgres.NewHistoryService(db),
+		}
+	})
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := postgres.Open(""); err == nil {
+		t.Error("Open() error = nil, want an error for an empty DSN")
+	}
+}
@@ -0,0 +1,848 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.PersonRepository = (*PersonService)(nil)
+
+// DefaultRetention is the time deleted persons are kept in the archive unless
+// a different retention period is configured on the PersonService.
+const DefaultRetention = 90 * 24 * time.Hour
+
+type PersonService struct {
+	db         *sql.DB
+	GenerateID func() string
+	Retention  time.Duration
+	Now        func() time.Time
+}
+
+func NewPersonService(db *sql.DB) *PersonService {
+	service := PersonService{
+		db: db,
+		GenerateID: func() string {
+			return uuid.NewV4().String()
+		},
+		Retention: DefaultRetention,
+		Now:       time.Now,
+	}
+
+	return &service
+}
+
+func (ps *PersonService) FindAll(ctx context.Context) ([]xone.Person, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	persons, _, err := findManyPersons(ctx, tx, xone.PersonFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	return persons, tx.Commit()
+}
+
+// FindMany returns the persons matching the filter along with the number of
+// matching persons before limit and offset are applied.
+func (ps *PersonService) FindMany(ctx context.Context, filter xone.PersonFilter) ([]xone.Person, int, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	persons, n, err := findManyPersons(ctx, tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return persons, n, tx.Commit()
+}
+
+func (ps *PersonService) Find(ctx context.Context, id string) (xone.Person, bool, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Person{}, false, err
+	}
+	defer tx.Rollback()
+
+	person, found, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return xone.Person{}, false, err
+	}
+
+	return person, found, tx.Commit()
+}
+
+func (ps *PersonService) Create(ctx context.Context, data xone.CreatePersonData) (xone.Person, error) {
+	if err := data.Validate(); err != nil {
+		return xone.Person{}, err
+	}
+	if err := checkFieldValues(data.CustomFields); err != nil {
+		return xone.Person{}, err
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Person{}, err
+	}
+	defer tx.Rollback()
+
+	id, err := createPerson(ctx, tx, ps.GenerateID(), data)
+	if err != nil {
+		return xone.Person{}, err
+	}
+
+	err = createMembership(ctx, tx, xone.CreateMembershipData{
+		PersonID:         id,
+		MembershipTypeID: data.MembershipTypeID,
+		EffectiveFrom:    data.EffectiveFrom,
+	})
+	if err != nil {
+		return xone.Person{}, err
+	}
+
+	person, err := findPersonByID(ctx, tx, id)
+	if err != nil {
+		return xone.Person{}, err
+	}
+
+	if err := createAuditEntry(ctx, tx, ps.Now(), xone.AuditCreate, xone.AuditPerson, person.PID, nil, person); err != nil {
+		return xone.Person{}, err
+	}
+
+	return person, tx.Commit()
+}
+
+// Delete moves a person to the archive. As this package does not keep track
+// of payments, persons never have an open balance.
+func (ps *PersonService) Delete(ctx context.Context, id string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "person", ID: id}
+	}
+
+	now := ps.Now()
+	if err := archivePerson(ctx, tx, id, now); err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, now, xone.AuditDelete, xone.AuditPerson, id, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Restore brings back a deleted person.
+func (ps *PersonService) Restore(ctx context.Context, id string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findArchivedPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "deleted person", ID: id}
+	}
+
+	if err := restorePerson(ctx, tx, id); err != nil {
+		return err
+	}
+
+	after, _, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, ps.Now(), xone.AuditUpdate, xone.AuditPerson, id, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge removes all persons for good, including their memberships, who have
+// been deleted longer ago than the retention period.
+func (ps *PersonService) Purge(ctx context.Context) (int, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := ps.Now()
+	pids, err := findPurgeablePersons(ctx, tx, now.Add(-ps.Retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pid := range pids {
+		before, _, err := findArchivedPerson(ctx, tx, pid)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := deletePerson(ctx, tx, pid); err != nil {
+			return 0, err
+		}
+
+		if err := createAuditEntry(ctx, tx, now, xone.AuditDelete, xone.AuditPerson, pid, before, nil); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(pids), tx.Commit()
+}
+
+func (ps *PersonService) Update(ctx context.Context, id string, data xone.UpdatePersonData) error {
+	if err := data.Validate(); err != nil {
+		return err
+	}
+	if err := checkFieldValues(data.CustomFields); err != nil {
+		return err
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "person", ID: id}
+	}
+
+	if err := updatePerson(ctx, tx, id, data); err != nil {
+		return err
+	}
+
+	after, _, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, ps.Now(), xone.AuditUpdate, xone.AuditPerson, id, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Patch changes only the fields of a person which are set in the data, see
+// xone.PatchPersonData. An empty patch changes nothing.
+func (ps *PersonService) Patch(ctx context.Context, id string, data xone.PatchPersonData) error {
+	if err := data.Validate(); err != nil {
+		return err
+	}
+	if err := checkFieldValues(data.CustomFields); err != nil {
+		return err
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "person", ID: id}
+	}
+
+	if data.Empty() {
+		return nil
+	}
+
+	if err := patchPerson(ctx, tx, id, data); err != nil {
+		return err
+	}
+
+	after, _, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, ps.Now(), xone.AuditUpdate, xone.AuditPerson, id, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkFieldValues rejects changes of custom fields, which are not supported
+// by this package, with the same error as a field which is not defined.
+func checkFieldValues(changes map[string]string) error {
+	_, err := xone.ApplyFieldValues(nil, nil, changes)
+
+	return err
+}
+
+// personSortColumns maps the fields persons can be sorted by to the columns
+// of the query in findManyPersons. Text is compared byte by byte like in
+// SQLite rather than by the collation of the database, so that both backends
+// sort persons alike.
+var personSortColumns = map[xone.PersonSortField]string{
+	"":                       "person.id",
+	xone.SortByID:            "person.id",
+	xone.SortByFirstName:     `person.first_name COLLATE "C"`,
+	xone.SortByLastName:      `person.last_name COLLATE "C"`,
+	xone.SortByDateOfBirth:   `person.date_of_birth COLLATE "C"`,
+	xone.SortByEmail:         `person.email COLLATE "C"`,
+	xone.SortByPhone:         `person.phone COLLATE "C"`,
+	xone.SortByMobile:        `person.mobile COLLATE "C"`,
+	xone.SortByStreet:        `person.street COLLATE "C"`,
+	xone.SortByHouseNumber:   `person.house_number COLLATE "C"`,
+	xone.SortByZipCode:       `person.zip_code COLLATE "C"`,
+	xone.SortByCity:          `person.city COLLATE "C"`,
+	xone.SortByEffectiveFrom: `current_membership.effective_from COLLATE "C"`,
+}
+
+// findManyPersons returns the persons matching the filter and the total number
+// of matching persons, regardless of limit and offset. The query is the same
+// as the one of the sqlite package.
+func findManyPersons(ctx context.Context, tx dbtx, filter xone.PersonFilter) ([]xone.Person, int, error) {
+	sortColumn, ok := personSortColumns[filter.SortBy]
+	if !ok {
+		return nil, 0, &xone.ErrInvalid{Msg: fmt.Sprintf("cannot sort persons by %q", filter.SortBy)}
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, 0, &xone.ErrInvalid{Msg: "limit and offset must not be negative"}
+	}
+
+	today := filter.Today
+	if today.IsZero() {
+		today = time.Now()
+	}
+
+	var args params
+	todayParam := args.add(today.Format(formatDate))
+
+	// The current membership of a person is the one which became effective
+	// most recently. Memberships without an effective date are considered to
+	// be the oldest ones. Persons whose current membership has ended do not
+	// match any of the membership criteria.
+	from := `
+		FROM
+			person
+			LEFT JOIN membership AS current_membership ON current_membership.id = (
+				SELECT
+					membership.id
+				FROM
+					membership
+				WHERE
+					membership.person_id = person.id
+					AND membership.effective_from <= ` + todayParam + `
+				ORDER BY
+					membership.effective_from DESC,
+					membership.id DESC
+				LIMIT 1
+			)
+	`
+	active := "(current_membership.end_date = '' OR current_membership.end_date >= " + todayParam + ")"
+
+	where := []string{"person.deleted_at = ''"}
+	if filter.Archived {
+		where = []string{"person.deleted_at <> ''"}
+	}
+	if filter.Search != "" {
+		// SQLite's LIKE ignores the case of ASCII letters, PostgreSQL's ILIKE
+		// does so for all letters.
+		pattern := args.add("%" + escapeLike(filter.Search) + "%")
+		where = append(where, `(
+			person.first_name ILIKE `+pattern+` ESCAPE '\'
+			OR person.last_name ILIKE `+pattern+` ESCAPE '\'
+			OR person.email ILIKE `+pattern+` ESCAPE '\'
+			OR person.city ILIKE `+pattern+` ESCAPE '\'
+		)`)
+	}
+	if filter.MembershipTypeID != 0 {
+		where = append(where, "current_membership.type_id = "+args.add(filter.MembershipTypeID), active)
+	}
+	if filter.MinAge > 0 || filter.MaxAge > 0 {
+		where = append(where, "person.date_of_birth <> ''")
+	}
+	if filter.MinAge > 0 {
+		where = append(where, "person.date_of_birth <= "+args.add(today.AddDate(-filter.MinAge, 0, 0).Format(xone.FormatDateOfBirth)))
+	}
+	if filter.MaxAge > 0 {
+		where = append(where, "person.date_of_birth > "+args.add(today.AddDate(-filter.MaxAge-1, 0, 0).Format(xone.FormatDateOfBirth)))
+	}
+	if !filter.EffectiveFrom.IsZero() {
+		where = append(where, "current_membership.effective_from >= "+args.add(filter.EffectiveFrom.Format(formatDate)), active)
+	}
+	if !filter.EffectiveUntil.IsZero() {
+		where = append(where, "current_membership.effective_from <> '' AND current_membership.effective_from <= "+args.add(filter.EffectiveUntil.Format(formatDate)), active)
+	}
+	from += "WHERE " + strings.Join(where, " AND ")
+
+	var n int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) "+from, args...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+
+	// SQLite sorts NULL before all other values, PostgreSQL after them.
+	// Persons without a current membership are sorted like in SQLite.
+	direction := "ASC NULLS FIRST"
+	if filter.Descending {
+		direction = "DESC NULLS LAST"
+	}
+	query := `
+		SELECT
+			person.id,
+			person.public_id,
+			person.first_name,
+			person.last_name,
+			person.date_of_birth,
+			person.email,
+			person.phone,
+			person.mobile,
+			person.street,
+			person.house_number,
+			person.zip_code,
+			person.city,
+			person.deleted_at,
+			person.version
+	` + from + fmt.Sprintf(" ORDER BY %s %s, person.id %s", sortColumn, direction, direction)
+	if filter.Limit > 0 {
+		query += " LIMIT " + args.add(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query += " OFFSET " + args.add(filter.Offset)
+	}
+
+	persons, err := queryPersons(ctx, tx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return persons, n, nil
+}
+
+// queryPersons runs a query which selects the columns of the person table in
+// the order of findManyPersons and returns the persons along with their
+// memberships.
+func queryPersons(ctx context.Context, tx dbtx, query string, args ...interface{}) ([]xone.Person, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var persons []xone.Person
+	for rows.Next() {
+		var p xone.Person
+		var dob, deletedAt string
+		if err := rows.Scan(&p.ID, &p.PID, &p.FirstName, &p.LastName, &dob, &p.Email, &p.Phone, &p.Mobile, &p.Street, &p.HouseNumber, &p.ZipCode, &p.City, &deletedAt, &p.Version); err != nil {
+			return nil, err
+		}
+
+		if dob != "" {
+			if p.DateOfBirth, err = time.Parse(xone.FormatDateOfBirth, dob); err != nil {
+				return nil, err
+			}
+		}
+		if deletedAt != "" {
+			if p.DeletedAt, err = time.Parse(formatTimestamp, deletedAt); err != nil {
+				return nil, err
+			}
+		}
+
+		persons = append(persons, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	ids := make([]int, len(persons))
+	for i, p := range persons {
+		ids[i] = p.ID
+	}
+
+	memberships, err := findMembershipsByPersons(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range persons {
+		persons[i].Memberships = memberships[persons[i].ID]
+	}
+
+	return persons, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so that s is matched
+// literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// findPerson returns the person with the given PID unless they have been
+// deleted.
+func findPerson(ctx context.Context, tx dbtx, pid string) (xone.Person, bool, error) {
+	return findPersonByPID(ctx, tx, pid, false)
+}
+
+// findArchivedPerson returns the person with the given PID if they have been
+// deleted.
+func findArchivedPerson(ctx context.Context, tx dbtx, pid string) (xone.Person, bool, error) {
+	return findPersonByPID(ctx, tx, pid, true)
+}
+
+func findPersonByPID(ctx context.Context, tx dbtx, pid string, archived bool) (xone.Person, bool, error) {
+	persons, err := queryPersons(ctx, tx, `
+		SELECT
+			id,
+			public_id,
+			first_name,
+			last_name,
+			date_of_birth,
+			email,
+			phone,
+			mobile,
+			street,
+			house_number,
+			zip_code,
+			city,
+			deleted_at,
+			version
+		FROM
+			person
+		WHERE
+			public_id = $1
+			AND (deleted_at <> '') = $2
+	`, pid, archived)
+	if err != nil || len(persons) == 0 {
+		return xone.Person{}, false, err
+	}
+
+	return persons[0], true, nil
+}
+
+// findPersonByID returns the person with the given internal ID, regardless of
+// whether they have been deleted.
+func findPersonByID(ctx context.Context, tx dbtx, id int) (xone.Person, error) {
+	persons, err := queryPersons(ctx, tx, `
+		SELECT
+			id,
+			public_id,
+			first_name,
+			last_name,
+			date_of_birth,
+			email,
+			phone,
+			mobile,
+			street,
+			house_number,
+			zip_code,
+			city,
+			deleted_at,
+			version
+		FROM
+			person
+		WHERE
+			id = $1
+	`, id)
+	if err != nil {
+		return xone.Person{}, err
+	} else if len(persons) == 0 {
+		return xone.Person{}, sql.ErrNoRows
+	}
+
+	return persons[0], nil
+}
+
+// createPerson inserts a new person and returns their internal ID.
+func createPerson(ctx context.Context, tx dbtx, pid string, data xone.CreatePersonData) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO person (
+			public_id,
+			first_name,
+			last_name,
+			date_of_birth,
+			email,
+			phone,
+			mobile,
+			street,
+			house_number,
+			zip_code,
+			city
+		) VALUES (
+			$1,
+			$2,
+			$3,
+			$4,
+			$5,
+			$6,
+			$7,
+			$8,
+			$9,
+			$10,
+			$11
+		)
+		RETURNING
+			id
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	dob := ""
+	if !data.DateOfBirth.IsZero() {
+		dob = data.DateOfBirth.Format(xone.FormatDateOfBirth)
+	}
+
+	var id int
+	err = stmt.QueryRowContext(
+		ctx,
+		pid,
+		data.FirstName,
+		data.LastName,
+		dob,
+		data.Email,
+		data.Phone,
+		data.Mobile,
+		data.Street,
+		data.HouseNumber,
+		data.ZipCode,
+		data.City,
+	).Scan(&id)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return id, nil
+}
+
+func deletePerson(ctx context.Context, tx dbtx, id string) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		DELETE FROM
+			person
+		WHERE
+			public_id = $1
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, id)
+
+	return mapError(err)
+}
+
+// archivePerson marks the person with the given PID as deleted at the given
+// point in time.
+func archivePerson(ctx context.Context, tx dbtx, id string, now time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			person
+		SET
+			deleted_at = $1,
+			version = version + 1
+		WHERE
+			public_id = $2
+			AND deleted_at = ''
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, now.UTC().Format(formatTimestamp), id)
+
+	return mapError(err)
+}
+
+func restorePerson(ctx context.Context, tx dbtx, id string) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			person
+		SET
+			deleted_at = '',
+			version = version + 1
+		WHERE
+			public_id = $1
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, id)
+
+	return mapError(err)
+}
+
+// findPurgeablePersons returns the PIDs of all persons who have been deleted
+// at or before the given point in time.
+func findPurgeablePersons(ctx context.Context, tx dbtx, before time.Time) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			public_id
+		FROM
+			person
+		WHERE
+			deleted_at <> ''
+			AND deleted_at <= $1
+		ORDER BY
+			id
+	`, before.UTC().Format(formatTimestamp))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pids []string
+	for rows.Next() {
+		var pid string
+		if err := rows.Scan(&pid); err != nil {
+			return nil, err
+		}
+
+		pids = append(pids, pid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pids, nil
+}
+
+// updatePerson overwrites the data of the person with the given PID and
+// increments their version. It fails with an *xone.ErrStaleVersion unless the
+// person exists with the version named in upd.
+func updatePerson(ctx context.Context, tx dbtx, id string, upd xone.UpdatePersonData) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			person
+		SET
+			first_name = $1,
+			last_name = $2,
+			date_of_birth = $3,
+			email = $4,
+			phone = $5,
+			mobile = $6,
+			street = $7,
+			house_number = $8,
+			zip_code = $9,
+			city = $10,
+			version = version + 1
+		WHERE
+			public_id = $11
+			AND deleted_at = ''
+			AND version = $12
+	`)
+	if err != nil {
+		return err
+	}
+
+	dob := ""
+	if !upd.DateOfBirth.IsZero() {
+		dob = upd.DateOfBirth.Format(xone.FormatDateOfBirth)
+	}
+
+	res, err := stmt.ExecContext(ctx, upd.FirstName, upd.LastName, dob, upd.Email, upd.Phone, upd.Mobile, upd.Street, upd.HouseNumber, upd.ZipCode, upd.City, id, upd.Version)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if rows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rows != 1 {
+		return &xone.ErrStaleVersion{Entity: "person", ID: id, Version: upd.Version}
+	}
+
+	return nil
+}
+
+// patchPerson writes the fields which are set in the patch and increments the
+// version of the person with the given PID. If the patch names a version, it
+// fails with an *xone.ErrStaleVersion unless the person has that version.
+func patchPerson(ctx context.Context, tx dbtx, id string, patch xone.PatchPersonData) error {
+	var columns []string
+	var args params
+	set := func(column string, value *string) {
+		if value != nil {
+			columns = append(columns, column+" = "+args.add(*value))
+		}
+	}
+
+	var dob *string
+	if patch.DateOfBirth != nil {
+		s := ""
+		if !patch.DateOfBirth.IsZero() {
+			s = patch.DateOfBirth.Format(xone.FormatDateOfBirth)
+		}
+		dob = &s
+	}
+
+	set("first_name", patch.FirstName)
+	set("last_name", patch.LastName)
+	set("date_of_birth", dob)
+	set("email", patch.Email)
+	set("phone", patch.Phone)
+	set("mobile", patch.Mobile)
+	set("street", patch.Street)
+	set("house_number", patch.HouseNumber)
+	set("zip_code", patch.ZipCode)
+	set("city", patch.City)
+	columns = append(columns, "version = version + 1")
+
+	conditions := "public_id = " + args.add(id) + " AND deleted_at = ''"
+	if patch.Version != 0 {
+		conditions += " AND version = " + args.add(patch.Version)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			person
+		SET
+			`+strings.Join(columns, ", ")+`
+		WHERE
+			`+conditions+`
+	`)
+	if err != nil {
+		return err
+	}
+
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if rows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rows != 1 {
+		if patch.Version == 0 {
+			return &xone.ErrNotFound{Entity: "person", ID: id}
+		}
+		return &xone.ErrStaleVersion{Entity: "person", ID: id, Version: patch.Version}
+	}
+
+	return nil
+}
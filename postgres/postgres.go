@@ -0,0 +1,146 @@
+// Package postgres implements the person, user, membership and history
+// services on top of PostgreSQL. It is meant for installations which are too
+// large for the sqlite package, e.g. a federation of many organizations.
+//
+// The services behave like their counterparts in the sqlite package, which is
+// verified by the contract tests in the xonetest package. The schema mirrors
+// the tables of those entities only: custom fields, households, fees,
+// payments, mandates, invoices and sessions are not supported yet. Persons do
+// not have custom fields, and persons cannot owe money, so they can always be
+// deleted.
+//
+// The tests run against the database named by the XONE_POSTGRES_DSN
+// environment variable. Without it, they are skipped unless they are built
+// with the embeddedpostgres tag, which starts a temporary server.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+
+	"github.com/lib/pq"
+	"github.com/stillwondering/xone"
+)
+
+// formatDate is the format used to store dates, see the sqlite package.
+const formatDate = "2006-01-02"
+
+// dbtx is an abstraction layer over a database OR a transaction.
+type dbtx interface {
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+//go:embed migration/*.sql
+var migrationFS embed.FS
+
+// Open connects to the database with the given DSN, e.g.
+// "postgres://xone@localhost/xone?sslmode=disable", and runs all migrations
+// which have not been run yet.
+func Open(dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		return nil, errors.New("DSN required")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	return db, nil
+}
+
+func migrate(db *sql.DB) error {
+	// Ensure the 'migrations' table exists so we don't duplicate migrations.
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS migrations (name TEXT PRIMARY KEY);`); err != nil {
+		return fmt.Errorf("cannot create migrations table: %w", err)
+	}
+
+	names, err := fs.Glob(migrationFS, "migration/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := migrateFile(db, migrationFS, name); err != nil {
+			return fmt.Errorf("migration error: name=%q err=%w", name, err)
+		}
+	}
+	return nil
+}
+
+// migrateFile runs a single migration file within a transaction. On success,
+// the migration file name is saved to the "migrations" table to prevent
+// re-running.
+func migrateFile(db *sql.DB, fsys fs.FS, name string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Ensure migration has not already been run.
+	var n int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM migrations WHERE name = $1`, name).Scan(&n); err != nil {
+		return err
+	} else if n != 0 {
+		return nil // already run migration, skip
+	}
+
+	// Read and execute migration file. Without arguments, the statements are
+	// sent as a simple query, which may contain several of them.
+	if buf, err := fs.ReadFile(fsys, name); err != nil {
+		return err
+	} else if _, err := tx.Exec(string(buf)); err != nil {
+		return err
+	}
+
+	// Insert record into migrations to prevent re-running migration.
+	if _, err := tx.Exec(`INSERT INTO migrations (name) VALUES ($1)`, name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// mapError translates the integrity constraint violations reported by
+// PostgreSQL into the error kinds of the xone package like the function of the
+// same name in the sqlite package: unique violations become an
+// *xone.ErrConflict, all other violations an *xone.ErrInvalid. Other errors are
+// returned unchanged.
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code.Class() != "23" {
+		return err
+	}
+
+	if pqErr.Code.Name() == "unique_violation" {
+		return &xone.ErrConflict{Msg: pqErr.Message, Err: err}
+	}
+
+	return &xone.ErrInvalid{Msg: pqErr.Message, Err: err}
+}
+
+// params collects the arguments of a query which is built dynamically and
+// hands out their placeholders.
+type params []interface{}
+
+// add appends the argument and returns its placeholder, e.g. "$3".
+func (p *params) add(v interface{}) string {
+	*p = append(*p, v)
+
+	return "$" + strconv.Itoa(len(*p))
+}
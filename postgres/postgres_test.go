@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stillwondering/xone"
+)
+
+func Test_mapError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want interface{}
+	}{
+		{name: "Unique violation", err: &pq.Error{Code: "23505", Message: "duplicate key value"}, want: new(*xone.ErrConflict)},
+		{name: "Foreign key violation", err: &pq.Error{Code: "23503", Message: "violates foreign key constraint"}, want: new(*xone.ErrInvalid)},
+		{name: "Check violation", err: &pq.Error{Code: "23514", Message: "violates check constraint"}, want: new(*xone.ErrInvalid)},
+		{name: "Wrapped", err: fmt.Errorf("insert: %w", &pq.Error{Code: "23505"}), want: new(*xone.ErrConflict)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mapError(tt.err)
+			if !errors.As(got, tt.want) {
+				t.Fatalf("mapError() = %v, want %T", got, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("mapError() = %v, want it to wrap %v", got, tt.err)
+			}
+		})
+	}
+
+	for _, err := range []error{nil, errors.New("connection refused"), &pq.Error{Code: "42P01"}} {
+		if got := mapError(err); got != err {
+			t.Errorf("mapError(%v) = %v, want it unchanged", err, got)
+		}
+	}
+}
+
+func Test_params(t *testing.T) {
+	var args params
+	got := []string{args.add("Harry"), args.add(42), args.add("Potter")}
+
+	if want := []string{"$1", "$2", "$3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("params.add() = %v, want %v", got, want)
+	}
+	if want := (params{"Harry", 42, "Potter"}); !reflect.DeepEqual(args, want) {
+		t.Errorf("params = %v, want %v", args, want)
+	}
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/bcrypt"
+)
+
+var _ xone.UserService = (*UserService)(nil)
+
+type UserService struct {
+	db  *sql.DB
+	Now func() time.Time
+}
+
+func NewUserService(db *sql.DB) (*UserService, error) {
+	service := UserService{
+		db:  db,
+		Now: time.Now,
+	}
+
+	return &service, nil
+}
+
+func (us *UserService) FindByEmail(ctx context.Context, email string) (xone.User, bool, error) {
+	tx, err := us.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.User{}, false, err
+	}
+	defer tx.Rollback()
+
+	user, found, err := findUserByEmail(ctx, tx, email)
+	if err != nil {
+		return xone.User{}, false, err
+	}
+
+	return user, found, tx.Commit()
+}
+
+func (us *UserService) Create(ctx context.Context, data xone.CreateUserData) (xone.User, error) {
+	tx, err := us.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.User{}, err
+	}
+	defer tx.Rollback()
+
+	if data.Role == "" {
+		data.Role = xone.RoleReadOnly
+	} else if !data.Role.Valid() {
+		return xone.User{}, &xone.ErrInvalidRole{Role: data.Role}
+	}
+
+	if _, found, err := findUserByEmail(ctx, tx, data.Email); err != nil {
+		return xone.User{}, err
+	} else if found {
+		return xone.User{}, &xone.ErrUserExists{Data: data}
+	}
+
+	user, err := createUser(ctx, tx, data)
+	if err != nil {
+		return xone.User{}, err
+	}
+
+	if err := createAuditEntry(ctx, tx, us.Now(), xone.AuditCreate, xone.AuditUser, strconv.Itoa(user.ID), nil, auditUser{ID: user.ID, Email: user.Email, Role: user.Role}); err != nil {
+		return xone.User{}, err
+	}
+
+	return user, tx.Commit()
+}
+
+// Authenticate verifies the given credentials and returns the matching user
+// without their password hash. If the email is unknown or the password is
+// wrong, *xone.ErrInvalidCredentials is returned.
+func (us *UserService) Authenticate(ctx context.Context, email, password string) (xone.User, error) {
+	tx, err := us.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.User{}, err
+	}
+	defer tx.Rollback()
+
+	user, found, err := findUserByEmail(ctx, tx, email)
+	if err != nil {
+		return xone.User{}, err
+	}
+
+	// Compare against a dummy hash for unknown users as well, so the response
+	// time does not reveal which email addresses are registered.
+	hash := dummyPasswordHash
+	if found {
+		hash = []byte(user.Password)
+	}
+
+	if !bcrypt.PasswordMatchesHash(hash, []byte(password)) || !found {
+		return xone.User{}, &xone.ErrInvalidCredentials{Email: email}
+	}
+
+	// The hash is not needed anymore, so it must not travel any further, e.g.
+	// into a session or the request context.
+	user.Password = ""
+
+	return user, tx.Commit()
+}
+
+// dummyPasswordHash is a valid bcrypt hash of a random password which is only
+// used to keep Authenticate busy for unknown users.
+var dummyPasswordHash = []byte("$2a$08$MxU7JPbgKHne3ENDK.C3IeHGSNMTdwmaYMQJAKn7lxma5XnoUYDGu")
+
+func findUserByEmail(ctx context.Context, db dbtx, email string) (xone.User, bool, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		SELECT
+			id,
+			email,
+			password,
+			role
+		FROM
+			users
+		WHERE
+			email = $1
+	`)
+	if err != nil {
+		return xone.User{}, false, err
+	}
+
+	user := xone.User{}
+	row := stmt.QueryRowContext(ctx, email)
+	if err := row.Scan(&user.ID, &user.Email, &user.Password, &user.Role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, false, nil
+		}
+
+		return user, false, err
+	}
+
+	return user, true, nil
+}
+
+func createUser(ctx context.Context, db dbtx, data xone.CreateUserData) (xone.User, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		INSERT INTO users (
+			email,
+			password,
+			role
+		) VALUES (
+			$1,
+			$2,
+			$3
+		)
+		RETURNING
+			id
+	`)
+	if err != nil {
+		return xone.User{}, err
+	}
+
+	var id int
+	if err := stmt.QueryRowContext(ctx, data.Email, data.Password, data.Role).Scan(&id); err != nil {
+		return xone.User{}, mapError(err)
+	}
+
+	return xone.User{
+		ID:       id,
+		Email:    data.Email,
+		Password: data.Password,
+		Role:     data.Role,
+	}, nil
+}
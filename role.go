@@ -0,0 +1,83 @@
+package xone
+
+// Role determines what a user is allowed to do.
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleBoard     Role = "board"
+	RoleTreasurer Role = "treasurer"
+	RoleReadOnly  Role = "read-only"
+)
+
+// Permission is the right to perform a certain kind of operation.
+type Permission string
+
+const (
+	PermissionReadPersons          Permission = "persons:read"
+	PermissionWritePersons         Permission = "persons:write"
+	PermissionDeletePersons        Permission = "persons:delete"
+	PermissionPurgePersons         Permission = "persons:purge"
+	PermissionWriteMemberships     Permission = "memberships:write"
+	PermissionWriteMembershipTypes Permission = "membership-types:write"
+	PermissionReadFees             Permission = "fees:read"
+	PermissionWriteFees            Permission = "fees:write"
+	PermissionReadPayments         Permission = "payments:read"
+	PermissionWritePayments        Permission = "payments:write"
+	PermissionManageFields         Permission = "fields:write"
+	PermissionManageUsers          Permission = "users:write"
+	PermissionReadAuditLog         Permission = "audit-log:read"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermissionReadPersons,
+		PermissionWritePersons,
+		PermissionDeletePersons,
+		PermissionPurgePersons,
+		PermissionWriteMemberships,
+		PermissionWriteMembershipTypes,
+		PermissionReadFees,
+		PermissionWriteFees,
+		PermissionReadPayments,
+		PermissionWritePayments,
+		PermissionManageFields,
+		PermissionManageUsers,
+		PermissionReadAuditLog,
+	},
+	RoleBoard: {
+		PermissionReadPersons,
+		PermissionWritePersons,
+		PermissionDeletePersons,
+		PermissionWriteMemberships,
+		PermissionWriteMembershipTypes,
+	},
+	RoleTreasurer: {
+		PermissionReadPersons,
+		PermissionWritePersons,
+		PermissionReadFees,
+		PermissionWriteFees,
+		PermissionReadPayments,
+		PermissionWritePayments,
+	},
+	RoleReadOnly: {
+		PermissionReadPersons,
+	},
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can reports whether the role grants the given permission.
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+
+	return false
+}
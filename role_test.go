@@ -0,0 +1,35 @@
+package xone
+
+import "testing"
+
+func TestRole_Can(t *testing.T) {
+	tests := []struct {
+		name string
+		role Role
+		p    Permission
+		want bool
+	}{
+		{name: "Admin may delete persons", role: RoleAdmin, p: PermissionDeletePersons, want: true},
+		{name: "Board may delete persons", role: RoleBoard, p: PermissionDeletePersons, want: true},
+		{name: "Board may not read fees", role: RoleBoard, p: PermissionReadFees, want: false},
+		{name: "Treasurer may read fees", role: RoleTreasurer, p: PermissionReadFees, want: true},
+		{name: "Treasurer may not delete persons", role: RoleTreasurer, p: PermissionDeletePersons, want: false},
+		{name: "Read-only may read persons", role: RoleReadOnly, p: PermissionReadPersons, want: true},
+		{name: "Read-only may not write persons", role: RoleReadOnly, p: PermissionWritePersons, want: false},
+		{name: "Board may not purge persons", role: RoleBoard, p: PermissionPurgePersons, want: false},
+		{name: "Admin may read the audit log", role: RoleAdmin, p: PermissionReadAuditLog, want: true},
+		{name: "Board may not read the audit log", role: RoleBoard, p: PermissionReadAuditLog, want: false},
+		{name: "Treasurer may record payments", role: RoleTreasurer, p: PermissionWritePayments, want: true},
+		{name: "Board may not read payments", role: RoleBoard, p: PermissionReadPayments, want: false},
+		{name: "Admin may manage custom fields", role: RoleAdmin, p: PermissionManageFields, want: true},
+		{name: "Board may not manage custom fields", role: RoleBoard, p: PermissionManageFields, want: false},
+		{name: "Unknown role", role: Role("headmaster"), p: PermissionReadPersons, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.role.Can(tt.p); got != tt.want {
+				t.Errorf("Role.Can() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Package sepa writes SEPA direct debit batches as pain.008.001.02 XML files,
+// which are accepted by most European banks.
+package sepa
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+// Namespace is the XML namespace of pain.008.001.02 documents.
+const Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.008.001.02"
+
+var ErrNoDebits = errors.New("no direct debits")
+
+// Creditor is the club which collects the direct debits.
+type Creditor struct {
+	Name string
+	IBAN string
+
+	// BIC is optional within SEPA.
+	BIC string
+
+	// ID is the SEPA creditor identifier, e.g. DE98ZZZ09999999999.
+	ID string
+}
+
+// Validate checks the IBAN, the BIC and the creditor identifier.
+func (c Creditor) Validate() error {
+	if sanitize(c.Name, 70) == "" {
+		return errors.New("creditor name required")
+	}
+	if !xone.ValidIBAN(c.IBAN) {
+		return &xone.ErrInvalidIBAN{IBAN: c.IBAN}
+	}
+	if c.BIC != "" && !xone.ValidBIC(c.BIC) {
+		return fmt.Errorf(`"%s" is not a valid BIC`, c.BIC)
+	}
+	if !ValidCreditorID(c.ID) {
+		return fmt.Errorf(`"%s" is not a valid creditor identifier`, c.ID)
+	}
+
+	return nil
+}
+
+// ValidCreditorID reports whether id is a valid SEPA creditor identifier. Its
+// check digits are calculated like the ones of an IBAN, except that the
+// creditor business code at positions 5 to 7 is ignored.
+func ValidCreditorID(id string) bool {
+	if len(id) < 8 || len(id) > 35 {
+		return false
+	}
+
+	return xone.Mod97(id[7:]+id[:4]) == 1
+}
+
+// Batch is a set of direct debits which are collected on the same day.
+type Batch struct {
+	// MessageID uniquely identifies the file, it must not be longer than 35
+	// characters.
+	MessageID      string
+	CreatedAt      time.Time
+	CollectionDate time.Time
+	Creditor       Creditor
+
+	// RemittanceInformation is shown to the debtors on their bank
+	// statements, e.g. "Membership fees 2022".
+	RemittanceInformation string
+
+	Debits []xone.DirectDebit
+}
+
+// Write writes the batch as a pain.008.001.02 document to w. Direct debits
+// are grouped by their sequence type, since a payment information block may
+// only contain one of them.
+func Write(w io.Writer, b Batch) error {
+	if len(b.Debits) == 0 {
+		return ErrNoDebits
+	}
+	if err := b.Creditor.Validate(); err != nil {
+		return err
+	}
+	if b.MessageID == "" || len(b.MessageID) > 35 {
+		return fmt.Errorf(`"%s" is not a valid message ID`, b.MessageID)
+	}
+
+	doc := document{
+		Xmlns: Namespace,
+		Initiation: initiation{
+			GroupHeader: groupHeader{
+				MessageID:         b.MessageID,
+				CreatedAt:         b.CreatedAt.Format("2006-01-02T15:04:05"),
+				NumberOfTxs:       len(b.Debits),
+				ControlSum:        xone.FormatAmount(sum(b.Debits)),
+				InitiatingPartyNm: sanitize(b.Creditor.Name, 70),
+			},
+		},
+	}
+
+	for _, seq := range []string{"FRST", "RCUR"} {
+		var debits []xone.DirectDebit
+		for _, d := range b.Debits {
+			if d.Mandate.SequenceType() == seq {
+				debits = append(debits, d)
+			}
+		}
+		if len(debits) == 0 {
+			continue
+		}
+
+		info := paymentInfo{
+			ID:              b.MessageID + "-" + seq,
+			Method:          "DD",
+			BatchBooking:    true,
+			NumberOfTxs:     len(debits),
+			ControlSum:      xone.FormatAmount(sum(debits)),
+			ServiceLevel:    "SEPA",
+			LocalInstrument: "CORE",
+			SequenceType:    seq,
+			CollectionDate:  b.CollectionDate.Format(xone.FormatDateOfBirth),
+			CreditorName:    sanitize(b.Creditor.Name, 70),
+			CreditorIBAN:    b.Creditor.IBAN,
+			CreditorAgent:   newAgent(b.Creditor.BIC),
+			ChargeBearer:    "SLEV",
+			CreditorScheme:  creditorScheme{ID: b.Creditor.ID, SchemeName: "SEPA"},
+		}
+
+		for _, d := range debits {
+			if d.Amount <= 0 {
+				return fmt.Errorf("direct debit %s: amount must be positive", d.EndToEndID)
+			}
+
+			info.Transactions = append(info.Transactions, transaction{
+				EndToEndID: d.EndToEndID,
+				Amount:     amount{Currency: "EUR", Value: xone.FormatAmount(d.Amount)},
+				Mandate: mandate{
+					ID:       d.Mandate.Reference,
+					SignedOn: d.Mandate.SignedOn.Format(xone.FormatDateOfBirth),
+				},
+				DebtorAgent:           newAgent(d.Mandate.BIC),
+				DebtorName:            sanitize(d.DebtorName(), 70),
+				DebtorIBAN:            d.Mandate.IBAN,
+				RemittanceInformation: sanitize(b.RemittanceInformation, 140),
+			})
+		}
+
+		doc.Initiation.PaymentInfos = append(doc.Initiation.PaymentInfos, info)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func sum(debits []xone.DirectDebit) int64 {
+	var total int64
+	for _, d := range debits {
+		total += d.Amount
+	}
+
+	return total
+}
+
+// transliterations replace characters which are common in names but not part
+// of the restricted SEPA character set.
+var transliterations = strings.NewReplacer(
+	"Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"&", "+", "é", "e", "è", "e", "ê", "e", "á", "a", "à", "a", "ó", "o", "ò", "o",
+	"í", "i", "ú", "u", "ñ", "n", "ç", "c", "É", "E", "Á", "A", "Ó", "O",
+)
+
+// sanitize restricts s to the SEPA character set and to the given number of
+// characters. Characters which cannot be transliterated are replaced by a
+// space.
+func sanitize(s string, max int) string {
+	s = transliterations.Replace(s)
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("/-?:().,'+ ", r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	s = strings.Join(strings.Fields(b.String()), " ")
+	if len(s) > max {
+		s = strings.TrimSpace(s[:max])
+	}
+
+	return s
+}
+
+type document struct {
+	XMLName    xml.Name   `xml:"Document"`
+	Xmlns      string     `xml:"xmlns,attr"`
+	Initiation initiation `xml:"CstmrDrctDbtInitn"`
+}
+
+type initiation struct {
+	GroupHeader  groupHeader   `xml:"GrpHdr"`
+	PaymentInfos []paymentInfo `xml:"PmtInf"`
+}
+
+type groupHeader struct {
+	MessageID         string `xml:"MsgId"`
+	CreatedAt         string `xml:"CreDtTm"`
+	NumberOfTxs       int    `xml:"NbOfTxs"`
+	ControlSum        string `xml:"CtrlSum"`
+	InitiatingPartyNm string `xml:"InitgPty>Nm"`
+}
+
+type paymentInfo struct {
+	ID              string         `xml:"PmtInfId"`
+	Method          string         `xml:"PmtMtd"`
+	BatchBooking    bool           `xml:"BtchBookg"`
+	NumberOfTxs     int            `xml:"NbOfTxs"`
+	ControlSum      string         `xml:"CtrlSum"`
+	ServiceLevel    string         `xml:"PmtTpInf>SvcLvl>Cd"`
+	LocalInstrument string         `xml:"PmtTpInf>LclInstrm>Cd"`
+	SequenceType    string         `xml:"PmtTpInf>SeqTp"`
+	CollectionDate  string         `xml:"ReqdColltnDt"`
+	CreditorName    string         `xml:"Cdtr>Nm"`
+	CreditorIBAN    string         `xml:"CdtrAcct>Id>IBAN"`
+	CreditorAgent   agent          `xml:"CdtrAgt"`
+	ChargeBearer    string         `xml:"ChrgBr"`
+	CreditorScheme  creditorScheme `xml:"CdtrSchmeId"`
+	Transactions    []transaction  `xml:"DrctDbtTxInf"`
+}
+
+// agent identifies a bank by its BIC. Without a BIC, "NOTPROVIDED" is sent
+// instead.
+type agent struct {
+	BIC   string      `xml:"FinInstnId>BIC,omitempty"`
+	Other *otherAgent `xml:"FinInstnId>Othr,omitempty"`
+}
+
+type otherAgent struct {
+	ID string `xml:"Id"`
+}
+
+func newAgent(bic string) agent {
+	if bic == "" {
+		return agent{Other: &otherAgent{ID: "NOTPROVIDED"}}
+	}
+
+	return agent{BIC: bic}
+}
+
+type creditorScheme struct {
+	ID         string `xml:"Id>PrvtId>Othr>Id"`
+	SchemeName string `xml:"Id>PrvtId>Othr>SchmeNm>Prtry"`
+}
+
+type transaction struct {
+	EndToEndID            string  `xml:"PmtId>EndToEndId"`
+	Amount                amount  `xml:"InstdAmt"`
+	Mandate               mandate `xml:"DrctDbtTx>MndtRltdInf"`
+	DebtorAgent           agent   `xml:"DbtrAgt"`
+	DebtorName            string  `xml:"Dbtr>Nm"`
+	DebtorIBAN            string  `xml:"DbtrAcct>Id>IBAN"`
+	RemittanceInformation string  `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+type amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type mandate struct {
+	ID       string `xml:"MndtId"`
+	SignedOn string `xml:"DtOfSgntr"`
+}
@@ -0,0 +1,114 @@
+package sepa
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+func TestWrite(t *testing.T) {
+	batch := Batch{
+		MessageID:      "XONE-20220115",
+		CreatedAt:      time.Date(2022, time.January, 10, 9, 30, 0, 0, time.UTC),
+		CollectionDate: time.Date(2022, time.January, 15, 0, 0, 0, 0, time.UTC),
+		Creditor: Creditor{
+			Name: "Quidditch Club Ottery St. Catchpole",
+			IBAN: "DE89370400440532013000",
+			BIC:  "COBADEFFXXX",
+			ID:   "DE98ZZZ09999999999",
+		},
+		RemittanceInformation: "Membership fees 2022",
+		Debits: []xone.DirectDebit{
+			{
+				Person: xone.Person{FirstName: "Harry", LastName: "Potter"},
+				Mandate: xone.Mandate{
+					IBAN:      "DE02120300000000202051",
+					BIC:       "BYLADEM1001",
+					Reference: "XONE-1",
+					SignedOn:  time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC),
+				},
+				Amount:     12000,
+				EndToEndID: "20220115-1",
+			},
+			{
+				Person: xone.Person{FirstName: "Ginny", LastName: "Weasley"},
+				Mandate: xone.Mandate{
+					AccountHolder:   "Molly Weasley",
+					IBAN:            "AT611904300234573201",
+					Reference:       "XONE-2",
+					SignedOn:        time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC),
+					LastCollectedOn: time.Date(2021, time.January, 15, 0, 0, 0, 0, time.UTC),
+				},
+				Amount:     6050,
+				EndToEndID: "20220115-2",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, batch); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	want, err := os.ReadFile("testdata/Write.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != string(want) {
+		t.Errorf("Write() = %s, want %s", got, want)
+	}
+
+	if err := Write(&buf, Batch{MessageID: "XONE", Creditor: batch.Creditor}); !errors.Is(err, ErrNoDebits) {
+		t.Errorf("Write() error = %v, want %v", err, ErrNoDebits)
+	}
+
+	batch.Creditor.IBAN = "DE89370400440532013001"
+	var invalidIBAN *xone.ErrInvalidIBAN
+	if err := Write(&buf, batch); !errors.As(err, &invalidIBAN) {
+		t.Errorf("Write() error = %v, want *xone.ErrInvalidIBAN", err)
+	}
+}
+
+func TestValidCreditorID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "DE98ZZZ09999999999", want: true},
+		{id: "DE98ABC09999999999", want: true},
+		{id: "DE99ZZZ09999999999", want: false},
+		{id: "DE98ZZZ", want: false},
+		{id: "de98zzz09999999999", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := ValidCreditorID(tt.id); got != tt.want {
+				t.Errorf("ValidCreditorID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sanitize(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{s: "Jürgen Müller-Lüdenscheidt", max: 70, want: "Juergen Mueller-Luedenscheidt"},
+		{s: "Smith & Sons", max: 70, want: "Smith + Sons"},
+		{s: "Łukasz  <Test>", max: 70, want: "ukasz Test"},
+		{s: "Hermione Granger", max: 8, want: "Hermione"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := sanitize(tt.s, tt.max); got != tt.want {
+				t.Errorf("sanitize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package xone
+
+import "time"
+
+// Session represents a logged in user. The token is handed out to the client
+// and has to be presented with every subsequent request.
+type Session struct {
+	Token     string
+	User      User
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the session is no longer valid at the given time.
+func (s Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
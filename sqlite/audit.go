@@ -0,0 +1,171 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.AuditService = (*AuditService)(nil)
+
+// AuditService reads the audit log which is written by the other services of
+// this package whenever they change something.
+type AuditService struct {
+	db *sql.DB
+}
+
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+func (as *AuditService) FindAuditEntries(ctx context.Context, entity xone.AuditEntity, id string) ([]xone.AuditEntry, error) {
+	tx, err := as.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	entries, err := findAuditEntries(ctx, tx, entity, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, tx.Commit()
+}
+
+// auditUser is the representation of a user in the audit log. It leaves out
+// the password hash.
+type auditUser struct {
+	ID    int
+	Email string
+	Role  xone.Role
+}
+
+// createAuditEntry records a change of an entity at the given time, which is
+// taken from the clock of the calling service. The actor and the reason are
+// taken from the context. Before and after are stored as JSON unless they are
+// nil.
+func createAuditEntry(ctx context.Context, tx dbtx, now time.Time, op xone.AuditOperation, entity xone.AuditEntity, id string, before, after interface{}) error {
+	beforeJSON, err := marshalAuditValue(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalAuditValue(after)
+	if err != nil {
+		return err
+	}
+
+	var actorID sql.NullInt64
+	var actorEmail string
+	if user, ok := xone.UserFromContext(ctx); ok {
+		actorID = sql.NullInt64{Int64: int64(user.ID), Valid: true}
+		actorEmail = user.Email
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO audit_log (
+			created_at,
+			actor_id,
+			actor_email,
+			operation,
+			entity,
+			entity_id,
+			before,
+			after,
+			reason
+		) VALUES (
+			?,
+			?,
+			?,
+			?,
+			?,
+			?,
+			?,
+			?,
+			?
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(
+		ctx,
+		now.UTC().Format(formatTimestamp),
+		actorID,
+		actorEmail,
+		op,
+		entity,
+		id,
+		beforeJSON,
+		afterJSON,
+		xone.ReasonFromContext(ctx),
+	)
+
+	return err
+}
+
+func marshalAuditValue(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func findAuditEntries(ctx context.Context, tx dbtx, entity xone.AuditEntity, id string) ([]xone.AuditEntry, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_at,
+			actor_id,
+			actor_email,
+			operation,
+			entity,
+			entity_id,
+			before,
+			after,
+			reason
+		FROM
+			audit_log
+		WHERE
+			entity = ?
+			AND entity_id = ?
+		ORDER BY
+			id
+	`, entity, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []xone.AuditEntry
+	for rows.Next() {
+		var e xone.AuditEntry
+		var createdAt string
+		var actorID sql.NullInt64
+		if err := rows.Scan(&e.ID, &createdAt, &actorID, &e.ActorEmail, &e.Operation, &e.Entity, &e.EntityID, &e.Before, &e.After, &e.Reason); err != nil {
+			return nil, err
+		}
+
+		if e.Time, err = time.Parse(formatTimestamp, createdAt); err != nil {
+			return nil, err
+		}
+		e.ActorID = int(actorID.Int64)
+
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
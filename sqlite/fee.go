@@ -0,0 +1,278 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.FeeService = (*FeeService)(nil)
+
+type FeeService struct {
+	db  *sql.DB
+	Now func() time.Time
+}
+
+func NewFeeService(db *sql.DB) *FeeService {
+	return &FeeService{
+		db:  db,
+		Now: time.Now,
+	}
+}
+
+func (s *FeeService) FindFees(ctx context.Context, membershipTypeID int) ([]xone.Fee, error) {
+	fees, err := findAllFees(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return fees[membershipTypeID], nil
+}
+
+func (s *FeeService) CreateFee(ctx context.Context, data xone.CreateFeeData) (xone.Fee, error) {
+	if !data.Period.Valid() {
+		return xone.Fee{}, &xone.ErrInvalidBillingPeriod{Period: data.Period}
+	}
+	if data.Amount < 0 {
+		return xone.Fee{}, &xone.ErrInvalid{Msg: "amount must not be negative"}
+	}
+	if data.ValidFrom.IsZero() {
+		return xone.Fee{}, &xone.ErrInvalid{Msg: "valid from date required"}
+	}
+	if !data.ValidUntil.IsZero() && data.ValidUntil.Before(data.ValidFrom) {
+		return xone.Fee{}, &xone.ErrInvalid{Msg: "valid until date lies before the valid from date"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Fee{}, err
+	}
+	defer tx.Rollback()
+
+	fees, err := findAllFees(ctx, tx)
+	if err != nil {
+		return xone.Fee{}, err
+	}
+
+	fee := xone.Fee{
+		MembershipTypeID: data.MembershipTypeID,
+		Amount:           data.Amount,
+		Period:           data.Period,
+		ValidFrom:        data.ValidFrom,
+		ValidUntil:       data.ValidUntil,
+	}
+	for _, other := range fees[data.MembershipTypeID] {
+		if fee.Overlaps(other) {
+			return xone.Fee{}, &xone.ErrOverlappingFee{Fee: other}
+		}
+	}
+
+	if fee.ID, err = createFee(ctx, tx, fee); err != nil {
+		return xone.Fee{}, err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditCreate, xone.AuditFee, strconv.Itoa(fee.ID), nil, fee); err != nil {
+		return xone.Fee{}, err
+	}
+
+	return fee, tx.Commit()
+}
+
+func (s *FeeService) DeleteFee(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	fee, found, err := findFee(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "fee", ID: strconv.Itoa(id)}
+	}
+
+	if err := deleteFee(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditDelete, xone.AuditFee, strconv.Itoa(id), fee, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *FeeService) CalculateDues(ctx context.Context, pid string, from, until time.Time) (xone.Dues, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Dues{}, err
+	}
+	defer tx.Rollback()
+
+	person, found, err := findPerson(ctx, tx, pid)
+	if err != nil {
+		return xone.Dues{}, err
+	} else if !found {
+		return xone.Dues{}, &xone.ErrNotFound{Entity: "person", ID: pid}
+	}
+
+	fees, err := findAllFees(ctx, tx)
+	if err != nil {
+		return xone.Dues{}, err
+	}
+
+	return duesOf(person, fees, from, until), tx.Commit()
+}
+
+// duesOf calculates the dues of a person from the fees of all membership
+// types as returned by findAllFees.
+func duesOf(person xone.Person, fees map[int][]xone.Fee, from, until time.Time) xone.Dues {
+	// A person may return to a membership type, whose fees must still be
+	// passed only once.
+	var all []xone.Fee
+	seen := map[int]bool{}
+	for _, m := range person.Memberships {
+		if !seen[m.Type.ID] {
+			seen[m.Type.ID] = true
+			all = append(all, fees[m.Type.ID]...)
+		}
+	}
+
+	return xone.CalculateDues(person.Memberships, all, from, until)
+}
+
+// findAllFees returns the fees of all membership types, grouped by the ID of
+// the membership type and ordered by the date they become valid.
+func findAllFees(ctx context.Context, db dbtx) (map[int][]xone.Fee, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			id,
+			membership_type_id,
+			amount,
+			billing_period,
+			valid_from,
+			valid_until
+		FROM
+			membership_fee
+		ORDER BY
+			membership_type_id,
+			valid_from
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fees := map[int][]xone.Fee{}
+	for rows.Next() {
+		fee, err := scanFee(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		fees[fee.MembershipTypeID] = append(fees[fee.MembershipTypeID], fee)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fees, nil
+}
+
+func findFee(ctx context.Context, db dbtx, id int) (xone.Fee, bool, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		SELECT
+			id,
+			membership_type_id,
+			amount,
+			billing_period,
+			valid_from,
+			valid_until
+		FROM
+			membership_fee
+		WHERE
+			id = ?
+	`)
+	if err != nil {
+		return xone.Fee{}, false, err
+	}
+
+	fee, err := scanFee(stmt.QueryRowContext(ctx, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return xone.Fee{}, false, nil
+		}
+
+		return xone.Fee{}, false, err
+	}
+
+	return fee, true, nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFee(s scanner) (xone.Fee, error) {
+	var fee xone.Fee
+	var validFrom, validUntil string
+	if err := s.Scan(&fee.ID, &fee.MembershipTypeID, &fee.Amount, &fee.Period, &validFrom, &validUntil); err != nil {
+		return xone.Fee{}, err
+	}
+
+	var err error
+	if fee.ValidFrom, err = time.Parse(formatDate, validFrom); err != nil {
+		return xone.Fee{}, err
+	}
+	if fee.ValidUntil, err = parseOptionalDate(validUntil); err != nil {
+		return xone.Fee{}, err
+	}
+
+	return fee, nil
+}
+
+func createFee(ctx context.Context, db dbtx, fee xone.Fee) (int, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		INSERT INTO membership_fee (
+			membership_type_id,
+			amount,
+			billing_period,
+			valid_from,
+			valid_until
+		) VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	validUntil := ""
+	if !fee.ValidUntil.IsZero() {
+		validUntil = fee.ValidUntil.Format(formatDate)
+	}
+
+	res, err := stmt.ExecContext(ctx, fee.MembershipTypeID, fee.Amount, fee.Period, fee.ValidFrom.Format(formatDate), validUntil)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	id, err := res.LastInsertId()
+
+	return int(id), err
+}
+
+func deleteFee(ctx context.Context, db dbtx, id int) error {
+	stmt, err := db.PrepareContext(ctx, `DELETE FROM membership_fee WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, id)
+
+	return mapError(err)
+}
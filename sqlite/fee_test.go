@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFeeService_CalculateDues(t *testing.T) {
+	tests := []struct {
+		name string
+		year int
+		want int64
+	}{
+		{name: "First adult membership", year: 2020, want: 10000},
+		{name: "Youth membership", year: 2021, want: 5000},
+		{name: "Adult membership again", year: 2022, want: 10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mustOpenDB(t)
+			mustMigrateFile(t, db, "testdata/TestFeeService_CalculateDues.sql")
+
+			from := time.Date(tt.year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			until := time.Date(tt.year, time.December, 31, 0, 0, 0, 0, time.UTC)
+			dues, err := NewFeeService(db).CalculateDues(context.Background(), "1", from, until)
+			if err != nil {
+				t.Fatalf("FeeService.CalculateDues() error = %v", err)
+			}
+			if dues.Total != tt.want {
+				t.Errorf("FeeService.CalculateDues() total = %v, want %v", dues.Total, tt.want)
+			}
+		})
+	}
+}
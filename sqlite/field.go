@@ -0,0 +1,360 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.FieldService = (*FieldService)(nil)
+
+type FieldService struct {
+	db  *sql.DB
+	Now func() time.Time
+}
+
+func NewFieldService(db *sql.DB) *FieldService {
+	return &FieldService{
+		db:  db,
+		Now: time.Now,
+	}
+}
+
+func (s *FieldService) FindFieldDefinitions(ctx context.Context) ([]xone.FieldDefinition, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	defs, err := findFieldDefinitions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return defs, tx.Commit()
+}
+
+// CreateFieldDefinition defines a new custom field. Enum fields need at least
+// one option, all other types must not have any.
+func (s *FieldService) CreateFieldDefinition(ctx context.Context, data xone.CreateFieldDefinitionData) (xone.FieldDefinition, error) {
+	data.Name = strings.TrimSpace(data.Name)
+	if data.Name == "" {
+		return xone.FieldDefinition{}, &xone.ErrInvalid{Msg: "name required"}
+	}
+	if !data.Type.Valid() {
+		return xone.FieldDefinition{}, &xone.ErrInvalidFieldType{Type: data.Type}
+	}
+
+	var options []string
+	for _, option := range data.Options {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	if data.Type == xone.FieldEnum && len(options) == 0 {
+		return xone.FieldDefinition{}, &xone.ErrInvalid{Msg: "enum fields require at least one option"}
+	}
+	if data.Type != xone.FieldEnum && len(options) > 0 {
+		return xone.FieldDefinition{}, &xone.ErrInvalid{Msg: "only enum fields have options"}
+	}
+	data.Options = options
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.FieldDefinition{}, err
+	}
+	defer tx.Rollback()
+
+	defs, err := findFieldDefinitions(ctx, tx)
+	if err != nil {
+		return xone.FieldDefinition{}, err
+	}
+	for _, d := range defs {
+		if strings.EqualFold(d.Name, data.Name) {
+			return xone.FieldDefinition{}, &xone.ErrFieldExists{Name: data.Name}
+		}
+	}
+
+	def, err := createFieldDefinition(ctx, tx, data)
+	if err != nil {
+		return xone.FieldDefinition{}, err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditCreate, xone.AuditField, strconv.Itoa(def.ID), nil, def); err != nil {
+		return xone.FieldDefinition{}, err
+	}
+
+	return def, tx.Commit()
+}
+
+func (s *FieldService) DeleteFieldDefinition(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	def, found, err := findFieldDefinition(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "custom field", ID: strconv.Itoa(id)}
+	}
+
+	if err := deleteFieldDefinition(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditDelete, xone.AuditField, strconv.Itoa(id), def, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func findFieldDefinitions(ctx context.Context, tx dbtx) ([]xone.FieldDefinition, error) {
+	return queryFieldDefinitions(ctx, tx, `
+		SELECT
+			id,
+			name,
+			type,
+			required,
+			options
+		FROM
+			custom_field
+		ORDER BY
+			name
+	`)
+}
+
+func findFieldDefinition(ctx context.Context, tx dbtx, id int) (xone.FieldDefinition, bool, error) {
+	defs, err := queryFieldDefinitions(ctx, tx, `
+		SELECT
+			id,
+			name,
+			type,
+			required,
+			options
+		FROM
+			custom_field
+		WHERE
+			id = ?
+	`, id)
+	if err != nil || len(defs) == 0 {
+		return xone.FieldDefinition{}, false, err
+	}
+
+	return defs[0], true, nil
+}
+
+func queryFieldDefinitions(ctx context.Context, tx dbtx, query string, args ...interface{}) ([]xone.FieldDefinition, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var defs []xone.FieldDefinition
+	for rows.Next() {
+		var def xone.FieldDefinition
+		var options string
+		if err := rows.Scan(&def.ID, &def.Name, &def.Type, &def.Required, &options); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(options), &def.Options); err != nil {
+			return nil, err
+		}
+		if len(def.Options) == 0 {
+			def.Options = nil
+		}
+
+		defs = append(defs, def)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+func createFieldDefinition(ctx context.Context, tx dbtx, data xone.CreateFieldDefinitionData) (xone.FieldDefinition, error) {
+	options := data.Options
+	if options == nil {
+		options = []string{}
+	}
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return xone.FieldDefinition{}, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO custom_field (
+			name,
+			type,
+			required,
+			options
+		) VALUES (
+			?,
+			?,
+			?,
+			?
+		)
+	`)
+	if err != nil {
+		return xone.FieldDefinition{}, err
+	}
+
+	result, err := stmt.ExecContext(ctx, data.Name, data.Type, data.Required, string(optionsJSON))
+	if err != nil {
+		return xone.FieldDefinition{}, mapError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return xone.FieldDefinition{}, err
+	}
+
+	return xone.FieldDefinition{
+		ID:       int(id),
+		Name:     data.Name,
+		Type:     data.Type,
+		Required: data.Required,
+		Options:  data.Options,
+	}, nil
+}
+
+func deleteFieldDefinition(ctx context.Context, tx dbtx, id int) error {
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM custom_field WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, id)
+
+	return mapError(err)
+}
+
+// saveFieldValues applies the changes to the custom fields of a person, see
+// xone.ApplyFieldValues, and replaces the stored values with the result.
+func saveFieldValues(ctx context.Context, tx dbtx, p *xone.Person, changes map[string]string) error {
+	defs, err := findFieldDefinitions(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	values, err := xone.ApplyFieldValues(defs, p.CustomFields, changes)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM custom_field_value WHERE person_id = ?`)
+	if err != nil {
+		return err
+	}
+	if _, err := stmt.ExecContext(ctx, p.ID); err != nil {
+		return mapError(err)
+	}
+
+	stmt, err = tx.PrepareContext(ctx, `
+		INSERT INTO custom_field_value (
+			person_id,
+			field_id,
+			value
+		) VALUES (
+			?,
+			?,
+			?
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range defs {
+		if value, ok := values[d.Name]; ok {
+			if _, err := stmt.ExecContext(ctx, p.ID, d.ID, value); err != nil {
+				return mapError(err)
+			}
+		}
+	}
+
+	p.CustomFields = nil
+	if len(values) > 0 {
+		p.CustomFields = values
+	}
+
+	return nil
+}
+
+// fieldValueBatchSize limits the number of persons whose custom fields are
+// loaded with a single query, see membershipBatchSize.
+const fieldValueBatchSize = 500
+
+// attachFieldValues loads the custom fields of all given persons at once.
+func attachFieldValues(ctx context.Context, tx dbtx, persons []xone.Person) error {
+	ids := make([]int, len(persons))
+	for i, p := range persons {
+		ids[i] = p.ID
+	}
+
+	values := map[int]map[string]string{}
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > fieldValueBatchSize {
+			batch = batch[:fieldValueBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT
+				custom_field_value.person_id,
+				custom_field.name,
+				custom_field_value.value
+			FROM
+				custom_field_value
+				JOIN custom_field ON custom_field.id = custom_field_value.field_id
+			WHERE
+				custom_field_value.person_id IN (?`+strings.Repeat(", ?", len(batch)-1)+`)
+		`, args...)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var personID int
+			var name, value string
+			if err := rows.Scan(&personID, &name, &value); err != nil {
+				rows.Close()
+				return err
+			}
+
+			if values[personID] == nil {
+				values[personID] = map[string]string{}
+			}
+			values[personID][name] = value
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return err
+		}
+		rows.Close()
+	}
+
+	for i := range persons {
+		persons[i].CustomFields = values[persons[i].ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+// formatHistoryTimestamp is the layout of the timestamps which are written to
+// the history tables by the triggers, see datetime() in SQLite. They are in
+// UTC.
+const formatHistoryTimestamp = "2006-01-02 15:04:05"
+
+var _ xone.HistoryService = (*HistoryService)(nil)
+
+// HistoryService reads the history tables which are populated by triggers
+// whenever a person, a membership or a membership type is changed.
+type HistoryService struct {
+	db *sql.DB
+}
+
+func NewHistoryService(db *sql.DB) *HistoryService {
+	return &HistoryService{db: db}
+}
+
+func (hs *HistoryService) Timeline(ctx context.Context, pid string) ([]xone.PersonChange, error) {
+	tx, err := hs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	changes, err := findPersonTimeline(ctx, tx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, tx.Commit()
+}
+
+func (hs *HistoryService) PersonAt(ctx context.Context, pid string, t time.Time) (xone.Person, bool, error) {
+	tx, err := hs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Person{}, false, err
+	}
+	defer tx.Rollback()
+
+	person, found, err := findPersonAt(ctx, tx, pid, t)
+	if err != nil {
+		return xone.Person{}, false, err
+	}
+
+	return person, found, tx.Commit()
+}
+
+// personVersion is a single row of the person_history table.
+type personVersion struct {
+	createdAt time.Time
+	person    xone.Person
+}
+
+// membershipVersion is a single row of the membership_history table. The name
+// of the membership type is the one it had when the row was written.
+type membershipVersion struct {
+	createdAt  time.Time
+	membership xone.Membership
+}
+
+func findPersonTimeline(ctx context.Context, tx dbtx, pid string) ([]xone.PersonChange, error) {
+	persons, err := findPersonVersions(ctx, tx, pid, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if len(persons) == 0 {
+		return nil, nil
+	}
+
+	memberships, err := findMembershipVersions(ctx, tx, persons[0].person.ID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []xone.PersonChange
+	for i, v := range persons {
+		change := xone.PersonChange{Time: v.createdAt, Created: i == 0}
+		if i == 0 {
+			change.Fields = xone.DiffPersons(xone.Person{}, v.person)
+		} else {
+			change.Fields = xone.DiffPersons(persons[i-1].person, v.person)
+		}
+
+		// Updates which did not change anything are left out.
+		if change.Created || len(change.Fields) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	previous := map[int]xone.Membership{}
+	for _, v := range memberships {
+		old, ok := previous[v.membership.ID]
+		change := xone.PersonChange{
+			Time:         v.createdAt,
+			MembershipID: v.membership.ID,
+			Created:      !ok,
+			Fields:       xone.DiffMemberships(old, v.membership),
+		}
+		previous[v.membership.ID] = v.membership
+
+		if change.Created || len(change.Fields) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	// Timestamps only have a precision of seconds, so the order of changes
+	// within the same second is kept: the person's own changes come first
+	// since a person is created before their memberships.
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Time.Before(changes[j].Time)
+	})
+
+	return changes, nil
+}
+
+func findPersonAt(ctx context.Context, tx dbtx, pid string, t time.Time) (xone.Person, bool, error) {
+	persons, err := findPersonVersions(ctx, tx, pid, t)
+	if err != nil {
+		return xone.Person{}, false, err
+	}
+	if len(persons) == 0 {
+		return xone.Person{}, false, nil
+	}
+	person := persons[len(persons)-1].person
+
+	memberships, err := findMembershipVersions(ctx, tx, person.ID, t)
+	if err != nil {
+		return xone.Person{}, true, err
+	}
+
+	// The versions are ordered by time, so the last version of every
+	// membership wins. Memberships are ordered by ID like everywhere else.
+	latest := map[int]xone.Membership{}
+	for _, v := range memberships {
+		latest[v.membership.ID] = v.membership
+	}
+	for _, m := range latest {
+		person.Memberships = append(person.Memberships, m)
+	}
+	sort.Slice(person.Memberships, func(i, j int) bool {
+		return person.Memberships[i].ID < person.Memberships[j].ID
+	})
+
+	return person, true, nil
+}
+
+// findPersonVersions returns all versions of a person which were written up
+// to the given point in time, the oldest first. A zero time returns every
+// version.
+func findPersonVersions(ctx context.Context, tx dbtx, pid string, until time.Time) ([]personVersion, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			created_at,
+			person_id,
+			public_id,
+			first_name,
+			last_name,
+			date_of_birth,
+			email,
+			phone,
+			mobile,
+			street,
+			house_number,
+			zip_code,
+			city
+		FROM
+			person_history
+		WHERE
+			public_id = ?
+			AND (? = '' OR created_at <= ?)
+		ORDER BY
+			created_at,
+			id
+	`, pid, formatUntil(until), formatUntil(until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []personVersion
+	for rows.Next() {
+		var v personVersion
+		var createdAt, dobString string
+		p := &v.person
+		if err := rows.Scan(&createdAt, &p.ID, &p.PID, &p.FirstName, &p.LastName, &dobString, &p.Email, &p.Phone, &p.Mobile, &p.Street, &p.HouseNumber, &p.ZipCode, &p.City); err != nil {
+			return nil, err
+		}
+
+		if v.createdAt, err = time.Parse(formatHistoryTimestamp, createdAt); err != nil {
+			return nil, err
+		}
+		if dobString != "" {
+			if p.DateOfBirth, err = parseDateOfBirth(dobString); err != nil {
+				return nil, err
+			}
+		}
+
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// findMembershipVersions returns all versions of the memberships of a person
+// which were written up to the given point in time, the oldest first. A zero
+// time returns every version.
+func findMembershipVersions(ctx context.Context, tx dbtx, personID int, until time.Time) ([]membershipVersion, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			membership_history.created_at,
+			membership_history.membership_id,
+			membership_history.effective_from,
+			membership_history.end_date,
+			membership_history.termination_reason,
+			membership_history.type_id,
+			COALESCE(
+				(
+					SELECT
+						membership_type_history.name
+					FROM
+						membership_type_history
+					WHERE
+						membership_type_history.membership_type_id = membership_history.type_id
+						AND membership_type_history.created_at <= membership_history.created_at
+					ORDER BY
+						membership_type_history.created_at DESC,
+						membership_type_history.id DESC
+					LIMIT 1
+				),
+				(
+					SELECT
+						membership_type.name
+					FROM
+						membership_type
+					WHERE
+						membership_type.id = membership_history.type_id
+				),
+				''
+			)
+		FROM
+			membership_history
+		WHERE
+			membership_history.person_id = ?
+			AND (? = '' OR membership_history.created_at <= ?)
+		ORDER BY
+			membership_history.created_at,
+			membership_history.id
+	`, personID, formatUntil(until), formatUntil(until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []membershipVersion
+	for rows.Next() {
+		var v membershipVersion
+		var createdAt, effectiveFrom, endDate string
+		m := &v.membership
+		if err := rows.Scan(&createdAt, &m.ID, &effectiveFrom, &endDate, &m.TerminationReason, &m.Type.ID, &m.Type.Name); err != nil {
+			return nil, err
+		}
+
+		if m.EndDate, err = parseOptionalDate(endDate); err != nil {
+			return nil, err
+		}
+
+		if v.createdAt, err = time.Parse(formatHistoryTimestamp, createdAt); err != nil {
+			return nil, err
+		}
+		if effectiveFrom != "" {
+			if m.EffectiveFrom, err = time.Parse(formatDate, effectiveFrom); err != nil {
+				return nil, err
+			}
+		}
+
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// formatUntil formats the upper bound of a history query. The zero time is
+// formatted as an empty string which means there is no bound.
+func formatUntil(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(formatHistoryTimestamp)
+}
@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+func Test_findPersonTimeline(t *testing.T) {
+	db := mustOpenDB(t)
+	mustMigrateFile(t, db, "testdata/Test_history.sql")
+
+	got, err := findPersonTimeline(context.Background(), db, "1")
+	if err != nil {
+		t.Fatalf("findPersonTimeline() error = %v", err)
+	}
+
+	want := []xone.PersonChange{
+		{
+			Time:    time.Date(2020, time.January, 1, 10, 0, 0, 0, time.UTC),
+			Created: true,
+			Fields: []xone.FieldChange{
+				{Field: "first_name", New: "Harry"},
+				{Field: "last_name", New: "Potter"},
+				{Field: "date_of_birth", New: "1980-07-31"},
+				{Field: "city", New: "Little Whinging"},
+			},
+		},
+		{
+			Time:         time.Date(2020, time.January, 1, 10, 0, 0, 0, time.UTC),
+			MembershipID: 1,
+			Created:      true,
+			Fields: []xone.FieldChange{
+				{Field: "membership_type", New: "active"},
+				{Field: "effective_from", New: "1991-09-01"},
+			},
+		},
+		{
+			Time: time.Date(2020, time.February, 1, 10, 0, 0, 0, time.UTC),
+			Fields: []xone.FieldChange{
+				{Field: "email", New: "harry.potter@hogwarts.co.uk"},
+			},
+		},
+		{
+			Time: time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC),
+			Fields: []xone.FieldChange{
+				{Field: "city", Old: "Little Whinging", New: "London"},
+			},
+		},
+		{
+			Time:         time.Date(2020, time.April, 1, 10, 0, 0, 0, time.UTC),
+			MembershipID: 1,
+			Fields: []xone.FieldChange{
+				{Field: "membership_type", Old: "active", New: "passive"},
+				{Field: "effective_from", Old: "1991-09-01", New: "1998-05-02"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findPersonTimeline() = %+v, want %+v", got, want)
+	}
+
+	if got, err := findPersonTimeline(context.Background(), db, "unknown"); err != nil || got != nil {
+		t.Errorf("findPersonTimeline() = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func Test_findPersonAt(t *testing.T) {
+	harry := xone.Person{
+		ID:          1,
+		PID:         "1",
+		FirstName:   "Harry",
+		LastName:    "Potter",
+		DateOfBirth: time.Date(1980, time.July, 31, 0, 0, 0, 0, time.UTC),
+		Email:       "harry.potter@hogwarts.co.uk",
+		City:        "Little Whinging",
+		Memberships: []xone.Membership{
+			{
+				ID:            1,
+				Type:          xone.MembershipType{ID: 1, Name: "active"},
+				EffectiveFrom: time.Date(1991, time.September, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	// The time is converted to UTC, so this is before the email address was
+	// added at 10:00 UTC.
+	beforeEmail := harry
+	beforeEmail.Email = ""
+
+	current := harry
+	current.City = "London"
+	current.Memberships = []xone.Membership{
+		{
+			ID:            1,
+			Type:          xone.MembershipType{ID: 2, Name: "passive"},
+			EffectiveFrom: time.Date(1998, time.May, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		name  string
+		pid   string
+		t     time.Time
+		want  xone.Person
+		found bool
+	}{
+		{
+			name: "Before creation",
+			pid:  "1",
+			t:    time.Date(2019, time.December, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "After first changes",
+			pid:   "1",
+			t:     time.Date(2020, time.February, 15, 0, 0, 0, 0, time.UTC),
+			want:  harry,
+			found: true,
+		},
+		{
+			name:  "Time zones",
+			pid:   "1",
+			t:     time.Date(2020, time.February, 1, 10, 30, 0, 0, time.FixedZone("CET", 3600)),
+			want:  beforeEmail,
+			found: true,
+		},
+		{
+			name:  "Now",
+			pid:   "1",
+			t:     time.Now(),
+			want:  current,
+			found: true,
+		},
+		{
+			name: "Unknown person",
+			pid:  "unknown",
+			t:    time.Now(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mustOpenDB(t)
+			mustMigrateFile(t, db, "testdata/Test_history.sql")
+
+			got, found, err := findPersonAt(context.Background(), db, tt.pid, tt.t)
+			if err != nil {
+				t.Fatalf("findPersonAt() error = %v", err)
+			}
+			if found != tt.found {
+				t.Errorf("findPersonAt() found = %v, want %v", found, tt.found)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findPersonAt() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,513 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.HouseholdService = (*HouseholdService)(nil)
+
+type HouseholdService struct {
+	db  *sql.DB
+	Now func() time.Time
+}
+
+func NewHouseholdService(db *sql.DB) *HouseholdService {
+	return &HouseholdService{
+		db:  db,
+		Now: time.Now,
+	}
+}
+
+func (s *HouseholdService) FindHouseholds(ctx context.Context) ([]xone.Household, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	households, err := queryHouseholds(ctx, tx, `
+		SELECT `+householdColumns+`
+		FROM
+			household
+			LEFT JOIN person ON person.id = household.primary_contact_id AND person.deleted_at = ''
+		ORDER BY
+			household.name,
+			household.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return households, tx.Commit()
+}
+
+func (s *HouseholdService) FindHousehold(ctx context.Context, id int) (xone.Household, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Household{}, false, err
+	}
+	defer tx.Rollback()
+
+	household, found, err := findHousehold(ctx, tx, id)
+	if err != nil {
+		return xone.Household{}, false, err
+	}
+
+	return household, found, tx.Commit()
+}
+
+func (s *HouseholdService) FindHouseholdOfPerson(ctx context.Context, pid string) (xone.Household, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Household{}, false, err
+	}
+	defer tx.Rollback()
+
+	person, found, err := findPerson(ctx, tx, pid)
+	if err != nil || !found {
+		return xone.Household{}, false, err
+	}
+
+	id, found, err := findHouseholdIDOfPerson(ctx, tx, person.ID)
+	if err != nil || !found {
+		return xone.Household{}, false, err
+	}
+
+	household, found, err := findHousehold(ctx, tx, id)
+	if err != nil {
+		return xone.Household{}, false, err
+	}
+
+	return household, found, tx.Commit()
+}
+
+// CreateHousehold creates a household. Without a name, the household is named
+// after the last name of its primary contact.
+func (s *HouseholdService) CreateHousehold(ctx context.Context, data xone.CreateHouseholdData) (xone.Household, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Household{}, err
+	}
+	defer tx.Rollback()
+
+	contact, found, err := findPerson(ctx, tx, data.PrimaryContact)
+	if err != nil {
+		return xone.Household{}, err
+	} else if !found {
+		return xone.Household{}, &xone.ErrInvalid{Msg: "primary contact not found"}
+	}
+
+	if other, found, err := findHouseholdIDOfPerson(ctx, tx, contact.ID); err != nil {
+		return xone.Household{}, err
+	} else if found {
+		return xone.Household{}, &xone.ErrHouseholdMember{PID: contact.PID, HouseholdID: other}
+	}
+
+	household := xone.Household{
+		Name:        strings.TrimSpace(data.Name),
+		Street:      data.Street,
+		HouseNumber: data.HouseNumber,
+		ZipCode:     data.ZipCode,
+		City:        data.City,
+	}
+	if household.Name == "" {
+		household.Name = contact.LastName
+	}
+	if !household.HasAddress() {
+		household.Street = contact.Street
+		household.HouseNumber = contact.HouseNumber
+		household.ZipCode = contact.ZipCode
+		household.City = contact.City
+	}
+
+	id, err := createHousehold(ctx, tx, household, contact.ID)
+	if err != nil {
+		return xone.Household{}, err
+	}
+
+	if err := addHouseholdMember(ctx, tx, id, contact.ID); err != nil {
+		return xone.Household{}, err
+	}
+
+	after, _, err := findHousehold(ctx, tx, id)
+	if err != nil {
+		return xone.Household{}, err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditCreate, xone.AuditHousehold, strconv.Itoa(id), nil, after); err != nil {
+		return xone.Household{}, err
+	}
+
+	return after, tx.Commit()
+}
+
+// UpdateHousehold updates a household. The primary contact has to be one of
+// its members. Households whose primary contact has been archived may keep
+// it, so that it is back once the person is restored. If the address is
+// propagated, every member whose address differs is updated and the change is
+// recorded in their audit log.
+func (s *HouseholdService) UpdateHousehold(ctx context.Context, id int, data xone.UpdateHouseholdData) error {
+	if strings.TrimSpace(data.Name) == "" {
+		return &xone.ErrInvalid{Msg: "household name required"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findHousehold(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "household", ID: strconv.Itoa(id)}
+	}
+
+	now := s.Now()
+
+	var contactID sql.NullInt64
+	if data.PrimaryContact != "" {
+		var contact *xone.Person
+		for i := range before.Members {
+			if before.Members[i].PID == data.PrimaryContact {
+				contact = &before.Members[i]
+			}
+		}
+		if contact == nil {
+			return &xone.ErrNotHouseholdMember{PID: data.PrimaryContact, HouseholdID: id}
+		}
+		contactID = sql.NullInt64{Int64: int64(contact.ID), Valid: true}
+	} else if before.PrimaryContact != "" && len(before.Members) > 0 {
+		return &xone.ErrInvalid{Msg: "primary contact required"}
+	} else if contactID, err = findHouseholdContactID(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := updateHousehold(ctx, tx, id, data, contactID); err != nil {
+		return err
+	}
+
+	if data.PropagateAddress {
+		for _, member := range before.Members {
+			if member.Street == data.Street && member.HouseNumber == data.HouseNumber && member.ZipCode == data.ZipCode && member.City == data.City {
+				continue
+			}
+
+			upd := member.ToUpdateData()
+			upd.Street = data.Street
+			upd.HouseNumber = data.HouseNumber
+			upd.ZipCode = data.ZipCode
+			upd.City = data.City
+
+			if err := updatePerson(ctx, tx, member.PID, upd); err != nil {
+				return err
+			}
+
+			updated, _, err := findPerson(ctx, tx, member.PID)
+			if err != nil {
+				return err
+			}
+
+			if err := createAuditEntry(ctx, tx, now, xone.AuditUpdate, xone.AuditPerson, member.PID, member, updated); err != nil {
+				return err
+			}
+		}
+	}
+
+	after, _, err := findHousehold(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, now, xone.AuditUpdate, xone.AuditHousehold, strconv.Itoa(id), before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *HouseholdService) DeleteHousehold(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findHousehold(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "household", ID: strconv.Itoa(id)}
+	}
+
+	if err := deleteHousehold(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditDelete, xone.AuditHousehold, strconv.Itoa(id), before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *HouseholdService) AddHouseholdMember(ctx context.Context, id int, pid string) error {
+	return s.changeMembers(ctx, id, pid, func(tx dbtx, household xone.Household, person xone.Person) error {
+		if other, found, err := findHouseholdIDOfPerson(ctx, tx, person.ID); err != nil {
+			return err
+		} else if found {
+			return &xone.ErrHouseholdMember{PID: pid, HouseholdID: other}
+		}
+
+		return addHouseholdMember(ctx, tx, id, person.ID)
+	})
+}
+
+func (s *HouseholdService) RemoveHouseholdMember(ctx context.Context, id int, pid string) error {
+	return s.changeMembers(ctx, id, pid, func(tx dbtx, household xone.Household, person xone.Person) error {
+		if !household.IsMember(pid) {
+			return &xone.ErrNotHouseholdMember{PID: pid, HouseholdID: id}
+		}
+		if household.PrimaryContact == pid {
+			return &xone.ErrPrimaryContact{PID: pid}
+		}
+
+		return removeHouseholdMember(ctx, tx, id, person.ID)
+	})
+}
+
+// changeMembers looks up the household and the person, applies the change and
+// records it in the audit log of the household.
+func (s *HouseholdService) changeMembers(ctx context.Context, id int, pid string, change func(dbtx, xone.Household, xone.Person) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findHousehold(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "household", ID: strconv.Itoa(id)}
+	}
+
+	person, found, err := findPerson(ctx, tx, pid)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "person", ID: pid}
+	}
+
+	if err := change(tx, before, person); err != nil {
+		return err
+	}
+
+	after, _, err := findHousehold(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditUpdate, xone.AuditHousehold, strconv.Itoa(id), before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const householdColumns = `
+	household.id,
+	household.name,
+	household.street,
+	household.house_number,
+	household.zip_code,
+	household.city,
+	COALESCE(person.public_id, '')
+`
+
+func findHousehold(ctx context.Context, tx dbtx, id int) (xone.Household, bool, error) {
+	households, err := queryHouseholds(ctx, tx, `
+		SELECT `+householdColumns+`
+		FROM
+			household
+			LEFT JOIN person ON person.id = household.primary_contact_id AND person.deleted_at = ''
+		WHERE
+			household.id = ?
+	`, id)
+	if err != nil {
+		return xone.Household{}, false, err
+	} else if len(households) == 0 {
+		return xone.Household{}, false, nil
+	}
+
+	return households[0], true, nil
+}
+
+// findHouseholdContactID returns the internal ID of the primary contact of a
+// household, even if the person has been archived.
+func findHouseholdContactID(ctx context.Context, tx dbtx, id int) (sql.NullInt64, error) {
+	var contactID sql.NullInt64
+	err := tx.QueryRowContext(ctx, `SELECT primary_contact_id FROM household WHERE id = ?`, id).Scan(&contactID)
+
+	return contactID, err
+}
+
+func findHouseholdIDOfPerson(ctx context.Context, tx dbtx, personID int) (int, bool, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT household_id FROM household_member WHERE person_id = ?`, personID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	return id, true, nil
+}
+
+func queryHouseholds(ctx context.Context, tx dbtx, query string, args ...interface{}) ([]xone.Household, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var households []xone.Household
+	for rows.Next() {
+		var h xone.Household
+		if err := rows.Scan(&h.ID, &h.Name, &h.Street, &h.HouseNumber, &h.ZipCode, &h.City, &h.PrimaryContact); err != nil {
+			return nil, err
+		}
+
+		households = append(households, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range households {
+		if households[i].Members, err = findHouseholdMembers(ctx, tx, households[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return households, nil
+}
+
+func findHouseholdMembers(ctx context.Context, tx dbtx, householdID int) ([]xone.Person, error) {
+	return queryPersons(ctx, tx, `
+		SELECT
+			person.id,
+			person.public_id,
+			person.first_name,
+			person.last_name,
+			person.date_of_birth,
+			person.email,
+			person.phone,
+			person.mobile,
+			person.street,
+			person.house_number,
+			person.zip_code,
+			person.city,
+			person.deleted_at,
+			person.version
+		FROM
+			person
+			JOIN household_member ON household_member.person_id = person.id
+		WHERE
+			household_member.household_id = ?
+			AND person.deleted_at = ''
+		ORDER BY
+			person.last_name,
+			person.first_name
+	`, householdID)
+}
+
+func createHousehold(ctx context.Context, tx dbtx, h xone.Household, primaryContactID int) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO household (
+			name,
+			street,
+			house_number,
+			zip_code,
+			city,
+			primary_contact_id
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := stmt.ExecContext(ctx, h.Name, h.Street, h.HouseNumber, h.ZipCode, h.City, primaryContactID)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	id, err := res.LastInsertId()
+
+	return int(id), err
+}
+
+func updateHousehold(ctx context.Context, tx dbtx, id int, data xone.UpdateHouseholdData, primaryContactID sql.NullInt64) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			household
+		SET
+			name = ?,
+			street = ?,
+			house_number = ?,
+			zip_code = ?,
+			city = ?,
+			primary_contact_id = ?
+		WHERE
+			id = ?
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, strings.TrimSpace(data.Name), data.Street, data.HouseNumber, data.ZipCode, data.City, primaryContactID, id)
+
+	return mapError(err)
+}
+
+func deleteHousehold(ctx context.Context, tx dbtx, id int) error {
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM household WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, id)
+
+	return mapError(err)
+}
+
+func addHouseholdMember(ctx context.Context, tx dbtx, householdID, personID int) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO household_member (household_id, person_id) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, householdID, personID)
+
+	return mapError(err)
+}
+
+func removeHouseholdMember(ctx context.Context, tx dbtx, householdID, personID int) error {
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM household_member WHERE household_id = ? AND person_id = ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, householdID, personID)
+
+	return mapError(err)
+}
@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+// Import creates all given persons in a single transaction. Every person is
+// validated, their membership type is resolved by name and they are checked
+// against existing persons as well as against the other persons in the list.
+// If any person cannot be imported, nothing is stored and the report lists the
+// errors of every affected person. The returned error is only set if the
+// import could not be carried out at all.
+func (ps *PersonService) Import(ctx context.Context, persons []xone.Person, opts xone.ImportOptions) (xone.ImportReport, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.ImportReport{}, err
+	}
+	defer tx.Rollback()
+
+	membershipTypes, err := findAllMembershipTypes(ctx, tx)
+	if err != nil {
+		return xone.ImportReport{}, err
+	}
+
+	existing, err := findPersonKeys(ctx, tx)
+	if err != nil {
+		return xone.ImportReport{}, err
+	}
+
+	now := ps.Now()
+	report := xone.ImportReport{}
+	for i, p := range persons {
+		row := xone.ImportRow{Index: i, Person: p}
+
+		data, errs := importData(p, membershipTypes, opts)
+		if key := newPersonKey(p.FirstName, p.LastName, p.DateOfBirth); existing[key] {
+			errs = append(errs, &xone.ErrDuplicatePerson{FirstName: p.FirstName, LastName: p.LastName, DateOfBirth: p.DateOfBirth})
+		} else {
+			existing[key] = true
+		}
+
+		if len(errs) == 0 {
+			person, err := createPersonWithMembership(ctx, tx, ps.GenerateID(), data)
+			if err == nil {
+				err = createAuditEntry(ctx, tx, now, xone.AuditCreate, xone.AuditPerson, person.PID, nil, person)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				row.Person = person
+			}
+		}
+
+		row.Errors = errs
+		report.Rows = append(report.Rows, row)
+	}
+
+	if opts.DryRun || report.Failed() {
+		return report, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return xone.ImportReport{}, err
+	}
+	report.Committed = true
+
+	return report, nil
+}
+
+// importData validates a person who is about to be imported and converts them
+// into the data needed to create them.
+func importData(p xone.Person, membershipTypes []xone.MembershipType, opts xone.ImportOptions) (xone.CreatePersonData, []error) {
+	var errs []error
+
+	typeName := opts.DefaultMembershipType
+	effectiveFrom := opts.DefaultEffectiveFrom
+	if len(p.Memberships) > 0 {
+		if p.Memberships[0].Type.Name != "" {
+			typeName = p.Memberships[0].Type.Name
+		}
+		if !p.Memberships[0].EffectiveFrom.IsZero() {
+			effectiveFrom = p.Memberships[0].EffectiveFrom
+		}
+	}
+
+	var membershipTypeID int
+	if typeName != "" {
+		if mt, ok := lookupMembershipType(membershipTypes, typeName); !ok {
+			errs = append(errs, &xone.ErrUnknownMembershipType{Name: typeName})
+		} else {
+			membershipTypeID = mt.ID
+		}
+	}
+
+	data := xone.CreatePersonData{
+		FirstName:        p.FirstName,
+		LastName:         p.LastName,
+		DateOfBirth:      p.DateOfBirth,
+		Email:            p.Email,
+		Phone:            p.Phone,
+		Mobile:           p.Mobile,
+		Street:           p.Street,
+		HouseNumber:      p.HouseNumber,
+		ZipCode:          p.ZipCode,
+		City:             p.City,
+		MembershipTypeID: membershipTypeID,
+		EffectiveFrom:    effectiveFrom,
+		CustomFields:     p.CustomFields,
+	}
+
+	var invalid *xone.ValidationError
+	if err := data.Validate(); errors.As(err, &invalid) {
+		// An unknown membership type has already been reported by its name.
+		var fieldErrs []xone.FieldError
+		for _, fe := range invalid.Errors {
+			if fe.Field != "membership_type" || len(errs) == 0 {
+				fieldErrs = append(fieldErrs, fe)
+			}
+		}
+		if len(fieldErrs) > 0 {
+			errs = append(errs, &xone.ValidationError{Errors: fieldErrs})
+		}
+	}
+
+	return data, errs
+}
+
+func lookupMembershipType(membershipTypes []xone.MembershipType, name string) (xone.MembershipType, bool) {
+	for _, mt := range membershipTypes {
+		if strings.EqualFold(mt.Name, strings.TrimSpace(name)) {
+			return mt, true
+		}
+	}
+
+	return xone.MembershipType{}, false
+}
+
+// personKey identifies a person for the purpose of duplicate detection. Two
+// persons are considered the same if their names match case-insensitively and
+// they share the same date of birth.
+type personKey struct {
+	firstName   string
+	lastName    string
+	dateOfBirth string
+}
+
+func newPersonKey(firstName, lastName string, dob time.Time) personKey {
+	key := personKey{
+		firstName: strings.ToLower(strings.TrimSpace(firstName)),
+		lastName:  strings.ToLower(strings.TrimSpace(lastName)),
+	}
+	if !dob.IsZero() {
+		key.dateOfBirth = dob.Format(xone.FormatDateOfBirth)
+	}
+
+	return key
+}
+
+// findPersonKeys returns the keys of all persons who have not been deleted.
+func findPersonKeys(ctx context.Context, db dbtx) (map[personKey]bool, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			first_name,
+			last_name,
+			date_of_birth
+		FROM
+			person
+		WHERE
+			deleted_at = ''
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := map[personKey]bool{}
+	for rows.Next() {
+		var firstName, lastName, dobString string
+		if err := rows.Scan(&firstName, &lastName, &dobString); err != nil {
+			return nil, err
+		}
+
+		var dob time.Time
+		if dobString != "" {
+			if dob, err = parseDateOfBirth(dobString); err != nil {
+				return nil, err
+			}
+		}
+
+		keys[newPersonKey(firstName, lastName, dob)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
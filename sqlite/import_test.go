@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+func TestPersonService_Import(t *testing.T) {
+	draco := xone.Person{
+		FirstName:   "Draco",
+		LastName:    "Malfoy",
+		DateOfBirth: time.Date(1980, time.June, 5, 0, 0, 0, 0, time.UTC),
+		Memberships: []xone.Membership{{Type: xone.MembershipType{Name: "Passive"}}},
+	}
+	luna := xone.Person{
+		FirstName: "Luna",
+		LastName:  "Lovegood",
+	}
+
+	tests := []struct {
+		name          string
+		persons       []xone.Person
+		opts          xone.ImportOptions
+		wantErrors    []int
+		wantCommitted bool
+		wantPersons   int
+	}{
+		{
+			name:          "All persons valid",
+			persons:       []xone.Person{draco, luna},
+			opts:          xone.ImportOptions{DefaultMembershipType: "active"},
+			wantErrors:    []int{0, 0},
+			wantCommitted: true,
+			wantPersons:   5,
+		},
+		{
+			name:          "Dry run",
+			persons:       []xone.Person{draco, luna},
+			opts:          xone.ImportOptions{DefaultMembershipType: "active", DryRun: true},
+			wantErrors:    []int{0, 0},
+			wantCommitted: false,
+			wantPersons:   3,
+		},
+		{
+			name:          "Missing membership type",
+			persons:       []xone.Person{draco, luna},
+			opts:          xone.ImportOptions{},
+			wantErrors:    []int{0, 1},
+			wantCommitted: false,
+			wantPersons:   3,
+		},
+		{
+			name: "Unknown membership type and missing name",
+			persons: []xone.Person{
+				{FirstName: "Luna", Memberships: []xone.Membership{{Type: xone.MembershipType{Name: "honorary"}}}},
+			},
+			opts:          xone.ImportOptions{},
+			wantErrors:    []int{2},
+			wantCommitted: false,
+			wantPersons:   3,
+		},
+		{
+			name: "Duplicates",
+			persons: []xone.Person{
+				{FirstName: "harry", LastName: "POTTER", DateOfBirth: time.Date(1980, time.July, 31, 0, 0, 0, 0, time.UTC)},
+				luna,
+				luna,
+			},
+			opts:          xone.ImportOptions{DefaultMembershipType: "active"},
+			wantErrors:    []int{1, 0, 1},
+			wantCommitted: false,
+			wantPersons:   3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mustOpenDB(t)
+			mustMigrateFile(t, db, "testdata/Test_findMembershipsByPerson.sql")
+
+			ps := NewPersonService(db)
+			report, err := ps.Import(context.Background(), tt.persons, tt.opts)
+			if err != nil {
+				t.Fatalf("PersonService.Import() error = %v", err)
+			}
+
+			if report.Committed != tt.wantCommitted {
+				t.Errorf("PersonService.Import() Committed = %v, want %v", report.Committed, tt.wantCommitted)
+			}
+			if len(report.Rows) != len(tt.wantErrors) {
+				t.Fatalf("PersonService.Import() returned %d rows, want %d", len(report.Rows), len(tt.wantErrors))
+			}
+			for i, row := range report.Rows {
+				if len(row.Errors) != tt.wantErrors[i] {
+					t.Errorf("PersonService.Import() row %d errors = %v, want %d errors", i, row.Errors, tt.wantErrors[i])
+				}
+			}
+
+			persons, err := ps.FindAll(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(persons) != tt.wantPersons {
+				t.Errorf("PersonService.FindAll() returned %d persons, want %d", len(persons), tt.wantPersons)
+			}
+		})
+	}
+}
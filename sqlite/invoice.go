@@ -0,0 +1,308 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.InvoiceService = (*InvoiceService)(nil)
+
+type InvoiceService struct {
+	db  *sql.DB
+	Now func() time.Time
+}
+
+func NewInvoiceService(db *sql.DB) *InvoiceService {
+	return &InvoiceService{
+		db:  db,
+		Now: time.Now,
+	}
+}
+
+func (s *InvoiceService) FindInvoices(ctx context.Context, pid string) ([]xone.Invoice, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invoices, err := queryInvoices(ctx, tx, `
+		SELECT `+invoiceColumns+`
+		FROM
+			invoice
+			JOIN person ON person.id = invoice.person_id
+		WHERE
+			person.public_id = ?
+		ORDER BY
+			invoice.issued_on DESC,
+			invoice.id DESC
+	`, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoices, tx.Commit()
+}
+
+func (s *InvoiceService) FindInvoice(ctx context.Context, number string) (xone.Invoice, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Invoice{}, false, err
+	}
+	defer tx.Rollback()
+
+	invoice, found, err := findInvoice(ctx, tx, number)
+	if err != nil {
+		return xone.Invoice{}, false, err
+	}
+
+	return invoice, found, tx.Commit()
+}
+
+// CreateInvoice issues a document to a person. The number is drawn within the
+// same transaction as the document is stored, so a failed attempt does not
+// use up a number.
+func (s *InvoiceService) CreateInvoice(ctx context.Context, data xone.CreateInvoiceData) (xone.Invoice, error) {
+	if !data.Kind.Valid() {
+		return xone.Invoice{}, &xone.ErrInvalidInvoiceKind{Kind: data.Kind}
+	}
+	if len(data.Items) == 0 {
+		return xone.Invoice{}, &xone.ErrInvalid{Msg: "at least one item required"}
+	}
+	for _, item := range data.Items {
+		if strings.TrimSpace(item.Description) == "" {
+			return xone.Invoice{}, &xone.ErrInvalid{Msg: "item description required"}
+		}
+		if item.Amount <= 0 {
+			return xone.Invoice{}, &xone.ErrInvalid{Msg: "item amount must be positive"}
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	person, found, err := findPerson(ctx, tx, data.PID)
+	if err != nil {
+		return xone.Invoice{}, err
+	} else if !found {
+		return xone.Invoice{}, &xone.ErrNotFound{Entity: "person", ID: data.PID}
+	}
+
+	now := s.Now().UTC().Truncate(time.Second)
+	invoice := xone.Invoice{
+		Kind:      data.Kind,
+		PID:       person.PID,
+		IssuedOn:  data.IssuedOn,
+		Recipient: xone.NewInvoiceRecipient(person),
+		Items:     data.Items,
+		CreatedAt: now,
+	}
+	if invoice.IssuedOn.IsZero() {
+		invoice.IssuedOn = now
+	}
+	y, m, d := invoice.IssuedOn.Date()
+	invoice.IssuedOn = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	if membership := person.Membership(invoice.IssuedOn); membership != nil {
+		invoice.MembershipType = membership.Type.Name
+	}
+
+	n, err := nextInvoiceNumber(ctx, tx, invoice.Kind, y)
+	if err != nil {
+		return xone.Invoice{}, err
+	}
+	invoice.Number = xone.FormatInvoiceNumber(invoice.Kind, y, n)
+
+	if invoice.ID, err = createInvoice(ctx, tx, person.ID, invoice); err != nil {
+		return xone.Invoice{}, err
+	}
+
+	if err := createAuditEntry(ctx, tx, now, xone.AuditCreate, xone.AuditInvoice, invoice.Number, nil, invoice); err != nil {
+		return xone.Invoice{}, err
+	}
+
+	return invoice, tx.Commit()
+}
+
+const invoiceColumns = `
+	invoice.id,
+	invoice.number,
+	invoice.kind,
+	COALESCE(person.public_id, ''),
+	invoice.issued_on,
+	invoice.recipient_name,
+	invoice.street,
+	invoice.house_number,
+	invoice.zip_code,
+	invoice.city,
+	invoice.membership_type,
+	invoice.created_at
+`
+
+func findInvoice(ctx context.Context, tx dbtx, number string) (xone.Invoice, bool, error) {
+	invoices, err := queryInvoices(ctx, tx, `
+		SELECT `+invoiceColumns+`
+		FROM
+			invoice
+			LEFT JOIN person ON person.id = invoice.person_id
+		WHERE
+			invoice.number = ?
+	`, number)
+	if err != nil {
+		return xone.Invoice{}, false, err
+	} else if len(invoices) == 0 {
+		return xone.Invoice{}, false, nil
+	}
+
+	return invoices[0], true, nil
+}
+
+func queryInvoices(ctx context.Context, tx dbtx, query string, args ...interface{}) ([]xone.Invoice, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []xone.Invoice
+	for rows.Next() {
+		var i xone.Invoice
+		var issuedOn, createdAt string
+		r := &i.Recipient
+		if err := rows.Scan(&i.ID, &i.Number, &i.Kind, &i.PID, &issuedOn, &r.Name, &r.Street, &r.HouseNumber, &r.ZipCode, &r.City, &i.MembershipType, &createdAt); err != nil {
+			return nil, err
+		}
+
+		if i.IssuedOn, err = time.Parse(formatDate, issuedOn); err != nil {
+			return nil, err
+		}
+		if i.CreatedAt, err = time.Parse(formatTimestamp, createdAt); err != nil {
+			return nil, err
+		}
+
+		invoices = append(invoices, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range invoices {
+		if invoices[i].Items, err = findInvoiceItems(ctx, tx, invoices[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return invoices, nil
+}
+
+func findInvoiceItems(ctx context.Context, tx dbtx, invoiceID int) ([]xone.InvoiceItem, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			description,
+			amount
+		FROM
+			invoice_item
+		WHERE
+			invoice_id = ?
+		ORDER BY
+			position
+	`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []xone.InvoiceItem
+	for rows.Next() {
+		var item xone.InvoiceItem
+		if err := rows.Scan(&item.Description, &item.Amount); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// nextInvoiceNumber increments the sequence of the given kind and year and
+// returns the new value.
+func nextInvoiceNumber(ctx context.Context, tx dbtx, kind xone.InvoiceKind, year int) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO invoice_sequence (kind, year, last_number) VALUES (?, ?, 1)
+		ON CONFLICT (kind, year) DO UPDATE SET last_number = last_number + 1
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := stmt.ExecContext(ctx, kind, year); err != nil {
+		return 0, err
+	}
+
+	var n int
+	err = tx.QueryRowContext(ctx, `SELECT last_number FROM invoice_sequence WHERE kind = ? AND year = ?`, kind, year).Scan(&n)
+
+	return n, err
+}
+
+func createInvoice(ctx context.Context, tx dbtx, personID int, i xone.Invoice) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO invoice (
+			number,
+			kind,
+			person_id,
+			issued_on,
+			recipient_name,
+			street,
+			house_number,
+			zip_code,
+			city,
+			membership_type,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	r := i.Recipient
+	res, err := stmt.ExecContext(ctx, i.Number, i.Kind, personID, i.IssuedOn.Format(formatDate), r.Name, r.Street, r.HouseNumber, r.ZipCode, r.City, i.MembershipType, i.CreatedAt.Format(formatTimestamp))
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	itemStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO invoice_item (
+			invoice_id,
+			position,
+			description,
+			amount
+		) VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	for pos, item := range i.Items {
+		if _, err := itemStmt.ExecContext(ctx, id, pos+1, item.Description, item.Amount); err != nil {
+			return 0, fmt.Errorf("item %d: %w", pos+1, mapError(err))
+		}
+	}
+
+	return int(id), nil
+}
@@ -0,0 +1,380 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.MandateService = (*MandateService)(nil)
+
+type MandateService struct {
+	db  *sql.DB
+	Now func() time.Time
+}
+
+func NewMandateService(db *sql.DB) *MandateService {
+	return &MandateService{
+		db:  db,
+		Now: time.Now,
+	}
+}
+
+func (s *MandateService) FindMandate(ctx context.Context, pid string) (xone.Mandate, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Mandate{}, false, err
+	}
+	defer tx.Rollback()
+
+	mandate, found, err := findMandate(ctx, tx, pid)
+	if err != nil {
+		return xone.Mandate{}, false, err
+	}
+
+	return mandate, found, tx.Commit()
+}
+
+// SaveMandate creates or replaces the mandate of a person. If the IBAN or the
+// mandate reference of an existing mandate change, the next direct debit is
+// treated as the first one again.
+func (s *MandateService) SaveMandate(ctx context.Context, pid string, data xone.SaveMandateData) (xone.Mandate, error) {
+	data.IBAN = xone.NormalizeIBAN(data.IBAN)
+	data.BIC = strings.ToUpper(strings.TrimSpace(data.BIC))
+	data.Reference = strings.TrimSpace(data.Reference)
+
+	if !xone.ValidIBAN(data.IBAN) {
+		return xone.Mandate{}, &xone.ErrInvalidIBAN{IBAN: data.IBAN}
+	}
+	if data.BIC != "" && !xone.ValidBIC(data.BIC) {
+		return xone.Mandate{}, &xone.ErrInvalid{Msg: fmt.Sprintf(`"%s" is not a valid BIC`, data.BIC)}
+	}
+	if !xone.ValidMandateReference(data.Reference) {
+		return xone.Mandate{}, &xone.ErrInvalid{Msg: fmt.Sprintf(`"%s" is not a valid mandate reference`, data.Reference)}
+	}
+	if data.SignedOn.IsZero() {
+		return xone.Mandate{}, &xone.ErrInvalid{Msg: "signature date required"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Mandate{}, err
+	}
+	defer tx.Rollback()
+
+	person, found, err := findPerson(ctx, tx, pid)
+	if err != nil {
+		return xone.Mandate{}, err
+	} else if !found {
+		return xone.Mandate{}, &xone.ErrNotFound{Entity: "person", ID: pid}
+	}
+
+	if other, found, err := findMandateByReference(ctx, tx, data.Reference); err != nil {
+		return xone.Mandate{}, err
+	} else if found && other.PID != pid {
+		return xone.Mandate{}, &xone.ErrConflict{Msg: fmt.Sprintf(`mandate reference "%s" is already in use`, data.Reference)}
+	}
+
+	before, found, err := findMandate(ctx, tx, pid)
+	if err != nil {
+		return xone.Mandate{}, err
+	}
+
+	op := xone.AuditCreate
+	if found {
+		op = xone.AuditUpdate
+		lastCollectedOn := before.LastCollectedOn
+		if before.IBAN != data.IBAN || before.Reference != data.Reference {
+			lastCollectedOn = time.Time{}
+		}
+		err = updateMandate(ctx, tx, before.ID, data, lastCollectedOn)
+	} else {
+		err = createMandate(ctx, tx, person.ID, data)
+	}
+	if err != nil {
+		return xone.Mandate{}, err
+	}
+
+	after, _, err := findMandate(ctx, tx, pid)
+	if err != nil {
+		return xone.Mandate{}, err
+	}
+
+	var auditBefore interface{}
+	if found {
+		auditBefore = before
+	}
+	if err := createAuditEntry(ctx, tx, s.Now(), op, xone.AuditMandate, strconv.Itoa(after.ID), auditBefore, after); err != nil {
+		return xone.Mandate{}, err
+	}
+
+	return after, tx.Commit()
+}
+
+func (s *MandateService) DeleteMandate(ctx context.Context, pid string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	mandate, found, err := findMandate(ctx, tx, pid)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "mandate", ID: pid}
+	}
+
+	if err := deleteMandate(ctx, tx, mandate.ID); err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditDelete, xone.AuditMandate, strconv.Itoa(mandate.ID), mandate, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindDirectDebits returns the outstanding balances of all persons whose
+// mandate has been signed on or before the collection date. Dues only count
+// once they have been booked as charges, see PaymentService.ChargeDues.
+func (s *MandateService) FindDirectDebits(ctx context.Context, collectionDate time.Time) ([]xone.DirectDebit, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	arrears, err := findArrears(ctx, tx, collectionDate)
+	if err != nil {
+		return nil, err
+	}
+
+	mandates, err := findMandatesSignedBy(ctx, tx, collectionDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var debits []xone.DirectDebit
+	for _, a := range arrears {
+		mandate, found := mandates[a.Person.PID]
+		if !found {
+			continue
+		}
+
+		debits = append(debits, xone.DirectDebit{
+			Person:     a.Person,
+			Mandate:    mandate,
+			Amount:     a.Balance,
+			EndToEndID: fmt.Sprintf("%s-%d", collectionDate.Format("20060102"), mandate.ID),
+		})
+	}
+
+	return debits, tx.Commit()
+}
+
+func (s *MandateService) BookDirectDebits(ctx context.Context, collectionDate time.Time, debits []xone.DirectDebit) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	y, m, d := collectionDate.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	now := s.Now().UTC().Truncate(time.Second)
+
+	for _, debit := range debits {
+		entry := xone.LedgerEntry{
+			PID:         debit.Person.PID,
+			Kind:        xone.LedgerPayment,
+			Date:        date,
+			Amount:      debit.Amount,
+			Description: "SEPA direct debit",
+			Method:      xone.PaymentDirectDebit,
+			Reference:   debit.EndToEndID,
+			CreatedAt:   now,
+		}
+		if entry.ID, err = createLedgerEntry(ctx, tx, debit.Person.ID, entry); err != nil {
+			return err
+		}
+
+		if err := createAuditEntry(ctx, tx, now, xone.AuditCreate, xone.AuditLedgerEntry, strconv.Itoa(entry.ID), nil, entry); err != nil {
+			return err
+		}
+
+		if err := setMandateCollected(ctx, tx, debit.Mandate.ID, date); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const mandateColumns = `
+	mandate.id,
+	person.public_id,
+	mandate.account_holder,
+	mandate.iban,
+	mandate.bic,
+	mandate.reference,
+	mandate.signed_on,
+	mandate.last_collected_on
+`
+
+func findMandate(ctx context.Context, tx dbtx, pid string) (xone.Mandate, bool, error) {
+	return queryMandate(ctx, tx, `
+		SELECT `+mandateColumns+`
+		FROM
+			mandate
+			JOIN person ON person.id = mandate.person_id
+		WHERE
+			person.public_id = ?
+	`, pid)
+}
+
+func findMandateByReference(ctx context.Context, tx dbtx, reference string) (xone.Mandate, bool, error) {
+	return queryMandate(ctx, tx, `
+		SELECT `+mandateColumns+`
+		FROM
+			mandate
+			JOIN person ON person.id = mandate.person_id
+		WHERE
+			mandate.reference = ?
+	`, reference)
+}
+
+// findMandatesSignedBy returns all mandates signed on or before the given
+// date, keyed by the PID of the person.
+func findMandatesSignedBy(ctx context.Context, tx dbtx, date time.Time) (map[string]xone.Mandate, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+mandateColumns+`
+		FROM
+			mandate
+			JOIN person ON person.id = mandate.person_id
+		WHERE
+			mandate.signed_on <= ?
+	`, date.Format(formatDate))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mandates := map[string]xone.Mandate{}
+	for rows.Next() {
+		m, err := scanMandate(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		mandates[m.PID] = m
+	}
+
+	return mandates, rows.Err()
+}
+
+func queryMandate(ctx context.Context, tx dbtx, query string, args ...interface{}) (xone.Mandate, bool, error) {
+	m, err := scanMandate(tx.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return xone.Mandate{}, false, nil
+	} else if err != nil {
+		return xone.Mandate{}, false, err
+	}
+
+	return m, true, nil
+}
+
+func scanMandate(s scanner) (xone.Mandate, error) {
+	var m xone.Mandate
+	var signedOn, lastCollectedOn string
+	if err := s.Scan(&m.ID, &m.PID, &m.AccountHolder, &m.IBAN, &m.BIC, &m.Reference, &signedOn, &lastCollectedOn); err != nil {
+		return xone.Mandate{}, err
+	}
+
+	var err error
+	if m.SignedOn, err = time.Parse(formatDate, signedOn); err != nil {
+		return xone.Mandate{}, err
+	}
+	if m.LastCollectedOn, err = parseOptionalDate(lastCollectedOn); err != nil {
+		return xone.Mandate{}, err
+	}
+
+	return m, nil
+}
+
+func createMandate(ctx context.Context, tx dbtx, personID int, data xone.SaveMandateData) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO mandate (
+			person_id,
+			account_holder,
+			iban,
+			bic,
+			reference,
+			signed_on
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, personID, data.AccountHolder, data.IBAN, data.BIC, data.Reference, data.SignedOn.Format(formatDate))
+
+	return mapError(err)
+}
+
+func updateMandate(ctx context.Context, tx dbtx, id int, data xone.SaveMandateData, lastCollectedOn time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			mandate
+		SET
+			account_holder = ?,
+			iban = ?,
+			bic = ?,
+			reference = ?,
+			signed_on = ?,
+			last_collected_on = ?
+		WHERE
+			id = ?
+	`)
+	if err != nil {
+		return err
+	}
+
+	last := ""
+	if !lastCollectedOn.IsZero() {
+		last = lastCollectedOn.Format(formatDate)
+	}
+
+	_, err = stmt.ExecContext(ctx, data.AccountHolder, data.IBAN, data.BIC, data.Reference, data.SignedOn.Format(formatDate), last, id)
+
+	return mapError(err)
+}
+
+func setMandateCollected(ctx context.Context, tx dbtx, id int, date time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, `UPDATE mandate SET last_collected_on = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, date.Format(formatDate), id)
+
+	return mapError(err)
+}
+
+func deleteMandate(ctx context.Context, tx dbtx, id int) error {
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM mandate WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, id)
+
+	return mapError(err)
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/stillwondering/xone"
@@ -11,20 +14,44 @@ import (
 
 const formatDate = "2006-01-02"
 
+var _ xone.MembershipService = (*MembershipService)(nil)
+
 type MembershipService struct {
-	db *sql.DB
+	db  *sql.DB
+	Now func() time.Time
 }
 
 func NewMembershipService(db *sql.DB) *MembershipService {
 	service := MembershipService{
-		db: db,
+		db:  db,
+		Now: time.Now,
 	}
 
 	return &service
 }
 
+// FindAllMembershipTypes returns all membership types along with their fees.
 func (s *MembershipService) FindAllMembershipTypes(ctx context.Context) ([]xone.MembershipType, error) {
-	return findAllMembershipTypes(ctx, s.db)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	membershipTypes, err := findAllMembershipTypes(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := findAllFees(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range membershipTypes {
+		membershipTypes[i].Fees = fees[membershipTypes[i].ID]
+	}
+
+	return membershipTypes, tx.Commit()
 }
 
 func (s *MembershipService) CreateMembershipType(ctx context.Context, name string) (xone.MembershipType, error) {
@@ -34,14 +61,40 @@ func (s *MembershipService) CreateMembershipType(ctx context.Context, name strin
 	}
 	defer tx.Rollback()
 
+	if _, found, err := findMembershipTypeByName(ctx, tx, name); err != nil {
+		return xone.MembershipType{}, err
+	} else if found {
+		return xone.MembershipType{}, &xone.ErrMembershipTypeExists{Name: name}
+	}
+
 	membershipType, err := createMembershipType(ctx, tx, name)
 	if err != nil {
 		return xone.MembershipType{}, err
 	}
 
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditCreate, xone.AuditMembershipType, strconv.Itoa(membershipType.ID), nil, membershipType); err != nil {
+		return xone.MembershipType{}, err
+	}
+
 	return membershipType, tx.Commit()
 }
 
+// FindMembership returns the membership with the given ID.
+func (s *MembershipService) FindMembership(ctx context.Context, id int) (xone.Membership, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Membership{}, false, err
+	}
+	defer tx.Rollback()
+
+	membership, found, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return xone.Membership{}, false, err
+	}
+
+	return membership, found, tx.Commit()
+}
+
 func (s *MembershipService) UpdateMembership(ctx context.Context, id int, data xone.UpdateMembershipData) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -49,10 +102,100 @@ func (s *MembershipService) UpdateMembership(ctx context.Context, id int, data x
 	}
 	defer tx.Rollback()
 
+	before, found, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "membership", ID: strconv.Itoa(id)}
+	}
+
 	if err := updateMembership(ctx, tx, id, data); err != nil {
 		return err
 	}
 
+	after, _, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditUpdate, xone.AuditMembership, strconv.Itoa(id), before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TerminateMembership ends a membership on the given end date. The end date
+// must not lie before the date the membership became effective.
+func (s *MembershipService) TerminateMembership(ctx context.Context, id int, data xone.TerminateMembershipData) error {
+	if !data.Reason.Valid() {
+		return &xone.ErrInvalidTerminationReason{Reason: data.Reason}
+	}
+	if data.EndDate.IsZero() {
+		return &xone.ErrInvalid{Msg: "end date required"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "membership", ID: strconv.Itoa(id)}
+	}
+
+	if data.EndDate.Before(before.EffectiveFrom) {
+		return &xone.ErrInvalid{Msg: fmt.Sprintf("end date %s lies before the membership became effective", data.EndDate.Format(formatDate))}
+	}
+
+	if err := setMembershipEnd(ctx, tx, id, data.EndDate.Format(formatDate), data.Reason); err != nil {
+		return err
+	}
+
+	after, _, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditUpdate, xone.AuditMembership, strconv.Itoa(id), before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReinstateMembership revokes the termination of a membership.
+func (s *MembershipService) ReinstateMembership(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "membership", ID: strconv.Itoa(id)}
+	}
+
+	if err := setMembershipEnd(ctx, tx, id, "", ""); err != nil {
+		return err
+	}
+
+	after, _, err := findMembership(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, s.Now(), xone.AuditUpdate, xone.AuditMembership, strconv.Itoa(id), before, after); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -86,6 +229,33 @@ func findAllMembershipTypes(ctx context.Context, db dbtx) ([]xone.MembershipType
 	return membershipTypes, nil
 }
 
+func findMembershipTypeByName(ctx context.Context, db dbtx, name string) (xone.MembershipType, bool, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		SELECT
+			id,
+			name
+		FROM
+			membership_type
+		WHERE
+			name = ?
+	`)
+	if err != nil {
+		return xone.MembershipType{}, false, err
+	}
+
+	mt := xone.MembershipType{}
+	row := stmt.QueryRowContext(ctx, name)
+	if err := row.Scan(&mt.ID, &mt.Name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return xone.MembershipType{}, false, nil
+		}
+
+		return xone.MembershipType{}, false, err
+	}
+
+	return mt, true, nil
+}
+
 func createMembershipType(ctx context.Context, db dbtx, name string) (xone.MembershipType, error) {
 	stmt, err := db.PrepareContext(ctx, `INSERT INTO membership_type (name) VALUES (?)`)
 	if err != nil {
@@ -94,7 +264,7 @@ func createMembershipType(ctx context.Context, db dbtx, name string) (xone.Membe
 
 	res, err := stmt.ExecContext(ctx, name)
 	if err != nil {
-		return xone.MembershipType{}, err
+		return xone.MembershipType{}, mapError(err)
 	}
 
 	id, err := res.LastInsertId()
@@ -114,7 +284,10 @@ func findMembershipsByPerson(ctx context.Context, db dbtx, pid string) ([]xone.M
 			membership.id,
 			membership.effective_from,
 			membership_type.id,
-			membership_type.name
+			membership_type.name,
+			membership.end_date,
+			membership.termination_reason,
+			membership.version
 		FROM
 			membership
 			JOIN membership_type ON membership.type_id = membership_type.id
@@ -139,17 +312,23 @@ func findMembershipsByPerson(ctx context.Context, db dbtx, pid string) ([]xone.M
 	for rows.Next() {
 		membershipType := xone.MembershipType{}
 		membership := xone.Membership{}
-		var effectiveFromText string
+		var effectiveFromText, endDateText string
 
-		if err := rows.Scan(&membership.ID, &effectiveFromText, &membershipType.ID, &membershipType.Name); err != nil {
+		if err := rows.Scan(&membership.ID, &effectiveFromText, &membershipType.ID, &membershipType.Name, &endDateText, &membership.TerminationReason, &membership.Version); err != nil {
 			return nil, err
 		}
 
-		membership.EffectiveFrom, err = time.Parse(formatDate, effectiveFromText)
-		if err != nil {
+		if membership.EndDate, err = parseOptionalDate(endDateText); err != nil {
 			return nil, err
 		}
 
+		if effectiveFromText != "" {
+			membership.EffectiveFrom, err = time.Parse(formatDate, effectiveFromText)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		membership.Type = membershipType
 
 		memberships = append(memberships, membership)
@@ -161,13 +340,96 @@ func findMembershipsByPerson(ctx context.Context, db dbtx, pid string) ([]xone.M
 	return memberships, nil
 }
 
+// membershipBatchSize limits the number of persons whose memberships are
+// loaded by a single query in findMembershipsByPersons. It keeps the number of
+// bound parameters well below SQLite's limit.
+const membershipBatchSize = 500
+
+// findMembershipsByPersons returns the memberships of all persons with the
+// given IDs, keyed by person ID. Persons without memberships are not contained
+// in the result.
+func findMembershipsByPersons(ctx context.Context, db dbtx, personIDs []int) (map[int][]xone.Membership, error) {
+	memberships := map[int][]xone.Membership{}
+
+	for len(personIDs) > 0 {
+		batch := personIDs
+		if len(batch) > membershipBatchSize {
+			batch = batch[:membershipBatchSize]
+		}
+		personIDs = personIDs[len(batch):]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+
+		rows, err := db.QueryContext(ctx, `
+			SELECT
+				membership.person_id,
+				membership.id,
+				membership.effective_from,
+				membership_type.id,
+				membership_type.name,
+				membership.end_date,
+				membership.termination_reason,
+				membership.version
+			FROM
+				membership
+				JOIN membership_type ON membership.type_id = membership_type.id
+			WHERE
+				membership.person_id IN (?`+strings.Repeat(", ?", len(batch)-1)+`)
+			ORDER BY
+				membership.id
+		`, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var personID int
+			membership := xone.Membership{}
+			var effectiveFromText, endDateText string
+
+			if err := rows.Scan(&personID, &membership.ID, &effectiveFromText, &membership.Type.ID, &membership.Type.Name, &endDateText, &membership.TerminationReason, &membership.Version); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			if membership.EndDate, err = parseOptionalDate(endDateText); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			if effectiveFromText != "" {
+				membership.EffectiveFrom, err = time.Parse(formatDate, effectiveFromText)
+				if err != nil {
+					rows.Close()
+					return nil, err
+				}
+			}
+
+			memberships[personID] = append(memberships[personID], membership)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rows.Close()
+	}
+
+	return memberships, nil
+}
+
 func findMembership(ctx context.Context, db dbtx, id int) (xone.Membership, bool, error) {
 	stmt, err := db.PrepareContext(ctx, `
 		SELECT
 			membership.id,
 			membership.effective_from,
 			membership_type.id,
-			membership_type.name
+			membership_type.name,
+			membership.end_date,
+			membership.termination_reason,
+			membership.version
 		FROM
 			membership
 			JOIN membership_type ON membership.type_id = membership_type.id
@@ -181,9 +443,9 @@ func findMembership(ctx context.Context, db dbtx, id int) (xone.Membership, bool
 	row := stmt.QueryRowContext(ctx, id)
 
 	membership := xone.Membership{}
-	var effectiveFromText string
+	var effectiveFromText, endDateText string
 
-	if err := row.Scan(&membership.ID, &effectiveFromText, &membership.Type.ID, &membership.Type.Name); err != nil {
+	if err := row.Scan(&membership.ID, &effectiveFromText, &membership.Type.ID, &membership.Type.Name, &endDateText, &membership.TerminationReason, &membership.Version); err != nil {
 		if err == sql.ErrNoRows {
 			return xone.Membership{}, false, nil
 		}
@@ -191,6 +453,10 @@ func findMembership(ctx context.Context, db dbtx, id int) (xone.Membership, bool
 		return xone.Membership{}, false, err
 	}
 
+	if membership.EndDate, err = parseOptionalDate(endDateText); err != nil {
+		return xone.Membership{}, true, err
+	}
+
 	if effectiveFromText != "" {
 		membership.EffectiveFrom, err = time.Parse(formatDate, effectiveFromText)
 		if err != nil {
@@ -224,7 +490,7 @@ func createMembership(ctx context.Context, db dbtx, data xone.CreateMembershipDa
 
 	res, err := stmt.ExecContext(ctx, data.PersonID, data.MembershipTypeID, effectiveFrom)
 	if err != nil {
-		return xone.Membership{}, err
+		return xone.Membership{}, mapError(err)
 	}
 
 	id, err := res.LastInsertId()
@@ -240,15 +506,20 @@ func createMembership(ctx context.Context, db dbtx, data xone.CreateMembershipDa
 	return membership, nil
 }
 
+// updateMembership changes the type and the effective date of a membership and
+// increments its version. It fails with an *xone.ErrStaleVersion unless the
+// membership exists with the version named in data.
 func updateMembership(ctx context.Context, db dbtx, id int, data xone.UpdateMembershipData) error {
 	stmt, err := db.PrepareContext(ctx, `
 		UPDATE
 			membership
 		SET
 			type_id = ?,
-			effective_from = ?
+			effective_from = ?,
+			version = version + 1
 		WHERE
 			id = ?
+			AND version = ?
 	`)
 	if err != nil {
 		return err
@@ -259,16 +530,48 @@ func updateMembership(ctx context.Context, db dbtx, id int, data xone.UpdateMemb
 		effectiveFrom = data.EffectiveFrom.Format(formatDate)
 	}
 
-	res, err := stmt.ExecContext(ctx, data.MembershipTypeID, effectiveFrom, id)
+	res, err := stmt.ExecContext(ctx, data.MembershipTypeID, effectiveFrom, id, data.Version)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
 	if rows, err := res.RowsAffected(); err != nil {
 		return err
 	} else if int(rows) != 1 {
-		return errors.New("membership not found")
+		return &xone.ErrStaleVersion{Entity: "membership", ID: strconv.Itoa(id), Version: data.Version}
 	}
 
 	return nil
 }
+
+// setMembershipEnd sets the end date and the termination reason of a
+// membership. Empty values revoke a termination.
+func setMembershipEnd(ctx context.Context, db dbtx, id int, endDate string, reason xone.TerminationReason) error {
+	stmt, err := db.PrepareContext(ctx, `
+		UPDATE
+			membership
+		SET
+			end_date = ?,
+			termination_reason = ?,
+			version = version + 1
+		WHERE
+			id = ?
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, endDate, reason, id)
+
+	return mapError(err)
+}
+
+// parseOptionalDate parses a date which is stored as an empty string if it is
+// not set.
+func parseOptionalDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(formatDate, s)
+}
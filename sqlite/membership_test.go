@@ -43,6 +43,7 @@ func Test_findMembershipsByPerson(t *testing.T) {
 						Name: "active",
 					},
 					EffectiveFrom: time.Date(1998, time.July, 31, 0, 0, 0, 0, time.UTC),
+					Version:       1,
 				},
 				{
 					ID: 2,
@@ -51,6 +52,7 @@ func Test_findMembershipsByPerson(t *testing.T) {
 						Name: "passive",
 					},
 					EffectiveFrom: time.Date(2045, time.July, 31, 0, 0, 0, 0, time.UTC),
+					Version:       1,
 				},
 			},
 			wantErr: false,
@@ -73,6 +75,31 @@ func Test_findMembershipsByPerson(t *testing.T) {
 	}
 }
 
+func Test_findMembershipsByPersons(t *testing.T) {
+	db := mustOpenDB(t)
+	mustMigrateFile(t, db, "testdata/Test_findMembershipsByPerson.sql")
+
+	// Pad the list of IDs so that the persons end up in different batches.
+	ids := []int{1}
+	for i := 0; i < membershipBatchSize; i++ {
+		ids = append(ids, 1000+i)
+	}
+	ids = append(ids, 2, 3)
+
+	got, err := findMembershipsByPersons(context.Background(), db, ids)
+	if err != nil {
+		t.Fatalf("findMembershipsByPersons() error = %v", err)
+	}
+
+	want, err := findMembershipsByPerson(context.Background(), db, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, map[int][]xone.Membership{1: want}) {
+		t.Errorf("findMembershipsByPersons() = %v, want %v", got, map[int][]xone.Membership{1: want})
+	}
+}
+
 func Test_findAllMembershipTypes(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -143,6 +170,7 @@ func Test_findMembership(t *testing.T) {
 					Name: "active",
 				},
 				EffectiveFrom: time.Date(1998, time.July, 31, 0, 0, 0, 0, time.UTC),
+				Version:       1,
 			},
 			wantFound: true,
 			wantErr:   false,
@@ -218,6 +246,7 @@ func Test_createMembership(t *testing.T) {
 					Name: "active",
 				},
 				EffectiveFrom: time.Date(1997, time.September, 19, 0, 0, 0, 0, time.UTC),
+				Version:       1,
 			},
 			wantErr: false,
 		},
@@ -236,6 +265,7 @@ func Test_createMembership(t *testing.T) {
 					Name: "active",
 				},
 				EffectiveFrom: time.Time{},
+				Version:       1,
 			},
 			wantErr: false,
 		},
@@ -255,6 +285,7 @@ func Test_createMembership(t *testing.T) {
 					Name: "passive",
 				},
 				EffectiveFrom: time.Date(2045, time.July, 31, 0, 0, 0, 0, time.UTC),
+				Version:       1,
 			},
 			wantErr: false,
 		},
@@ -348,6 +379,7 @@ func Test_updateMembership(t *testing.T) {
 			args: args{
 				id: 1,
 				data: xone.UpdateMembershipData{
+					Version:          1,
 					MembershipTypeID: 123,
 				},
 			},
@@ -358,17 +390,30 @@ func Test_updateMembership(t *testing.T) {
 			args: args{
 				id: 2,
 				data: xone.UpdateMembershipData{
+					Version:          1,
 					MembershipTypeID: 1,
 					EffectiveFrom:    time.Time{},
 				},
 			},
 			wantErr: false,
 		},
+		{
+			name: "Stale version",
+			args: args{
+				id: 2,
+				data: xone.UpdateMembershipData{
+					Version:          2,
+					MembershipTypeID: 1,
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "No op",
 			args: args{
 				id: 1,
 				data: xone.UpdateMembershipData{
+					Version:          1,
 					MembershipTypeID: 1,
 					EffectiveFrom:    time.Date(1998, time.July, 31, 0, 0, 0, 0, time.UTC),
 				},
@@ -387,3 +432,46 @@ func Test_updateMembership(t *testing.T) {
 		})
 	}
 }
+
+func Test_findMembershipTypeByName(t *testing.T) {
+	tests := []struct {
+		name      string
+		typeName  string
+		want      xone.MembershipType
+		wantFound bool
+		wantErr   bool
+	}{
+		{
+			name:      "Existing membership type",
+			typeName:  "active",
+			want:      xone.MembershipType{ID: 1, Name: "active"},
+			wantFound: true,
+			wantErr:   false,
+		},
+		{
+			name:      "Nonexistent membership type",
+			typeName:  "passive",
+			want:      xone.MembershipType{},
+			wantFound: false,
+			wantErr:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mustOpenDB(t)
+			mustMigrateFile(t, db, "testdata/Test_createMembershipType.sql")
+
+			got, gotFound, err := findMembershipTypeByName(context.Background(), db, tt.typeName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("findMembershipTypeByName() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotFound != tt.wantFound {
+				t.Errorf("findMembershipTypeByName() gotFound = %v, want %v", gotFound, tt.wantFound)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findMembershipTypeByName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
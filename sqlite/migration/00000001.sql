@@ -0,0 +1,12 @@
+--
+-- Session
+--
+CREATE TABLE `session` (
+    `id` INTEGER NOT NULL PRIMARY KEY,
+    `token_hash` TEXT NOT NULL UNIQUE,
+    `user_id` INTEGER NOT NULL REFERENCES `users`(`id`) ON DELETE CASCADE,
+    `created_at` TEXT NOT NULL,
+    `expires_at` TEXT NOT NULL
+);
+
+CREATE INDEX `session_user_id` ON `session`(`user_id`);
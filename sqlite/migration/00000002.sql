@@ -0,0 +1,9 @@
+--
+-- Roles
+--
+ALTER TABLE `users` ADD COLUMN `role` TEXT NOT NULL DEFAULT 'read-only'
+    CHECK (`role` IN ('admin', 'board', 'treasurer', 'read-only'));
+
+-- Users created before the introduction of roles were allowed to do
+-- everything, so they keep doing so.
+UPDATE `users` SET `role` = 'admin';
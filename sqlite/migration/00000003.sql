@@ -0,0 +1,4 @@
+--
+-- Indexes for loading the memberships of many persons at once
+--
+CREATE INDEX `membership_person_id` ON `membership`(`person_id`, `effective_from`);
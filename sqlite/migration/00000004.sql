@@ -0,0 +1,20 @@
+--
+-- Audit log
+--
+-- Entries are not tied to the changed entities or to the users who made the
+-- changes by foreign keys, so they outlive both.
+--
+CREATE TABLE `audit_log` (
+    `id` INTEGER NOT NULL PRIMARY KEY,
+    `created_at` TEXT NOT NULL,
+    `actor_id` INTEGER,
+    `actor_email` TEXT NOT NULL DEFAULT '',
+    `operation` TEXT NOT NULL CHECK (`operation` IN ('create', 'update', 'delete')),
+    `entity` TEXT NOT NULL,
+    `entity_id` TEXT NOT NULL,
+    `before` TEXT NOT NULL DEFAULT '',
+    `after` TEXT NOT NULL DEFAULT '',
+    `reason` TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX `audit_log_entity` ON `audit_log`(`entity`, `entity_id`);
@@ -0,0 +1,9 @@
+--
+-- Archived persons
+--
+-- Deleted persons are archived by setting the point in time they were deleted
+-- at. They are removed for good once the retention period has passed.
+--
+ALTER TABLE `person` ADD COLUMN `deleted_at` TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX `person_deleted_at` ON `person`(`deleted_at`);
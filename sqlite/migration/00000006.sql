@@ -0,0 +1,56 @@
+--
+-- Membership termination
+--
+ALTER TABLE `membership` ADD COLUMN `end_date` TEXT NOT NULL DEFAULT '';
+ALTER TABLE `membership` ADD COLUMN `termination_reason` TEXT NOT NULL DEFAULT ''
+    CHECK (`termination_reason` IN ('', 'resignation', 'death', 'exclusion', 'other'));
+
+ALTER TABLE `membership_history` ADD COLUMN `end_date` TEXT NOT NULL DEFAULT '';
+ALTER TABLE `membership_history` ADD COLUMN `termination_reason` TEXT NOT NULL DEFAULT '';
+
+DROP TRIGGER membership_insert;
+DROP TRIGGER membership_update;
+
+CREATE TRIGGER membership_insert
+    AFTER INSERT ON membership
+BEGIN
+    INSERT INTO membership_history (
+        created_at,
+        membership_id,
+        type_id,
+        person_id,
+        effective_from,
+        end_date,
+        termination_reason
+    ) VALUES (
+        datetime(),
+        NEW.id,
+        NEW.type_id,
+        NEW.person_id,
+        NEW.effective_from,
+        NEW.end_date,
+        NEW.termination_reason
+    );
+END;
+
+CREATE TRIGGER membership_update
+    AFTER UPDATE ON membership
+BEGIN
+    INSERT INTO membership_history (
+        created_at,
+        membership_id,
+        type_id,
+        person_id,
+        effective_from,
+        end_date,
+        termination_reason
+    ) VALUES (
+        datetime(),
+        NEW.id,
+        NEW.type_id,
+        NEW.person_id,
+        NEW.effective_from,
+        NEW.end_date,
+        NEW.termination_reason
+    );
+END;
@@ -0,0 +1,16 @@
+--
+-- Membership fees
+--
+-- Amounts are stored in cents. An empty valid_until means the fee is valid
+-- indefinitely.
+--
+CREATE TABLE `membership_fee` (
+    `id` INTEGER NOT NULL PRIMARY KEY,
+    `membership_type_id` INTEGER NOT NULL REFERENCES `membership_type`(`id`) ON DELETE CASCADE,
+    `amount` INTEGER NOT NULL CHECK (`amount` >= 0),
+    `billing_period` TEXT NOT NULL CHECK (`billing_period` IN ('annual', 'quarterly', 'monthly')),
+    `valid_from` TEXT NOT NULL,
+    `valid_until` TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX `membership_fee_membership_type_id` ON `membership_fee`(`membership_type_id`, `valid_from`);
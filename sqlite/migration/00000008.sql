@@ -0,0 +1,21 @@
+--
+-- Ledger
+--
+-- Every charge and payment of a person. Amounts are stored in cents and are
+-- always positive, the kind determines whether an entry increases or
+-- decreases the balance.
+--
+CREATE TABLE `ledger_entry` (
+    `id` INTEGER NOT NULL PRIMARY KEY,
+    `person_id` INTEGER NOT NULL REFERENCES `person`(`id`) ON DELETE CASCADE,
+    `kind` TEXT NOT NULL CHECK (`kind` IN ('charge', 'payment')),
+    `entry_date` TEXT NOT NULL,
+    `amount` INTEGER NOT NULL CHECK (`amount` > 0),
+    `description` TEXT NOT NULL DEFAULT '',
+    `payment_method` TEXT NOT NULL DEFAULT ''
+        CHECK (`payment_method` IN ('', 'cash', 'bank_transfer', 'direct_debit', 'card')),
+    `reference` TEXT NOT NULL DEFAULT '',
+    `created_at` TEXT NOT NULL
+);
+
+CREATE INDEX `ledger_entry_person_id` ON `ledger_entry`(`person_id`, `entry_date`);
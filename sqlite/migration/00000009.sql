@@ -0,0 +1,16 @@
+--
+-- SEPA direct debit mandates
+--
+-- Every person has at most one mandate. An empty last_collected_on means the
+-- mandate has not been used yet.
+--
+CREATE TABLE `mandate` (
+    `id` INTEGER NOT NULL PRIMARY KEY,
+    `person_id` INTEGER NOT NULL UNIQUE REFERENCES `person`(`id`) ON DELETE CASCADE,
+    `account_holder` TEXT NOT NULL DEFAULT '',
+    `iban` TEXT NOT NULL,
+    `bic` TEXT NOT NULL DEFAULT '',
+    `reference` TEXT NOT NULL UNIQUE,
+    `signed_on` TEXT NOT NULL,
+    `last_collected_on` TEXT NOT NULL DEFAULT ''
+);
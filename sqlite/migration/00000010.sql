@@ -0,0 +1,39 @@
+--
+-- Invoices
+--
+-- Invoices and receipts are kept when the person they have been issued to is
+-- purged, which is why the name and address of the recipient are copied.
+-- Their numbers are drawn from `invoice_sequence`, which holds the last
+-- number issued per kind and year and is never decremented.
+--
+CREATE TABLE `invoice_sequence` (
+    `kind` TEXT NOT NULL,
+    `year` INTEGER NOT NULL,
+    `last_number` INTEGER NOT NULL,
+    PRIMARY KEY (`kind`, `year`)
+);
+
+CREATE TABLE `invoice` (
+    `id` INTEGER NOT NULL PRIMARY KEY,
+    `number` TEXT NOT NULL UNIQUE,
+    `kind` TEXT NOT NULL CHECK (`kind` IN ('invoice', 'receipt')),
+    `person_id` INTEGER REFERENCES `person`(`id`) ON DELETE SET NULL,
+    `issued_on` TEXT NOT NULL,
+    `recipient_name` TEXT NOT NULL,
+    `street` TEXT NOT NULL DEFAULT '',
+    `house_number` TEXT NOT NULL DEFAULT '',
+    `zip_code` TEXT NOT NULL DEFAULT '',
+    `city` TEXT NOT NULL DEFAULT '',
+    `membership_type` TEXT NOT NULL DEFAULT '',
+    `created_at` TEXT NOT NULL
+);
+
+CREATE INDEX `invoice_person_id` ON `invoice`(`person_id`);
+
+CREATE TABLE `invoice_item` (
+    `invoice_id` INTEGER NOT NULL REFERENCES `invoice`(`id`) ON DELETE CASCADE,
+    `position` INTEGER NOT NULL,
+    `description` TEXT NOT NULL,
+    `amount` INTEGER NOT NULL,
+    PRIMARY KEY (`invoice_id`, `position`)
+);
@@ -0,0 +1,22 @@
+--
+-- Households
+--
+-- A household groups persons who live at the same address. Every person
+-- belongs to at most one household. The primary contact is one of the
+-- members.
+--
+CREATE TABLE `household` (
+    `id` INTEGER NOT NULL PRIMARY KEY,
+    `name` TEXT NOT NULL,
+    `street` TEXT NOT NULL DEFAULT '',
+    `house_number` TEXT NOT NULL DEFAULT '',
+    `zip_code` TEXT NOT NULL DEFAULT '',
+    `city` TEXT NOT NULL DEFAULT '',
+    `primary_contact_id` INTEGER REFERENCES `person`(`id`) ON DELETE SET NULL
+);
+
+CREATE TABLE `household_member` (
+    `household_id` INTEGER NOT NULL REFERENCES `household`(`id`) ON DELETE CASCADE,
+    `person_id` INTEGER NOT NULL UNIQUE REFERENCES `person`(`id`) ON DELETE CASCADE,
+    PRIMARY KEY (`household_id`, `person_id`)
+);
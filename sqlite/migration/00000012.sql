@@ -0,0 +1,22 @@
+--
+-- Custom fields
+--
+-- Custom fields are defined by the administrators of an organization. The
+-- values of persons are stored in their canonical text form, the type of the
+-- field determines how they are interpreted. The options of enum fields are
+-- stored as a JSON array.
+--
+CREATE TABLE `custom_field` (
+    `id` INTEGER NOT NULL PRIMARY KEY,
+    `name` TEXT NOT NULL UNIQUE,
+    `type` TEXT NOT NULL CHECK (`type` IN ('text', 'number', 'date', 'bool', 'enum')),
+    `required` INTEGER NOT NULL DEFAULT 0,
+    `options` TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE TABLE `custom_field_value` (
+    `person_id` INTEGER NOT NULL REFERENCES `person`(`id`) ON DELETE CASCADE,
+    `field_id` INTEGER NOT NULL REFERENCES `custom_field`(`id`) ON DELETE CASCADE,
+    `value` TEXT NOT NULL,
+    PRIMARY KEY (`person_id`, `field_id`)
+);
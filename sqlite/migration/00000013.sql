@@ -0,0 +1,9 @@
+--
+-- Versions
+--
+-- Persons and memberships carry a version which is incremented on every
+-- change. Updates name the version they are based on so that concurrent
+-- changes do not silently overwrite each other.
+--
+ALTER TABLE `person` ADD COLUMN `version` INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE `membership` ADD COLUMN `version` INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,99 @@
+--
+-- Full-text index of persons
+--
+-- This migration is only run if SQLite has been compiled with FTS5, see
+-- migrateSearch. It must therefore be safe to run again after the triggers have
+-- been dropped by a build without FTS5.
+--
+CREATE VIRTUAL TABLE IF NOT EXISTS `person_fts` USING fts5(
+    `first_name`,
+    `last_name`,
+    `email`,
+    `street`,
+    `city`,
+    content = 'person',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS person_fts_after_insert_person
+    AFTER INSERT ON person
+BEGIN
+    INSERT INTO person_fts (
+        rowid,
+        first_name,
+        last_name,
+        email,
+        street,
+        city
+    ) VALUES (
+        NEW.id,
+        NEW.first_name,
+        NEW.last_name,
+        NEW.email,
+        NEW.street,
+        NEW.city
+    );
+END;
+
+CREATE TRIGGER IF NOT EXISTS person_fts_after_delete_person
+    AFTER DELETE ON person
+BEGIN
+    INSERT INTO person_fts (
+        person_fts,
+        rowid,
+        first_name,
+        last_name,
+        email,
+        street,
+        city
+    ) VALUES (
+        'delete',
+        OLD.id,
+        OLD.first_name,
+        OLD.last_name,
+        OLD.email,
+        OLD.street,
+        OLD.city
+    );
+END;
+
+CREATE TRIGGER IF NOT EXISTS person_fts_after_update_person
+    AFTER UPDATE ON person
+BEGIN
+    INSERT INTO person_fts (
+        person_fts,
+        rowid,
+        first_name,
+        last_name,
+        email,
+        street,
+        city
+    ) VALUES (
+        'delete',
+        OLD.id,
+        OLD.first_name,
+        OLD.last_name,
+        OLD.email,
+        OLD.street,
+        OLD.city
+    );
+    INSERT INTO person_fts (
+        rowid,
+        first_name,
+        last_name,
+        email,
+        street,
+        city
+    ) VALUES (
+        NEW.id,
+        NEW.first_name,
+        NEW.last_name,
+        NEW.email,
+        NEW.street,
+        NEW.city
+    );
+END;
+
+-- Index all persons which have been created before the index existed.
+INSERT INTO person_fts (person_fts) VALUES ('rebuild');
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/bcrypt"
 	"github.com/stillwondering/xone/sqlite"
+	"github.com/stillwondering/xone/xonetest"
 )
 
 func Test(t *testing.T) {
@@ -52,8 +56,10 @@ func Test(t *testing.T) {
 				ID:            1,
 				Type:          mt,
 				EffectiveFrom: time.Date(1998, time.July, 31, 0, 0, 0, 0, time.UTC),
+				Version:       1,
 			},
 		},
+		Version: 1,
 	}
 	if !reflect.DeepEqual(expectedPerson, p) {
 		t.Fatalf("PersonService.Create() = %v, want %v", p, expectedPerson)
@@ -124,7 +130,7 @@ func TestUserService(t *testing.T) {
 	if err != nil {
 		t.Errorf("UserService.Create() err = %v, want %v", err, nil)
 	}
-	wantUser := xone.User{Email: "albus.dumbledore@hogwarts.co.uk", Password: "Harrydidyouputyournameinthegobletoffire"}
+	wantUser := xone.User{ID: 1, Email: "albus.dumbledore@hogwarts.co.uk", Password: "Harrydidyouputyournameinthegobletoffire", Role: xone.RoleReadOnly}
 	if user != wantUser {
 		t.Errorf("UserService.Create() want = %v, got %v", wantUser, user)
 	}
@@ -137,4 +143,1119 @@ func TestUserService(t *testing.T) {
 	if !errors.As(err, &e) {
 		t.Errorf("UserService.Create() wantErr = %v, got %v", e, err)
 	}
+
+	_, err = userService.Create(context.Background(), xone.CreateUserData{
+		Email:    "severus.snape@hogwarts.co.uk",
+		Password: "Detention!",
+		Role:     "headmaster",
+	})
+	var invalidRole *xone.ErrInvalidRole
+	if !errors.As(err, &invalidRole) {
+		t.Errorf("UserService.Create() wantErr = %T, got %v", invalidRole, err)
+	}
+}
+
+func TestUserService_Authenticate(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	userService, err := sqlite.NewUserService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := bcrypt.HashFromPassword([]byte("Harrydidyouputyournameinthegobletoffire"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := userService.Create(context.Background(), xone.CreateUserData{
+		Email:    "albus.dumbledore@hogwarts.co.uk",
+		Password: string(hash),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := userService.Authenticate(context.Background(), "albus.dumbledore@hogwarts.co.uk", "Harrydidyouputyournameinthegobletoffire")
+	if err != nil {
+		t.Fatalf("UserService.Authenticate() err = %v, want %v", err, nil)
+	}
+	want.Password = ""
+	if got != want {
+		t.Errorf("UserService.Authenticate() = %v, want %v", got, want)
+	}
+
+	var e *xone.ErrInvalidCredentials
+	_, err = userService.Authenticate(context.Background(), "albus.dumbledore@hogwarts.co.uk", "wrong password")
+	if !errors.As(err, &e) {
+		t.Errorf("UserService.Authenticate() wrong password err = %v, want %T", err, e)
+	}
+	_, err = userService.Authenticate(context.Background(), "severus.snape@hogwarts.co.uk", "Harrydidyouputyournameinthegobletoffire")
+	if !errors.As(err, &e) {
+		t.Errorf("UserService.Authenticate() unknown user err = %v, want %T", err, e)
+	}
+}
+
+func TestSessionService(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	userService, err := sqlite.NewUserService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := userService.Create(context.Background(), xone.CreateUserData{Email: "albus.dumbledore@hogwarts.co.uk", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2022, time.February, 1, 10, 0, 0, 0, time.UTC)
+	sessionService := sqlite.NewSessionService(db)
+	sessionService.Now = func() time.Time { return now }
+
+	first, err := sessionService.Create(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := sessionService.Create(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Token == second.Token {
+		t.Errorf("SessionService.Create() returned the same token twice")
+	}
+	if want := now.Add(sqlite.DefaultSessionLifetime); !first.ExpiresAt.Equal(want) {
+		t.Errorf("SessionService.Create() ExpiresAt = %v, want %v", first.ExpiresAt, want)
+	}
+	if first.User.Password != "" {
+		t.Errorf("SessionService.Create() User.Password = %q, want it to be empty", first.User.Password)
+	}
+
+	found, ok, err := sessionService.Find(context.Background(), first.Token)
+	if err != nil || !ok {
+		t.Fatalf("SessionService.Find() found = %v, err = %v", ok, err)
+	}
+	if !reflect.DeepEqual(found, first) {
+		t.Errorf("SessionService.Find() = %v, want %v", found, first)
+	}
+
+	if err := sessionService.Revoke(context.Background(), first.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := sessionService.Find(context.Background(), first.Token); ok {
+		t.Errorf("SessionService.Find() found revoked session")
+	}
+
+	now = now.Add(sqlite.DefaultSessionLifetime)
+	if _, ok, _ := sessionService.Find(context.Background(), second.Token); ok {
+		t.Errorf("SessionService.Find() found expired session")
+	}
+
+	now = time.Date(2022, time.February, 1, 10, 0, 0, 0, time.UTC)
+	if err := sessionService.RevokeAll(context.Background(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := sessionService.Find(context.Background(), second.Token); ok {
+		t.Errorf("SessionService.Find() found session after RevokeAll()")
+	}
+}
+
+func TestAuditService(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	userService, err := sqlite.NewUserService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := userService.Create(context.Background(), xone.CreateUserData{
+		Email:    "albus.dumbledore@hogwarts.co.uk",
+		Password: "Harrydidyouputyournameinthegobletoffire",
+		Role:     xone.RoleAdmin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := xone.NewContextWithUser(context.Background(), admin)
+	personService := sqlite.NewPersonService(db)
+	now := time.Date(2022, time.March, 1, 12, 30, 0, 0, time.UTC)
+	personService.Now = func() time.Time { return now }
+	membershipService := sqlite.NewMembershipService(db)
+	auditService := sqlite.NewAuditService(db)
+
+	mt, err := membershipService.CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := p.ToUpdateData()
+	data.City = "London"
+	if err := personService.Update(xone.NewContextWithReason(ctx, "Moved"), p.PID, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := personService.Delete(context.Background(), p.PID); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := auditService.FindAuditEntries(context.Background(), xone.AuditPerson, p.PID)
+	if err != nil {
+		t.Fatalf("AuditService.FindAuditEntries() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("AuditService.FindAuditEntries() len = %v, want %v", len(entries), 3)
+	}
+
+	for i, want := range []struct {
+		op         xone.AuditOperation
+		actorEmail string
+		reason     string
+		hasBefore  bool
+		hasAfter   bool
+	}{
+		{op: xone.AuditCreate, actorEmail: admin.Email, hasAfter: true},
+		{op: xone.AuditUpdate, actorEmail: admin.Email, reason: "Moved", hasBefore: true, hasAfter: true},
+		{op: xone.AuditDelete, hasBefore: true},
+	} {
+		e := entries[i]
+		if e.Operation != want.op || e.ActorEmail != want.actorEmail || e.Reason != want.reason || (e.Before != "") != want.hasBefore || (e.After != "") != want.hasAfter || !e.Time.Equal(now) {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want)
+		}
+	}
+	if !strings.Contains(entries[1].After, "London") || strings.Contains(entries[1].Before, "London") {
+		t.Errorf("entry 1 before = %s, after = %s, want the city to change", entries[1].Before, entries[1].After)
+	}
+
+	entries, err = auditService.FindAuditEntries(context.Background(), xone.AuditUser, strconv.Itoa(admin.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || strings.Contains(entries[0].After, admin.Password) {
+		t.Errorf("AuditService.FindAuditEntries() = %+v, want one entry without the password", entries)
+	}
+}
+
+func TestPersonService_Archive(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	now := time.Date(2022, time.January, 1, 12, 0, 0, 0, time.UTC)
+	personService := sqlite.NewPersonService(db)
+	personService.Retention = 30 * 24 * time.Hour
+	personService.Now = func() time.Time { return now }
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := personService.Delete(ctx, p.PID); err != nil {
+		t.Fatalf("PersonService.Delete() error = %v", err)
+	}
+	if _, found, err := personService.Find(ctx, p.PID); err != nil || found {
+		t.Errorf("PersonService.Find() found = %v, err = %v, want a deleted person not to be found", found, err)
+	}
+
+	archived, n, err := personService.FindMany(ctx, xone.PersonFilter{Archived: true})
+	if err != nil {
+		t.Fatalf("PersonService.FindMany() error = %v", err)
+	}
+	if n != 1 || !archived[0].DeletedAt.Equal(now) || len(archived[0].Memberships) != 1 {
+		t.Errorf("PersonService.FindMany() = %v, want the deleted person along with their membership", archived)
+	}
+
+	if err := personService.Restore(ctx, p.PID); err != nil {
+		t.Fatalf("PersonService.Restore() error = %v", err)
+	}
+	if got, found, err := personService.Find(ctx, p.PID); err != nil || !found || got.Archived() {
+		t.Errorf("PersonService.Find() = %v, %v, %v, want the restored person", got, found, err)
+	}
+
+	if err := personService.Delete(ctx, p.PID); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(29 * 24 * time.Hour)
+	if n, err := personService.Purge(ctx); err != nil || n != 0 {
+		t.Errorf("PersonService.Purge() = %v, %v, want no person to be purged within the retention period", n, err)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if n, err := personService.Purge(ctx); err != nil || n != 1 {
+		t.Errorf("PersonService.Purge() = %v, %v, want %v", n, err, 1)
+	}
+	if archived, _, err := personService.FindMany(ctx, xone.PersonFilter{Archived: true}); err != nil || len(archived) != 0 {
+		t.Errorf("PersonService.FindMany() = %v, %v, want no archived persons after purging", archived, err)
+	}
+}
+
+func TestPersonService_Validate(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	ps := sqlite.NewPersonService(db)
+
+	var invalid *xone.ValidationError
+	if _, err := ps.Create(ctx, xone.CreatePersonData{FirstName: "Harry", Email: "harry"}); !errors.As(err, &invalid) {
+		t.Fatalf("PersonService.Create() error = %v, want *xone.ValidationError", err)
+	}
+	if len(invalid.Errors) != 3 || !invalid.Has("last_name", xone.ValidationRequired) || !invalid.Has("email", xone.ValidationFormat) || !invalid.Has("membership_type", xone.ValidationRequired) {
+		t.Errorf("PersonService.Create() errors = %v, want last name, email and membership type", invalid.Errors)
+	}
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	harry, err := ps.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatalf("PersonService.Create() error = %v", err)
+	}
+
+	data := harry.ToUpdateData()
+	data.DateOfBirth = time.Now().AddDate(1, 0, 0)
+	if err := ps.Update(ctx, harry.PID, data); !errors.As(err, &invalid) || !invalid.Has("date_of_birth", xone.ValidationInFuture) {
+		t.Errorf("PersonService.Update() error = %v, want a date of birth in the future", err)
+	}
+}
+
+func TestPersonService_Errors(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	membershipService := sqlite.NewMembershipService(db)
+
+	var notFound *xone.ErrNotFound
+	if err := personService.Update(ctx, "unknown", xone.UpdatePersonData{FirstName: "Harry", LastName: "Potter"}); !errors.As(err, &notFound) {
+		t.Errorf("PersonService.Update() error = %v, want *xone.ErrNotFound", err)
+	}
+	if err := personService.Delete(ctx, "unknown"); !errors.As(err, &notFound) {
+		t.Errorf("PersonService.Delete() error = %v, want *xone.ErrNotFound", err)
+	}
+	if err := personService.Restore(ctx, "unknown"); !errors.As(err, &notFound) {
+		t.Errorf("PersonService.Restore() error = %v, want *xone.ErrNotFound", err)
+	}
+	if err := membershipService.UpdateMembership(ctx, 42, xone.UpdateMembershipData{}); !errors.As(err, &notFound) {
+		t.Errorf("MembershipService.UpdateMembership() error = %v, want *xone.ErrNotFound", err)
+	}
+
+	var invalid *xone.ErrInvalid
+	if _, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: 42}); !errors.As(err, &invalid) {
+		t.Errorf("PersonService.Create() error = %v, want *xone.ErrInvalid for an unknown membership type", err)
+	}
+
+	var conflict *xone.ErrConflict
+	if _, err := membershipService.CreateMembershipType(ctx, "active"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := membershipService.CreateMembershipType(ctx, "active"); !errors.As(err, &conflict) {
+		t.Errorf("MembershipService.CreateMembershipType() error = %v, want *xone.ErrConflict", err)
+	}
+}
+
+func TestPersonService_Version(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	membershipService := sqlite.NewMembershipService(db)
+
+	mt, err := membershipService.CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	harry, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mine, theirs := harry.ToUpdateData(), harry.ToUpdateData()
+	mine.Email = "harry.potter@hogwarts.co.uk"
+	theirs.Phone = "1234"
+	if err := personService.Update(ctx, harry.PID, mine); err != nil {
+		t.Fatalf("PersonService.Update() error = %v", err)
+	}
+	var stale *xone.ErrStaleVersion
+	if err := personService.Update(ctx, harry.PID, theirs); !errors.As(err, &stale) {
+		t.Errorf("PersonService.Update() error = %v, want *xone.ErrStaleVersion", err)
+	}
+	if got, _, err := personService.Find(ctx, harry.PID); err != nil || got.Version != harry.Version+1 || got.Phone != "" {
+		t.Errorf("PersonService.Find() = %v, %v, want only the first update to be applied", got, err)
+	}
+
+	m := harry.Memberships[0]
+	if err := membershipService.UpdateMembership(ctx, m.ID, m.ToUpdateData()); err != nil {
+		t.Fatalf("MembershipService.UpdateMembership() error = %v", err)
+	}
+	if err := membershipService.UpdateMembership(ctx, m.ID, m.ToUpdateData()); !errors.As(err, &stale) {
+		t.Errorf("MembershipService.UpdateMembership() error = %v, want *xone.ErrStaleVersion", err)
+	}
+}
+
+func TestPersonService_Patch(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	harry, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", Phone: "1234", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email, dob := "harry.potter@hogwarts.co.uk", time.Time{}
+	if err := personService.Patch(ctx, harry.PID, xone.PatchPersonData{Email: &email, DateOfBirth: &dob}); err != nil {
+		t.Fatalf("PersonService.Patch() error = %v", err)
+	}
+	got, _, err := personService.Find(ctx, harry.PID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Email != email || got.FirstName != "Harry" || got.Phone != "1234" || got.Version != harry.Version+1 {
+		t.Errorf("PersonService.Patch() = %v, want only the email to be changed", got)
+	}
+
+	timeline, err := sqlite.NewHistoryService(db).Timeline(ctx, harry.PID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := false
+	for _, c := range timeline {
+		recorded = recorded || !c.Created && len(c.Fields) == 1 && c.Fields[0].Field == "email"
+	}
+	if !recorded {
+		t.Errorf("HistoryService.Timeline() = %v, want the email change to be recorded", timeline)
+	}
+
+	phone := ""
+	var stale *xone.ErrStaleVersion
+	if err := personService.Patch(ctx, harry.PID, xone.PatchPersonData{Version: harry.Version, Phone: &phone}); !errors.As(err, &stale) {
+		t.Errorf("PersonService.Patch() error = %v, want *xone.ErrStaleVersion", err)
+	}
+	var invalid *xone.ValidationError
+	if err := personService.Patch(ctx, harry.PID, xone.PatchPersonData{LastName: &phone}); !errors.As(err, &invalid) {
+		t.Errorf("PersonService.Patch() error = %v, want *xone.ValidationError", err)
+	}
+	var notFound *xone.ErrNotFound
+	if err := personService.Patch(ctx, "unknown", xone.PatchPersonData{Phone: &phone}); !errors.As(err, &notFound) {
+		t.Errorf("PersonService.Patch() error = %v, want *xone.ErrNotFound", err)
+	}
+}
+
+func TestMembershipService_Terminate(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	membershipService := sqlite.NewMembershipService(db)
+
+	mt, err := membershipService.CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	effectiveFrom := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	p, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID, EffectiveFrom: effectiveFrom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := p.Memberships[0].ID
+
+	invalid := []xone.TerminateMembershipData{
+		{EndDate: effectiveFrom, Reason: "boredom"},
+		{Reason: xone.TerminationResignation},
+		{EndDate: effectiveFrom.AddDate(0, 0, -1), Reason: xone.TerminationResignation},
+	}
+	for _, data := range invalid {
+		if err := membershipService.TerminateMembership(ctx, id, data); err == nil {
+			t.Errorf("MembershipService.TerminateMembership(%v) error = nil, want an error", data)
+		}
+	}
+
+	endDate := time.Date(2021, time.December, 31, 0, 0, 0, 0, time.UTC)
+	if err := membershipService.TerminateMembership(ctx, id, xone.TerminateMembershipData{EndDate: endDate, Reason: xone.TerminationDeath}); err != nil {
+		t.Fatalf("MembershipService.TerminateMembership() error = %v", err)
+	}
+
+	got, _, err := personService.Find(ctx, p.PID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := got.Memberships[0]; !m.EndDate.Equal(endDate) || m.TerminationReason != xone.TerminationDeath {
+		t.Errorf("Membership = %v, want it to end on %v", m, endDate)
+	}
+	if m := got.Membership(endDate.AddDate(0, 0, 1)); m != nil {
+		t.Errorf("Person.Membership() = %v, want nil after the end date", m)
+	}
+
+	filter := xone.PersonFilter{MembershipTypeID: mt.ID, Today: endDate.AddDate(0, 0, 1)}
+	if persons, _, err := personService.FindMany(ctx, filter); err != nil || len(persons) != 0 {
+		t.Errorf("PersonService.FindMany() = %v, %v, want no persons with an ended membership", persons, err)
+	}
+	filter.Today = endDate
+	if persons, _, err := personService.FindMany(ctx, filter); err != nil || len(persons) != 1 {
+		t.Errorf("PersonService.FindMany() = %v, %v, want the person on the last day of their membership", persons, err)
+	}
+
+	if err := membershipService.ReinstateMembership(ctx, id); err != nil {
+		t.Fatalf("MembershipService.ReinstateMembership() error = %v", err)
+	}
+	if got, _, err := personService.Find(ctx, p.PID); err != nil || got.Memberships[0].Terminated() {
+		t.Errorf("PersonService.Find() = %v, %v, want a reinstated membership", got, err)
+	}
+
+	timeline, err := sqlite.NewHistoryService(db).Timeline(ctx, p.PID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ended bool
+	for _, c := range timeline {
+		for _, f := range c.Fields {
+			if f.Field == "end_date" && f.New == "2021-12-31" {
+				ended = true
+			}
+		}
+	}
+	if !ended {
+		t.Errorf("HistoryService.Timeline() = %v, want the termination to be recorded", timeline)
+	}
+}
+
+func TestFeeService(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	feeService := sqlite.NewFeeService(db)
+	membershipService := sqlite.NewMembershipService(db)
+
+	mt, err := membershipService.CreateMembershipType(ctx, "adult")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fee, err := feeService.CreateFee(ctx, xone.CreateFeeData{
+		MembershipTypeID: mt.ID,
+		Amount:           12000,
+		Period:           xone.BillingAnnual,
+		ValidFrom:        time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ValidUntil:       time.Date(2021, time.December, 31, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("FeeService.CreateFee() error = %v", err)
+	}
+
+	var overlapping *xone.ErrOverlappingFee
+	if _, err := feeService.CreateFee(ctx, xone.CreateFeeData{MembershipTypeID: mt.ID, Amount: 1000, Period: xone.BillingMonthly, ValidFrom: time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)}); !errors.As(err, &overlapping) {
+		t.Errorf("FeeService.CreateFee() error = %v, want *xone.ErrOverlappingFee", err)
+	}
+	var invalidPeriod *xone.ErrInvalidBillingPeriod
+	if _, err := feeService.CreateFee(ctx, xone.CreateFeeData{MembershipTypeID: mt.ID, Period: "weekly", ValidFrom: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)}); !errors.As(err, &invalidPeriod) {
+		t.Errorf("FeeService.CreateFee() error = %v, want *xone.ErrInvalidBillingPeriod", err)
+	}
+	if _, err := feeService.CreateFee(ctx, xone.CreateFeeData{MembershipTypeID: mt.ID, Amount: 1000, Period: xone.BillingMonthly, ValidFrom: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatalf("FeeService.CreateFee() error = %v", err)
+	}
+
+	fees, err := feeService.FindFees(ctx, mt.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fees) != 2 || !reflect.DeepEqual(fees[0], fee) {
+		t.Errorf("FeeService.FindFees() = %v, want two fees starting with %v", fees, fee)
+	}
+	if membershipTypes, err := membershipService.FindAllMembershipTypes(ctx); err != nil || len(membershipTypes[0].Fees) != 2 {
+		t.Errorf("MembershipService.FindAllMembershipTypes() = %v, %v, want the fees to be loaded", membershipTypes, err)
+	}
+
+	p, err := sqlite.NewPersonService(db).Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID, EffectiveFrom: time.Date(2021, time.July, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dues, err := feeService.CalculateDues(ctx, p.PID, time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, time.March, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("FeeService.CalculateDues() error = %v", err)
+	}
+	if want := int64(6049 + 3000); dues.Total != want {
+		t.Errorf("FeeService.CalculateDues() total = %v, want %v", dues.Total, want)
+	}
+
+	if err := feeService.DeleteFee(ctx, fee.ID); err != nil {
+		t.Fatalf("FeeService.DeleteFee() error = %v", err)
+	}
+	if fees, err := feeService.FindFees(ctx, mt.ID); err != nil || len(fees) != 1 {
+		t.Errorf("FeeService.FindFees() = %v, %v, want one fee after deleting", fees, err)
+	}
+}
+
+func TestPaymentService(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	paymentService := sqlite.NewPaymentService(db)
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	harry, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ron, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Ron", LastName: "Weasley", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []xone.CreateLedgerEntryData{
+		{PID: harry.PID, Kind: xone.LedgerCharge, Date: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), Amount: 12000, Description: "Dues 2022"},
+		{PID: harry.PID, Kind: xone.LedgerPayment, Date: time.Date(2022, time.February, 1, 0, 0, 0, 0, time.UTC), Amount: 5000, Method: xone.PaymentBankTransfer, Reference: "Dues 2022"},
+		{PID: harry.PID, Kind: xone.LedgerCharge, Date: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC), Amount: 12000, Description: "Dues 2023"},
+		{PID: ron.PID, Kind: xone.LedgerCharge, Date: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), Amount: 6000, Description: "Dues 2022"},
+		{PID: ron.PID, Kind: xone.LedgerPayment, Date: time.Date(2022, time.January, 15, 0, 0, 0, 0, time.UTC), Amount: 6000, Method: xone.PaymentCash},
+	}
+	for _, data := range entries {
+		if _, err := paymentService.CreateLedgerEntry(ctx, data); err != nil {
+			t.Fatalf("PaymentService.CreateLedgerEntry() error = %v", err)
+		}
+	}
+
+	invalid := []xone.CreateLedgerEntryData{
+		{PID: harry.PID, Kind: xone.LedgerPayment, Amount: 100, Method: "cheque"},
+		{PID: harry.PID, Kind: xone.LedgerCharge, Amount: 0},
+		{PID: harry.PID, Kind: "refund", Amount: 100},
+		{PID: "unknown", Kind: xone.LedgerCharge, Amount: 100},
+	}
+	for _, data := range invalid {
+		if _, err := paymentService.CreateLedgerEntry(ctx, data); err == nil {
+			t.Errorf("PaymentService.CreateLedgerEntry(%v) error = nil, want an error", data)
+		}
+	}
+
+	if got, err := paymentService.FindLedgerEntries(ctx, harry.PID); err != nil || len(got) != 3 || xone.Balance(got) != 19000 {
+		t.Errorf("PaymentService.FindLedgerEntries() = %v, %v, want three entries", got, err)
+	}
+	if balance, err := paymentService.Balance(ctx, harry.PID); err != nil || balance != 19000 {
+		t.Errorf("PaymentService.Balance() = %v, %v, want %v", balance, err, 19000)
+	}
+
+	arrears, err := paymentService.FindArrears(ctx, time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("PaymentService.FindArrears() error = %v", err)
+	}
+	if len(arrears) != 1 || arrears[0].Person.PID != harry.PID || arrears[0].Balance != 7000 {
+		t.Errorf("PaymentService.FindArrears() = %v, want only Harry owing %v", arrears, 7000)
+	}
+
+	var openBalance *xone.ErrOpenBalance
+	if err := personService.Delete(ctx, harry.PID); !errors.As(err, &openBalance) {
+		t.Errorf("PersonService.Delete() error = %v, want *xone.ErrOpenBalance", err)
+	}
+	if err := personService.Delete(ctx, ron.PID); err != nil {
+		t.Errorf("PersonService.Delete() error = %v, want nil for a settled balance", err)
+	}
+}
+
+func TestPaymentService_ChargeDues(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	paymentService := sqlite.NewPaymentService(db)
+	mandateService := sqlite.NewMandateService(db)
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqlite.NewFeeService(db).CreateFee(ctx, xone.CreateFeeData{MembershipTypeID: mt.ID, Amount: 12000, Period: xone.BillingAnnual, ValidFrom: date(2022, time.January, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	harry, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID, EffectiveFrom: date(2022, time.January, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ron, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Ron", LastName: "Weasley", MembershipTypeID: mt.ID, EffectiveFrom: date(2022, time.January, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var invalid *xone.ErrInvalid
+	if _, err := paymentService.ChargeDues(ctx, date(2022, time.December, 31), date(2022, time.January, 1)); !errors.As(err, &invalid) {
+		t.Errorf("PaymentService.ChargeDues() error = %v, want *xone.ErrInvalid", err)
+	}
+
+	// The first half of the year is 181 of 365 days.
+	charges, err := paymentService.ChargeDues(ctx, date(2022, time.January, 1), date(2022, time.June, 30))
+	if err != nil {
+		t.Fatalf("PaymentService.ChargeDues() error = %v", err)
+	}
+	if len(charges) != 2 || charges[0].Amount != 5951 || !charges[0].Date.Equal(date(2022, time.June, 30)) {
+		t.Errorf("PaymentService.ChargeDues() = %v, want two charges of %v", charges, 5951)
+	}
+
+	// Charging the whole year only charges the second half.
+	charges, err = paymentService.ChargeDues(ctx, date(2022, time.January, 1), date(2022, time.December, 31))
+	if err != nil {
+		t.Fatalf("PaymentService.ChargeDues() error = %v", err)
+	}
+	if len(charges) != 2 || charges[0].Amount != 6049 || !charges[0].Date.Equal(date(2022, time.December, 31)) || charges[0].Reference != "DUES-20220701-20221231" {
+		t.Errorf("PaymentService.ChargeDues() = %v, want two charges of %v for the second half", charges, 6049)
+	}
+	if charges, err := paymentService.ChargeDues(ctx, date(2022, time.January, 1), date(2022, time.December, 31)); err != nil || len(charges) != 0 {
+		t.Errorf("PaymentService.ChargeDues() = %v, %v, want no charges the second time", charges, err)
+	}
+
+	if _, err := mandateService.SaveMandate(ctx, harry.PID, xone.SaveMandateData{IBAN: "DE89370400440532013000", Reference: "M-1", SignedOn: date(2022, time.January, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mandateService.SaveMandate(ctx, ron.PID, xone.SaveMandateData{IBAN: "GB29NWBK60161331926819", Reference: "M-2", SignedOn: date(2023, time.February, 1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	debits, err := mandateService.FindDirectDebits(ctx, date(2023, time.January, 15))
+	if err != nil {
+		t.Fatalf("MandateService.FindDirectDebits() error = %v", err)
+	}
+	if len(debits) != 1 || debits[0].Person.PID != harry.PID || debits[0].Mandate.Reference != "M-1" || debits[0].Amount != 12000 {
+		t.Errorf("MandateService.FindDirectDebits() = %v, want only Harry's dues of %v", debits, 12000)
+	}
+}
+
+func TestMandateService(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	paymentService := sqlite.NewPaymentService(db)
+	mandateService := sqlite.NewMandateService(db)
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	harry, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ron, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Ron", LastName: "Weasley", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signedOn := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var invalidIBAN *xone.ErrInvalidIBAN
+	if _, err := mandateService.SaveMandate(ctx, harry.PID, xone.SaveMandateData{IBAN: "DE89370400440532013001", Reference: "M-1", SignedOn: signedOn}); !errors.As(err, &invalidIBAN) {
+		t.Errorf("MandateService.SaveMandate() error = %v, want *xone.ErrInvalidIBAN", err)
+	}
+
+	mandate, err := mandateService.SaveMandate(ctx, harry.PID, xone.SaveMandateData{IBAN: "de89 3704 0044 0532 0130 00", BIC: "cobadeff", Reference: "M-1", SignedOn: signedOn})
+	if err != nil {
+		t.Fatalf("MandateService.SaveMandate() error = %v", err)
+	}
+	if mandate.IBAN != "DE89370400440532013000" || mandate.BIC != "COBADEFF" || mandate.SequenceType() != "FRST" {
+		t.Errorf("MandateService.SaveMandate() = %+v, want a normalized first mandate", mandate)
+	}
+	if _, err := mandateService.SaveMandate(ctx, ron.PID, xone.SaveMandateData{IBAN: "DE89370400440532013000", Reference: "M-1", SignedOn: signedOn}); err == nil {
+		t.Error("MandateService.SaveMandate() error = nil, want an error for a duplicate reference")
+	}
+
+	if _, err := paymentService.CreateLedgerEntry(ctx, xone.CreateLedgerEntryData{PID: harry.PID, Kind: xone.LedgerCharge, Date: signedOn, Amount: 12000}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := paymentService.CreateLedgerEntry(ctx, xone.CreateLedgerEntryData{PID: ron.PID, Kind: xone.LedgerCharge, Date: signedOn, Amount: 6000}); err != nil {
+		t.Fatal(err)
+	}
+
+	collectionDate := time.Date(2022, time.February, 1, 0, 0, 0, 0, time.UTC)
+	debits, err := mandateService.FindDirectDebits(ctx, collectionDate)
+	if err != nil {
+		t.Fatalf("MandateService.FindDirectDebits() error = %v", err)
+	}
+	if len(debits) != 1 || debits[0].Person.PID != harry.PID || debits[0].Amount != 12000 {
+		t.Fatalf("MandateService.FindDirectDebits() = %v, want only Harry owing %v", debits, 12000)
+	}
+
+	if err := mandateService.BookDirectDebits(ctx, collectionDate, debits); err != nil {
+		t.Fatalf("MandateService.BookDirectDebits() error = %v", err)
+	}
+	if balance, err := paymentService.Balance(ctx, harry.PID); err != nil || balance != 0 {
+		t.Errorf("PaymentService.Balance() = %v, %v, want %v", balance, err, 0)
+	}
+	if mandate, _, err := mandateService.FindMandate(ctx, harry.PID); err != nil || mandate.SequenceType() != "RCUR" {
+		t.Errorf("MandateService.FindMandate() = %+v, %v, want a recurring mandate", mandate, err)
+	}
+
+	mandate, err = mandateService.SaveMandate(ctx, harry.PID, xone.SaveMandateData{IBAN: "GB29NWBK60161331926819", Reference: "M-1", SignedOn: collectionDate})
+	if err != nil {
+		t.Fatalf("MandateService.SaveMandate() error = %v", err)
+	}
+	if mandate.SequenceType() != "FRST" {
+		t.Errorf("MandateService.SaveMandate() sequence type = %v, want FRST after changing the IBAN", mandate.SequenceType())
+	}
+
+	if err := mandateService.DeleteMandate(ctx, harry.PID); err != nil {
+		t.Errorf("MandateService.DeleteMandate() error = %v", err)
+	}
+	if _, found, err := mandateService.FindMandate(ctx, harry.PID); err != nil || found {
+		t.Errorf("MandateService.FindMandate() found = %v, %v, want false", found, err)
+	}
+}
+
+func TestInvoiceService(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	personService.Retention = 0
+	invoiceService := sqlite.NewInvoiceService(db)
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	harry, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", Street: "Privet Drive", HouseNumber: "4", City: "Little Whinging", MembershipTypeID: mt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	items := []xone.InvoiceItem{{Description: "Membership fee", Amount: 12000}}
+
+	tests := []struct {
+		data    xone.CreateInvoiceData
+		want    string
+		wantErr bool
+	}{
+		{data: xone.CreateInvoiceData{PID: harry.PID, Kind: xone.InvoiceMembershipFees, IssuedOn: date(2022, time.January, 3), Items: items}, want: "INV-2022-0001"},
+		{data: xone.CreateInvoiceData{PID: harry.PID, Kind: xone.InvoiceMembershipFees, IssuedOn: date(2022, time.February, 1), Items: items}, want: "INV-2022-0002"},
+		{data: xone.CreateInvoiceData{PID: harry.PID, Kind: xone.InvoiceDonationReceipt, IssuedOn: date(2022, time.March, 1), Items: items}, want: "REC-2022-0001"},
+		{data: xone.CreateInvoiceData{PID: harry.PID, Kind: xone.InvoiceMembershipFees, IssuedOn: date(2023, time.January, 2), Items: items}, want: "INV-2023-0001"},
+		{data: xone.CreateInvoiceData{PID: "unknown", Kind: xone.InvoiceMembershipFees, IssuedOn: date(2022, time.April, 1), Items: items}, wantErr: true},
+		{data: xone.CreateInvoiceData{PID: harry.PID, Kind: "quote", IssuedOn: date(2022, time.April, 1), Items: items}, wantErr: true},
+		{data: xone.CreateInvoiceData{PID: harry.PID, Kind: xone.InvoiceMembershipFees, IssuedOn: date(2022, time.April, 1)}, wantErr: true},
+		{data: xone.CreateInvoiceData{PID: harry.PID, Kind: xone.InvoiceMembershipFees, IssuedOn: date(2022, time.April, 1), Items: items}, want: "INV-2022-0003"},
+	}
+	for _, tt := range tests {
+		got, err := invoiceService.CreateInvoice(ctx, tt.data)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("InvoiceService.CreateInvoice(%v) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+		}
+		if got.Number != tt.want {
+			t.Errorf("InvoiceService.CreateInvoice() number = %v, want %v", got.Number, tt.want)
+		}
+	}
+
+	update := harry.ToUpdateData()
+	update.City = "Godric's Hollow"
+	if err := personService.Update(ctx, harry.PID, update); err != nil {
+		t.Fatal(err)
+	}
+
+	invoice, found, err := invoiceService.FindInvoice(ctx, "INV-2022-0001")
+	if err != nil || !found {
+		t.Fatalf("InvoiceService.FindInvoice() = %v, %v, want the invoice", found, err)
+	}
+	if invoice.Recipient.City != "Little Whinging" || invoice.MembershipType != "active" || invoice.Total() != 12000 {
+		t.Errorf("InvoiceService.FindInvoice() = %+v, want the address and membership at the time of issue", invoice)
+	}
+
+	if invoices, err := invoiceService.FindInvoices(ctx, harry.PID); err != nil || len(invoices) != 5 || invoices[0].Number != "INV-2023-0001" {
+		t.Errorf("InvoiceService.FindInvoices() = %v, %v, want five documents, the most recent first", invoices, err)
+	}
+
+	if err := personService.Delete(ctx, harry.PID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := personService.Purge(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if invoice, found, err := invoiceService.FindInvoice(ctx, "INV-2022-0001"); err != nil || !found || invoice.PID != "" {
+		t.Errorf("InvoiceService.FindInvoice() = %+v, %v, %v, want the invoice to outlive the purged person", invoice, found, err)
+	}
+}
+
+func TestHouseholdService(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	householdService := sqlite.NewHouseholdService(db)
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "family")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var weasleys []xone.Person
+	for _, name := range []string{"Arthur", "Molly", "Ron"} {
+		p, err := personService.Create(ctx, xone.CreatePersonData{FirstName: name, LastName: "Weasley", Street: "The Burrow", City: "Ottery St Catchpole", MembershipTypeID: mt.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		weasleys = append(weasleys, p)
+	}
+	arthur, molly, ron := weasleys[0], weasleys[1], weasleys[2]
+
+	household, err := householdService.CreateHousehold(ctx, xone.CreateHouseholdData{PrimaryContact: arthur.PID})
+	if err != nil {
+		t.Fatalf("HouseholdService.CreateHousehold() error = %v", err)
+	}
+	if household.Name != "Weasley" || household.City != "Ottery St Catchpole" || household.PrimaryContact != arthur.PID || !household.IsMember(arthur.PID) {
+		t.Errorf("HouseholdService.CreateHousehold() = %+v, want Arthur's household at his address", household)
+	}
+
+	for _, p := range []xone.Person{molly, ron} {
+		if err := householdService.AddHouseholdMember(ctx, household.ID, p.PID); err != nil {
+			t.Fatalf("HouseholdService.AddHouseholdMember() error = %v", err)
+		}
+	}
+
+	var householdMember *xone.ErrHouseholdMember
+	if _, err := householdService.CreateHousehold(ctx, xone.CreateHouseholdData{PrimaryContact: ron.PID}); !errors.As(err, &householdMember) {
+		t.Errorf("HouseholdService.CreateHousehold() error = %v, want *xone.ErrHouseholdMember", err)
+	}
+
+	var primaryContact *xone.ErrPrimaryContact
+	if err := householdService.RemoveHouseholdMember(ctx, household.ID, arthur.PID); !errors.As(err, &primaryContact) {
+		t.Errorf("HouseholdService.RemoveHouseholdMember() error = %v, want *xone.ErrPrimaryContact", err)
+	}
+
+	data := household.ToUpdateData()
+	data.PrimaryContact = "unknown"
+	var notMember *xone.ErrNotHouseholdMember
+	if err := householdService.UpdateHousehold(ctx, household.ID, data); !errors.As(err, &notMember) {
+		t.Errorf("HouseholdService.UpdateHousehold() error = %v, want *xone.ErrNotHouseholdMember", err)
+	}
+
+	data.PrimaryContact = molly.PID
+	data.Street = "Shell Cottage"
+	data.City = "Tinworth"
+	data.PropagateAddress = true
+	if err := householdService.UpdateHousehold(ctx, household.ID, data); err != nil {
+		t.Fatalf("HouseholdService.UpdateHousehold() error = %v", err)
+	}
+	for _, p := range weasleys {
+		if got, _, err := personService.Find(ctx, p.PID); err != nil || got.Street != "Shell Cottage" || got.City != "Tinworth" {
+			t.Errorf("PersonService.Find(%s) = %+v, %v, want the propagated address", p.FirstName, got, err)
+		}
+	}
+
+	if err := householdService.RemoveHouseholdMember(ctx, household.ID, arthur.PID); err != nil {
+		t.Errorf("HouseholdService.RemoveHouseholdMember() error = %v", err)
+	}
+	if err := personService.Delete(ctx, ron.PID); err != nil {
+		t.Fatal(err)
+	}
+
+	households, err := householdService.FindHouseholds(ctx)
+	if err != nil {
+		t.Fatalf("HouseholdService.FindHouseholds() error = %v", err)
+	}
+	if len(households) != 1 || len(households[0].Members) != 1 || households[0].PrimaryContact != molly.PID {
+		t.Errorf("HouseholdService.FindHouseholds() = %+v, want Molly as the only member who has not been deleted", households)
+	}
+	if _, found, err := householdService.FindHouseholdOfPerson(ctx, arthur.PID); err != nil || found {
+		t.Errorf("HouseholdService.FindHouseholdOfPerson() found = %v, %v, want false after leaving", found, err)
+	}
+
+	if err := householdService.DeleteHousehold(ctx, household.ID); err != nil {
+		t.Fatalf("HouseholdService.DeleteHousehold() error = %v", err)
+	}
+	if _, found, err := personService.Find(ctx, molly.PID); err != nil || !found {
+		t.Errorf("PersonService.Find() found = %v, %v, want members to be kept", found, err)
+	}
+}
+
+func TestHouseholdService_archivedPrimaryContact(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	householdService := sqlite.NewHouseholdService(db)
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "family")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var potters []xone.Person
+	for _, name := range []string{"James", "Lily"} {
+		p, err := personService.Create(ctx, xone.CreatePersonData{FirstName: name, LastName: "Potter", MembershipTypeID: mt.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		potters = append(potters, p)
+	}
+	james, lily := potters[0], potters[1]
+
+	household, err := householdService.CreateHousehold(ctx, xone.CreateHouseholdData{PrimaryContact: james.PID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := householdService.AddHouseholdMember(ctx, household.ID, lily.PID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := personService.Delete(ctx, james.PID); err != nil {
+		t.Fatal(err)
+	}
+	household, _, err = householdService.FindHousehold(ctx, household.ID)
+	if err != nil {
+		t.Fatalf("HouseholdService.FindHousehold() error = %v", err)
+	}
+	if household.PrimaryContact != "" || len(household.Members) != 1 {
+		t.Errorf("HouseholdService.FindHousehold() = %+v, want no primary contact after archiving", household)
+	}
+
+	data := household.ToUpdateData()
+	data.Name = "Potter family"
+	if err := householdService.UpdateHousehold(ctx, household.ID, data); err != nil {
+		t.Errorf("HouseholdService.UpdateHousehold() error = %v, want nil without a primary contact", err)
+	}
+
+	if err := personService.Restore(ctx, james.PID); err != nil {
+		t.Fatal(err)
+	}
+	if household, _, err := householdService.FindHousehold(ctx, household.ID); err != nil || household.PrimaryContact != james.PID || household.Name != "Potter family" {
+		t.Errorf("HouseholdService.FindHousehold() = %+v, %v, want James back as primary contact", household, err)
+	}
+}
+
+func TestFieldService(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+	personService := sqlite.NewPersonService(db)
+	fieldService := sqlite.NewFieldService(db)
+
+	mt, err := sqlite.NewMembershipService(db).CreateMembershipType(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shirtSize, err := fieldService.CreateFieldDefinition(ctx, xone.CreateFieldDefinitionData{Name: "shirt_size", Type: xone.FieldEnum, Required: true, Options: []string{"S", "M", "L"}})
+	if err != nil {
+		t.Fatalf("FieldService.CreateFieldDefinition() error = %v", err)
+	}
+	if _, err := fieldService.CreateFieldDefinition(ctx, xone.CreateFieldDefinitionData{Name: "license", Type: xone.FieldNumber}); err != nil {
+		t.Fatalf("FieldService.CreateFieldDefinition() error = %v", err)
+	}
+
+	var exists *xone.ErrFieldExists
+	if _, err := fieldService.CreateFieldDefinition(ctx, xone.CreateFieldDefinitionData{Name: "Shirt_Size", Type: xone.FieldText}); !errors.As(err, &exists) {
+		t.Errorf("FieldService.CreateFieldDefinition() error = %v, want *xone.ErrFieldExists", err)
+	}
+	var invalidType *xone.ErrInvalidFieldType
+	if _, err := fieldService.CreateFieldDefinition(ctx, xone.CreateFieldDefinitionData{Name: "diet", Type: "list"}); !errors.As(err, &invalidType) {
+		t.Errorf("FieldService.CreateFieldDefinition() error = %v, want *xone.ErrInvalidFieldType", err)
+	}
+	if _, err := fieldService.CreateFieldDefinition(ctx, xone.CreateFieldDefinitionData{Name: "diet", Type: xone.FieldEnum}); err == nil {
+		t.Error("FieldService.CreateFieldDefinition() succeeded for an enum without options")
+	}
+
+	defs, err := fieldService.FindFieldDefinitions(ctx)
+	if err != nil {
+		t.Fatalf("FieldService.FindFieldDefinitions() error = %v", err)
+	}
+	if len(defs) != 2 || defs[0].Name != "license" || !reflect.DeepEqual(defs[1], shirtSize) {
+		t.Errorf("FieldService.FindFieldDefinitions() = %+v, want license and shirt_size", defs)
+	}
+
+	var required *xone.ErrFieldRequired
+	if _, err := personService.Create(ctx, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID}); !errors.As(err, &required) {
+		t.Errorf("PersonService.Create() error = %v, want *xone.ErrFieldRequired", err)
+	}
+
+	harry, err := personService.Create(ctx, xone.CreatePersonData{
+		FirstName:        "Harry",
+		LastName:         "Potter",
+		MembershipTypeID: mt.ID,
+		CustomFields:     map[string]string{"shirt_size": "m", "license": "0042"},
+	})
+	if err != nil {
+		t.Fatalf("PersonService.Create() error = %v", err)
+	}
+	want := map[string]string{"shirt_size": "M", "license": "42"}
+	if !reflect.DeepEqual(harry.CustomFields, want) {
+		t.Errorf("PersonService.Create() custom fields = %v, want %v", harry.CustomFields, want)
+	}
+
+	data := harry.ToUpdateData()
+	data.CustomFields = map[string]string{"license": ""}
+	if err := personService.Update(ctx, harry.PID, data); err != nil {
+		t.Fatalf("PersonService.Update() error = %v", err)
+	}
+	if got, _, err := personService.Find(ctx, harry.PID); err != nil || !reflect.DeepEqual(got.CustomFields, map[string]string{"shirt_size": "M"}) {
+		t.Errorf("PersonService.Find() custom fields = %v, %v, want only the shirt size", got.CustomFields, err)
+	}
+	data.Version++
+
+	var invalidValue *xone.ErrInvalidFieldValue
+	data.CustomFields = map[string]string{"shirt_size": "XL"}
+	if err := personService.Update(ctx, harry.PID, data); !errors.As(err, &invalidValue) {
+		t.Errorf("PersonService.Update() error = %v, want *xone.ErrInvalidFieldValue", err)
+	}
+	var unknown *xone.ErrUnknownField
+	data.CustomFields = map[string]string{"diet": "vegan"}
+	if err := personService.Update(ctx, harry.PID, data); !errors.As(err, &unknown) {
+		t.Errorf("PersonService.Update() error = %v, want *xone.ErrUnknownField", err)
+	}
+
+	if err := fieldService.DeleteFieldDefinition(ctx, shirtSize.ID); err != nil {
+		t.Fatalf("FieldService.DeleteFieldDefinition() error = %v", err)
+	}
+	if persons, err := personService.FindAll(ctx); err != nil || len(persons) != 1 || persons[0].CustomFields != nil {
+		t.Errorf("PersonService.FindAll() = %+v, %v, want Harry without custom fields", persons, err)
+	}
+}
+
+func TestContract(t *testing.T) {
+	xonetest.Run(t, func(t *testing.T) xonetest.Backend {
+		db := MustOpenDB(t)
+		t.Cleanup(func() { MustCloseDB(t, db) })
+
+		userService, err := sqlite.NewUserService(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return xonetest.Backend{
+			Persons:     sqlite.NewPersonService(db),
+			Users:       userService,
+			Memberships: sqlite.NewMembershipService(db),
+			History:     sqlite.NewHistoryService(db),
+		}
+	})
 }
@@ -0,0 +1,480 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var _ xone.PaymentService = (*PaymentService)(nil)
+
+type PaymentService struct {
+	db  *sql.DB
+	Now func() time.Time
+}
+
+func NewPaymentService(db *sql.DB) *PaymentService {
+	return &PaymentService{
+		db:  db,
+		Now: time.Now,
+	}
+}
+
+func (s *PaymentService) FindLedgerEntries(ctx context.Context, pid string) ([]xone.LedgerEntry, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	entries, err := findLedgerEntries(ctx, tx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, tx.Commit()
+}
+
+// CreateLedgerEntry records a charge or a payment. Entries without a date are
+// dated today.
+func (s *PaymentService) CreateLedgerEntry(ctx context.Context, data xone.CreateLedgerEntryData) (xone.LedgerEntry, error) {
+	if !data.Kind.Valid() {
+		return xone.LedgerEntry{}, &xone.ErrInvalid{Msg: fmt.Sprintf(`"%s" is not a valid kind of ledger entry`, data.Kind)}
+	}
+	if data.Amount <= 0 {
+		return xone.LedgerEntry{}, &xone.ErrInvalid{Msg: "amount must be positive"}
+	}
+	if data.Kind == xone.LedgerPayment && !data.Method.Valid() {
+		return xone.LedgerEntry{}, &xone.ErrInvalidPaymentMethod{Method: data.Method}
+	}
+	if data.Kind == xone.LedgerCharge && data.Method != "" {
+		return xone.LedgerEntry{}, &xone.ErrInvalid{Msg: "charges have no payment method"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.LedgerEntry{}, err
+	}
+	defer tx.Rollback()
+
+	person, found, err := findPerson(ctx, tx, data.PID)
+	if err != nil {
+		return xone.LedgerEntry{}, err
+	} else if !found {
+		return xone.LedgerEntry{}, &xone.ErrNotFound{Entity: "person", ID: data.PID}
+	}
+
+	now := s.Now().UTC().Truncate(time.Second)
+	entry := xone.LedgerEntry{
+		PID:         person.PID,
+		Kind:        data.Kind,
+		Date:        data.Date,
+		Amount:      data.Amount,
+		Description: data.Description,
+		Method:      data.Method,
+		Reference:   data.Reference,
+		CreatedAt:   now,
+	}
+	if entry.Date.IsZero() {
+		y, m, d := now.Date()
+		entry.Date = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	if entry.ID, err = createLedgerEntry(ctx, tx, person.ID, entry); err != nil {
+		return xone.LedgerEntry{}, err
+	}
+
+	if err := createAuditEntry(ctx, tx, now, xone.AuditCreate, xone.AuditLedgerEntry, strconv.Itoa(entry.ID), nil, entry); err != nil {
+		return xone.LedgerEntry{}, err
+	}
+
+	return entry, tx.Commit()
+}
+
+func (s *PaymentService) Balance(ctx context.Context, pid string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	person, found, err := findPerson(ctx, tx, pid)
+	if err != nil {
+		return 0, err
+	} else if !found {
+		return 0, &xone.ErrNotFound{Entity: "person", ID: pid}
+	}
+
+	balance, err := findBalance(ctx, tx, person.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, tx.Commit()
+}
+
+func (s *PaymentService) FindArrears(ctx context.Context, today time.Time) ([]xone.Arrear, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	arrears, err := findArrears(ctx, tx, today)
+	if err != nil {
+		return nil, err
+	}
+
+	return arrears, tx.Commit()
+}
+
+// ChargeDues books the dues of all persons as charges. The reference of every
+// charge names the range of dates it covers, see duesReference, which is how
+// the days a person has already been charged for are recognized and left out,
+// even if the ranges of two runs only overlap.
+func (s *PaymentService) ChargeDues(ctx context.Context, from, until time.Time) ([]xone.LedgerEntry, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+	if until.Before(from) {
+		return nil, &xone.ErrInvalid{Msg: "until date lies before from date"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	persons, err := findPersons(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := findAllFees(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	charged, err := findDuesCharges(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.Now().UTC().Truncate(time.Second)
+
+	var entries []xone.LedgerEntry
+	for _, p := range persons {
+		// A person who has been charged for some days within the range gets
+		// a charge for each of the gaps instead.
+		for _, r := range unchargedRanges(dateRange{from: from, until: until}, charged[p.ID]) {
+			dues := duesOf(p, fees, r.from, r.until)
+			if dues.Total <= 0 {
+				continue
+			}
+
+			entry := xone.LedgerEntry{
+				PID:         p.PID,
+				Kind:        xone.LedgerCharge,
+				Date:        r.until,
+				Amount:      dues.Total,
+				Description: fmt.Sprintf("Membership fees %s to %s", r.from.Format(formatDate), r.until.Format(formatDate)),
+				Reference:   duesReference(r),
+				CreatedAt:   now,
+			}
+			if entry.ID, err = createLedgerEntry(ctx, tx, p.ID, entry); err != nil {
+				return nil, err
+			}
+
+			if err := createAuditEntry(ctx, tx, now, xone.AuditCreate, xone.AuditLedgerEntry, strconv.Itoa(entry.ID), nil, entry); err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, tx.Commit()
+}
+
+// dateRange is a range of days, both inclusive.
+type dateRange struct {
+	from, until time.Time
+}
+
+// formatDuesReference is the layout of the dates in the reference of a charge
+// booked by ChargeDues.
+const formatDuesReference = "20060102"
+
+// duesReference returns the reference of the charge for the dues of the given
+// range, e.g. "DUES-20220101-20221231".
+func duesReference(r dateRange) string {
+	return "DUES-" + r.from.Format(formatDuesReference) + "-" + r.until.Format(formatDuesReference)
+}
+
+// parseDuesReference returns the range named by the reference of a charge
+// booked by ChargeDues. The returned bool is false for any other reference.
+func parseDuesReference(reference string) (dateRange, bool) {
+	var from, until string
+	if n, err := fmt.Sscanf(reference, "DUES-%8s-%8s", &from, &until); err != nil || n != 2 {
+		return dateRange{}, false
+	}
+
+	var r dateRange
+	var err error
+	if r.from, err = time.Parse(formatDuesReference, from); err != nil {
+		return dateRange{}, false
+	}
+	if r.until, err = time.Parse(formatDuesReference, until); err != nil {
+		return dateRange{}, false
+	}
+	if duesReference(r) != reference || r.until.Before(r.from) {
+		return dateRange{}, false
+	}
+
+	return r, true
+}
+
+// unchargedRanges returns the parts of r which are not covered by any of the
+// charged ranges, the earliest first.
+func unchargedRanges(r dateRange, charged []dateRange) []dateRange {
+	sorted := make([]dateRange, len(charged))
+	copy(sorted, charged)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].from.Before(sorted[j].from)
+	})
+
+	var gaps []dateRange
+	next := r.from
+	for _, c := range sorted {
+		if c.until.Before(next) {
+			continue
+		}
+		if c.from.After(r.until) {
+			break
+		}
+		if c.from.After(next) {
+			gaps = append(gaps, dateRange{from: next, until: c.from.AddDate(0, 0, -1)})
+		}
+		next = c.until.AddDate(0, 0, 1)
+	}
+	if !next.After(r.until) {
+		gaps = append(gaps, dateRange{from: next, until: r.until})
+	}
+
+	return gaps
+}
+
+// ledgerBalance is the SQL expression which sums up the amounts of ledger
+// entries, see xone.LedgerEntry.Signed.
+const ledgerBalance = `COALESCE(SUM(CASE ledger_entry.kind WHEN 'charge' THEN ledger_entry.amount ELSE -ledger_entry.amount END), 0)`
+
+func findLedgerEntries(ctx context.Context, tx dbtx, pid string) ([]xone.LedgerEntry, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			ledger_entry.id,
+			person.public_id,
+			ledger_entry.kind,
+			ledger_entry.entry_date,
+			ledger_entry.amount,
+			ledger_entry.description,
+			ledger_entry.payment_method,
+			ledger_entry.reference,
+			ledger_entry.created_at
+		FROM
+			ledger_entry
+			JOIN person ON person.id = ledger_entry.person_id
+		WHERE
+			person.public_id = ?
+		ORDER BY
+			ledger_entry.entry_date,
+			ledger_entry.id
+	`, pid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []xone.LedgerEntry
+	for rows.Next() {
+		var e xone.LedgerEntry
+		var date, createdAt string
+		if err := rows.Scan(&e.ID, &e.PID, &e.Kind, &date, &e.Amount, &e.Description, &e.Method, &e.Reference, &createdAt); err != nil {
+			return nil, err
+		}
+
+		if e.Date, err = time.Parse(formatDate, date); err != nil {
+			return nil, err
+		}
+		if e.CreatedAt, err = time.Parse(formatTimestamp, createdAt); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func createLedgerEntry(ctx context.Context, tx dbtx, personID int, e xone.LedgerEntry) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO ledger_entry (
+			person_id,
+			kind,
+			entry_date,
+			amount,
+			description,
+			payment_method,
+			reference,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := stmt.ExecContext(ctx, personID, e.Kind, e.Date.Format(formatDate), e.Amount, e.Description, e.Method, e.Reference, e.CreatedAt.Format(formatTimestamp))
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	id, err := res.LastInsertId()
+
+	return int(id), err
+}
+
+// findBalance returns the balance of the person with the given internal ID,
+// including entries which are dated in the future.
+func findBalance(ctx context.Context, tx dbtx, personID int) (int64, error) {
+	var balance int64
+	err := tx.QueryRowContext(ctx, `SELECT `+ledgerBalance+` FROM ledger_entry WHERE person_id = ?`, personID).Scan(&balance)
+
+	return balance, err
+}
+
+// findDuesCharges returns the ranges of dates every person has been charged
+// for by ChargeDues, keyed by the internal ID of the person.
+func findDuesCharges(ctx context.Context, tx dbtx) (map[int][]dateRange, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			person_id,
+			reference
+		FROM
+			ledger_entry
+		WHERE
+			kind = 'charge'
+			AND reference LIKE 'DUES-%'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	charged := map[int][]dateRange{}
+	for rows.Next() {
+		var personID int
+		var reference string
+		if err := rows.Scan(&personID, &reference); err != nil {
+			return nil, err
+		}
+
+		if r, ok := parseDuesReference(reference); ok {
+			charged[personID] = append(charged[personID], r)
+		}
+	}
+
+	return charged, rows.Err()
+}
+
+func findArrears(ctx context.Context, tx dbtx, today time.Time) ([]xone.Arrear, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			ledger_entry.person_id,
+			`+ledgerBalance+` AS balance
+		FROM
+			ledger_entry
+		WHERE
+			ledger_entry.entry_date <= ?
+		GROUP BY
+			ledger_entry.person_id
+		HAVING
+			balance > 0
+	`, today.Format(formatDate))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := map[int]int64{}
+	for rows.Next() {
+		var personID int
+		var balance int64
+		if err := rows.Scan(&personID, &balance); err != nil {
+			return nil, err
+		}
+
+		balances[personID] = balance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(balances) == 0 {
+		return nil, nil
+	}
+
+	persons, err := queryPersons(ctx, tx, `
+		SELECT
+			id,
+			public_id,
+			first_name,
+			last_name,
+			date_of_birth,
+			email,
+			phone,
+			mobile,
+			street,
+			house_number,
+			zip_code,
+			city,
+			deleted_at,
+			version
+		FROM
+			person
+		WHERE
+			deleted_at = ''
+			AND id IN (
+				SELECT
+					ledger_entry.person_id
+				FROM
+					ledger_entry
+				WHERE
+					ledger_entry.entry_date <= ?
+				GROUP BY
+					ledger_entry.person_id
+				HAVING
+					`+ledgerBalance+` > 0
+			)
+	`, today.Format(formatDate))
+	if err != nil {
+		return nil, err
+	}
+
+	arrears := make([]xone.Arrear, 0, len(persons))
+	for _, p := range persons {
+		arrears = append(arrears, xone.Arrear{Person: p, Balance: balances[p.ID]})
+	}
+	sort.SliceStable(arrears, func(i, j int) bool {
+		return arrears[i].Balance > arrears[j].Balance
+	})
+
+	return arrears, nil
+}
@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_unchargedRanges(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2022, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	year := dateRange{from: day(time.January, 1), until: day(time.December, 31)}
+
+	tests := []struct {
+		name    string
+		charged []dateRange
+		want    []dateRange
+	}{
+		{name: "Nothing charged", charged: nil, want: []dateRange{year}},
+		{name: "Everything charged", charged: []dateRange{year}, want: nil},
+		{
+			name:    "First half charged",
+			charged: []dateRange{{from: day(time.January, 1), until: day(time.June, 30)}},
+			want:    []dateRange{{from: day(time.July, 1), until: day(time.December, 31)}},
+		},
+		{
+			name:    "Gap in the middle",
+			charged: []dateRange{{from: day(time.July, 1), until: day(time.December, 31)}, {from: day(time.January, 1), until: day(time.March, 31)}},
+			want:    []dateRange{{from: day(time.April, 1), until: day(time.June, 30)}},
+		},
+		{
+			name:    "Overlapping charges",
+			charged: []dateRange{{from: day(time.March, 1), until: day(time.May, 31)}, {from: day(time.April, 1), until: day(time.April, 30)}},
+			want:    []dateRange{{from: day(time.January, 1), until: day(time.February, 28)}, {from: day(time.June, 1), until: day(time.December, 31)}},
+		},
+		{
+			name:    "Charges outside the range",
+			charged: []dateRange{{from: day(time.January, 1).AddDate(-1, 0, 0), until: day(time.December, 31).AddDate(-1, 0, 0)}},
+			want:    []dateRange{year},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unchargedRanges(year, tt.charged); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unchargedRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDuesReference(t *testing.T) {
+	want := dateRange{from: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), until: time.Date(2022, time.June, 30, 0, 0, 0, 0, time.UTC)}
+	if got, ok := parseDuesReference(duesReference(want)); !ok || got != want {
+		t.Errorf("parseDuesReference() = %v, %v, want %v, true", got, ok, want)
+	}
+
+	for _, reference := range []string{"", "INV-2022-0001", "DUES-20220101", "DUES-20220101-20221231x", "DUES-20221231-20220101", "DUES-20220230-20221231"} {
+		if _, ok := parseDuesReference(reference); ok {
+			t.Errorf("parseDuesReference(%q) ok = true, want false", reference)
+		}
+	}
+}
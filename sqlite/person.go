@@ -3,6 +3,8 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	uuid "github.com/satori/go.uuid"
@@ -13,9 +15,15 @@ import (
 
 var _ xone.PersonRepository = (*PersonService)(nil)
 
+// DefaultRetention is the time deleted persons are kept in the archive unless
+// a different retention period is configured on the PersonService.
+const DefaultRetention = 90 * 24 * time.Hour
+
 type PersonService struct {
 	db         *sql.DB
 	GenerateID func() string
+	Retention  time.Duration
+	Now        func() time.Time
 }
 
 func NewPersonService(db *sql.DB) *PersonService {
@@ -24,6 +32,8 @@ func NewPersonService(db *sql.DB) *PersonService {
 		GenerateID: func() string {
 			return uuid.NewV4().String()
 		},
+		Retention: DefaultRetention,
+		Now:       time.Now,
 	}
 
 	return &service
@@ -44,6 +54,23 @@ func (ps *PersonService) FindAll(ctx context.Context) ([]xone.Person, error) {
 	return persons, tx.Commit()
 }
 
+// FindMany returns the persons matching the filter along with the number of
+// matching persons before limit and offset are applied.
+func (ps *PersonService) FindMany(ctx context.Context, filter xone.PersonFilter) ([]xone.Person, int, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	persons, n, err := findManyPersons(ctx, tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return persons, n, tx.Commit()
+}
+
 func (ps *PersonService) Find(ctx context.Context, id string) (xone.Person, bool, error) {
 	tx, err := ps.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -60,27 +87,22 @@ func (ps *PersonService) Find(ctx context.Context, id string) (xone.Person, bool
 }
 
 func (ps *PersonService) Create(ctx context.Context, data xone.CreatePersonData) (xone.Person, error) {
-	tx, err := ps.db.BeginTx(ctx, nil)
-	if err != nil {
+	if err := data.Validate(); err != nil {
 		return xone.Person{}, err
 	}
-	defer tx.Rollback()
 
-	person, err := createPerson(ctx, tx, ps.GenerateID(), data)
+	tx, err := ps.db.BeginTx(ctx, nil)
 	if err != nil {
 		return xone.Person{}, err
 	}
+	defer tx.Rollback()
 
-	_, err = createMembership(ctx, tx, xone.CreateMembershipData{
-		PersonID:         person.ID,
-		MembershipTypeID: data.MembershipTypeID,
-		EffectiveFrom:    data.EffectiveFrom,
-	})
+	person, err := createPersonWithMembership(ctx, tx, ps.GenerateID(), data)
 	if err != nil {
 		return xone.Person{}, err
 	}
 
-	if err := attachMemberships(ctx, tx, &person); err != nil {
+	if err := createAuditEntry(ctx, tx, ps.Now(), xone.AuditCreate, xone.AuditPerson, person.PID, nil, person); err != nil {
 		return xone.Person{}, err
 	}
 
@@ -94,55 +116,342 @@ func (ps *PersonService) Delete(ctx context.Context, id string) error {
 	}
 	defer tx.Rollback()
 
-	if err := deletePerson(ctx, tx, id); err != nil {
+	before, found, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "person", ID: id}
+	}
+
+	// Persons are kept as long as they owe money or have a credit.
+	if balance, err := findBalance(ctx, tx, before.ID); err != nil {
+		return err
+	} else if balance != 0 {
+		return &xone.ErrOpenBalance{PID: id, Balance: balance}
+	}
+
+	now := ps.Now()
+	if err := archivePerson(ctx, tx, id, now); err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, now, xone.AuditDelete, xone.AuditPerson, id, before, nil); err != nil {
 		return err
 	}
 
 	return tx.Commit()
 }
 
+// Restore brings back a deleted person.
+func (ps *PersonService) Restore(ctx context.Context, id string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findArchivedPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "deleted person", ID: id}
+	}
+
+	if err := restorePerson(ctx, tx, id); err != nil {
+		return err
+	}
+
+	after, _, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, ps.Now(), xone.AuditUpdate, xone.AuditPerson, id, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge removes all persons for good, including their memberships and their
+// history, who have been deleted longer ago than the retention period.
+func (ps *PersonService) Purge(ctx context.Context) (int, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := ps.Now()
+	pids, err := findPurgeablePersons(ctx, tx, now.Add(-ps.Retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pid := range pids {
+		before, _, err := findArchivedPerson(ctx, tx, pid)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := deletePerson(ctx, tx, pid); err != nil {
+			return 0, err
+		}
+
+		if err := createAuditEntry(ctx, tx, now, xone.AuditDelete, xone.AuditPerson, pid, before, nil); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(pids), tx.Commit()
+}
+
 func (ps *PersonService) Update(ctx context.Context, id string, data xone.UpdatePersonData) error {
+	if err := data.Validate(); err != nil {
+		return err
+	}
+
 	tx, err := ps.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	before, found, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "person", ID: id}
+	}
+
 	if err := updatePerson(ctx, tx, id, data); err != nil {
 		return err
 	}
 
+	current := before
+	if err := saveFieldValues(ctx, tx, &current, data.CustomFields); err != nil {
+		return err
+	}
+
+	after, _, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, ps.Now(), xone.AuditUpdate, xone.AuditPerson, id, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Patch changes only the fields of a person which are set in the data, see
+// xone.PatchPersonData. An empty patch changes nothing.
+func (ps *PersonService) Patch(ctx context.Context, id string, data xone.PatchPersonData) error {
+	if err := data.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, found, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if !found {
+		return &xone.ErrNotFound{Entity: "person", ID: id}
+	}
+
+	if data.Empty() {
+		return nil
+	}
+
+	if err := patchPerson(ctx, tx, id, data); err != nil {
+		return err
+	}
+
+	current := before
+	if err := saveFieldValues(ctx, tx, &current, data.CustomFields); err != nil {
+		return err
+	}
+
+	after, _, err := findPerson(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := createAuditEntry(ctx, tx, ps.Now(), xone.AuditUpdate, xone.AuditPerson, id, before, after); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
 func findPersons(ctx context.Context, tx dbtx) ([]xone.Person, error) {
-	rows, err := tx.QueryContext(ctx, `
-		SELECT
-			id,
-			public_id,
-			first_name,
-			last_name,
-			date_of_birth,
-			email,
-			phone,
-			mobile,
-			street,
-			house_number,
-			zip_code,
-			city
+	persons, _, err := findManyPersons(ctx, tx, xone.PersonFilter{})
+
+	return persons, err
+}
+
+// personSortColumns maps the fields persons can be sorted by to the columns
+// of the query in findManyPersons.
+var personSortColumns = map[xone.PersonSortField]string{
+	"":                       "person.id",
+	xone.SortByID:            "person.id",
+	xone.SortByFirstName:     "person.first_name",
+	xone.SortByLastName:      "person.last_name",
+	xone.SortByDateOfBirth:   "person.date_of_birth",
+	xone.SortByEmail:         "person.email",
+	xone.SortByPhone:         "person.phone",
+	xone.SortByMobile:        "person.mobile",
+	xone.SortByStreet:        "person.street",
+	xone.SortByHouseNumber:   "person.house_number",
+	xone.SortByZipCode:       "person.zip_code",
+	xone.SortByCity:          "person.city",
+	xone.SortByEffectiveFrom: "current_membership.effective_from",
+}
+
+// findManyPersons returns the persons matching the filter and the total number
+// of matching persons, regardless of limit and offset.
+func findManyPersons(ctx context.Context, tx dbtx, filter xone.PersonFilter) ([]xone.Person, int, error) {
+	sortColumn, ok := personSortColumns[filter.SortBy]
+	if !ok {
+		return nil, 0, &xone.ErrInvalid{Msg: fmt.Sprintf("cannot sort persons by %q", filter.SortBy)}
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, 0, &xone.ErrInvalid{Msg: "limit and offset must not be negative"}
+	}
+
+	today := filter.Today
+	if today.IsZero() {
+		today = time.Now()
+	}
+
+	// The current membership of a person is the one which became effective
+	// most recently. Memberships without an effective date are considered to
+	// be the oldest ones. Persons whose current membership has ended do not
+	// match any of the membership criteria.
+	from := `
 		FROM
 			person
-	`)
+			LEFT JOIN membership AS current_membership ON current_membership.id = (
+				SELECT
+					membership.id
+				FROM
+					membership
+				WHERE
+					membership.person_id = person.id
+					AND membership.effective_from <= ?
+				ORDER BY
+					membership.effective_from DESC,
+					membership.id DESC
+				LIMIT 1
+			)
+	`
+	args := []interface{}{today.Format(formatDate)}
+	active := "(current_membership.end_date = '' OR current_membership.end_date >= ?)"
+
+	where := []string{"person.deleted_at = ''"}
+	if filter.Archived {
+		where = []string{"person.deleted_at <> ''"}
+	}
+	if filter.Search != "" {
+		where = append(where, `(
+			person.first_name LIKE ? ESCAPE '\'
+			OR person.last_name LIKE ? ESCAPE '\'
+			OR person.email LIKE ? ESCAPE '\'
+			OR person.city LIKE ? ESCAPE '\'
+		)`)
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	if filter.MembershipTypeID != 0 {
+		where = append(where, "current_membership.type_id = ?", active)
+		args = append(args, filter.MembershipTypeID, today.Format(formatDate))
+	}
+	if filter.MinAge > 0 || filter.MaxAge > 0 {
+		where = append(where, "person.date_of_birth <> ''")
+	}
+	if filter.MinAge > 0 {
+		where = append(where, "person.date_of_birth <= ?")
+		args = append(args, today.AddDate(-filter.MinAge, 0, 0).Format(xone.FormatDateOfBirth))
+	}
+	if filter.MaxAge > 0 {
+		where = append(where, "person.date_of_birth > ?")
+		args = append(args, today.AddDate(-filter.MaxAge-1, 0, 0).Format(xone.FormatDateOfBirth))
+	}
+	if !filter.EffectiveFrom.IsZero() {
+		where = append(where, "current_membership.effective_from >= ?", active)
+		args = append(args, filter.EffectiveFrom.Format(formatDate), today.Format(formatDate))
+	}
+	if !filter.EffectiveUntil.IsZero() {
+		where = append(where, "current_membership.effective_from <> '' AND current_membership.effective_from <= ?", active)
+		args = append(args, filter.EffectiveUntil.Format(formatDate), today.Format(formatDate))
+	}
+	from += "WHERE " + strings.Join(where, " AND ")
+
+	var n int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) "+from, args...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+	query := `
+		SELECT
+			person.id,
+			person.public_id,
+			person.first_name,
+			person.last_name,
+			person.date_of_birth,
+			person.email,
+			person.phone,
+			person.mobile,
+			person.street,
+			person.house_number,
+			person.zip_code,
+			person.city,
+			person.deleted_at,
+			person.version
+	` + from + fmt.Sprintf(" ORDER BY %s %s, person.id %s", sortColumn, direction, direction)
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := filter.Limit
+		if limit == 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, filter.Offset)
+	}
+
+	persons, err := queryPersons(ctx, tx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return persons, n, nil
+}
+
+// queryPersons runs a query which selects all columns of the person table in
+// the order they are defined, except for the ones added by later migrations
+// which follow in the order of the migrations, and returns the persons along
+// with their memberships.
+func queryPersons(ctx context.Context, tx dbtx, query string, args ...interface{}) ([]xone.Person, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var persons []xone.Person
-	var id int
-	var pid, firstName, lastName, dobString, email, phone, mobile, street, houseNumber, zipCode, city string
+	var id, version int
+	var pid, firstName, lastName, dobString, email, phone, mobile, street, houseNumber, zipCode, city, deletedAt string
 	for rows.Next() {
-		if err := rows.Scan(&id, &pid, &firstName, &lastName, &dobString, &email, &phone, &mobile, &street, &houseNumber, &zipCode, &city); err != nil {
+		if err := rows.Scan(&id, &pid, &firstName, &lastName, &dobString, &email, &phone, &mobile, &street, &houseNumber, &zipCode, &city, &deletedAt, &version); err != nil {
 			return nil, err
 		}
 
@@ -159,6 +468,7 @@ func findPersons(ctx context.Context, tx dbtx) ([]xone.Person, error) {
 			HouseNumber: houseNumber,
 			ZipCode:     zipCode,
 			City:        city,
+			Version:     version,
 		}
 
 		if dobString != "" {
@@ -166,9 +476,10 @@ func findPersons(ctx context.Context, tx dbtx) ([]xone.Person, error) {
 				return nil, err
 			}
 		}
-
-		if err := attachMemberships(ctx, tx, &p); err != nil {
-			return nil, err
+		if deletedAt != "" {
+			if p.DeletedAt, err = time.Parse(formatTimestamp, deletedAt); err != nil {
+				return nil, err
+			}
 		}
 
 		persons = append(persons, p)
@@ -176,11 +487,38 @@ func findPersons(ctx context.Context, tx dbtx) ([]xone.Person, error) {
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	rows.Close()
+
+	if err := attachAllMemberships(ctx, tx, persons); err != nil {
+		return nil, err
+	}
+
+	if err := attachFieldValues(ctx, tx, persons); err != nil {
+		return nil, err
+	}
 
 	return persons, nil
 }
 
+// escapeLike escapes the wildcards of a LIKE pattern so that s is matched
+// literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// findPerson returns the person with the given PID unless they have been
+// deleted.
 func findPerson(ctx context.Context, tx dbtx, pid string) (xone.Person, bool, error) {
+	return findPersonByPID(ctx, tx, pid, false)
+}
+
+// findArchivedPerson returns the person with the given PID if they have been
+// deleted.
+func findArchivedPerson(ctx context.Context, tx dbtx, pid string) (xone.Person, bool, error) {
+	return findPersonByPID(ctx, tx, pid, true)
+}
+
+func findPersonByPID(ctx context.Context, tx dbtx, pid string, archived bool) (xone.Person, bool, error) {
 	stmt, err := tx.PrepareContext(ctx, `
 		SELECT
 			id,
@@ -193,23 +531,26 @@ func findPerson(ctx context.Context, tx dbtx, pid string) (xone.Person, bool, er
 			street,
 			house_number,
 			zip_code,
-			city
+			city,
+			deleted_at,
+			version
 		FROM
 			person
 		WHERE
 			public_id = ?
+			AND (deleted_at <> '') = ?
 	`)
 	if err != nil {
 		return xone.Person{}, false, err
 	}
 
-	row := stmt.QueryRowContext(ctx, pid)
+	row := stmt.QueryRowContext(ctx, pid, archived)
 
 	p := xone.Person{}
-	var id int
-	var firstName, lastName, dobString, email, phone, mobile, street, houseNumber, zipCode, city string
+	var id, version int
+	var firstName, lastName, dobString, email, phone, mobile, street, houseNumber, zipCode, city, deletedAt string
 
-	if err := row.Scan(&id, &firstName, &lastName, &dobString, &email, &phone, &mobile, &street, &houseNumber, &zipCode, &city); err != nil {
+	if err := row.Scan(&id, &firstName, &lastName, &dobString, &email, &phone, &mobile, &street, &houseNumber, &zipCode, &city, &deletedAt, &version); err != nil {
 		if err == sql.ErrNoRows {
 			return xone.Person{}, false, nil
 		}
@@ -230,6 +571,7 @@ func findPerson(ctx context.Context, tx dbtx, pid string) (xone.Person, bool, er
 		HouseNumber: houseNumber,
 		ZipCode:     zipCode,
 		City:        city,
+		Version:     version,
 	}
 
 	if dobString != "" {
@@ -237,12 +579,22 @@ func findPerson(ctx context.Context, tx dbtx, pid string) (xone.Person, bool, er
 			return xone.Person{}, true, err
 		}
 	}
+	if deletedAt != "" {
+		if p.DeletedAt, err = time.Parse(formatTimestamp, deletedAt); err != nil {
+			return xone.Person{}, true, err
+		}
+	}
 
 	if err := attachMemberships(ctx, tx, &p); err != nil {
 		return xone.Person{}, true, err
 	}
 
-	return p, true, nil
+	persons := []xone.Person{p}
+	if err := attachFieldValues(ctx, tx, persons); err != nil {
+		return xone.Person{}, true, err
+	}
+
+	return persons[0], true, nil
 }
 
 func createPerson(ctx context.Context, tx dbtx, pid string, data xone.CreatePersonData) (xone.Person, error) {
@@ -297,7 +649,7 @@ func createPerson(ctx context.Context, tx dbtx, pid string, data xone.CreatePers
 		data.City,
 	)
 	if err != nil {
-		return xone.Person{}, err
+		return xone.Person{}, mapError(err)
 	}
 
 	id, err := result.LastInsertId()
@@ -318,11 +670,40 @@ func createPerson(ctx context.Context, tx dbtx, pid string, data xone.CreatePers
 		HouseNumber: data.HouseNumber,
 		ZipCode:     data.ZipCode,
 		City:        data.City,
+		Version:     1,
 	}
 
 	return p, nil
 }
 
+// createPersonWithMembership creates a person along with their initial
+// membership and their custom fields.
+func createPersonWithMembership(ctx context.Context, tx dbtx, pid string, data xone.CreatePersonData) (xone.Person, error) {
+	person, err := createPerson(ctx, tx, pid, data)
+	if err != nil {
+		return xone.Person{}, err
+	}
+
+	if err := saveFieldValues(ctx, tx, &person, data.CustomFields); err != nil {
+		return xone.Person{}, err
+	}
+
+	_, err = createMembership(ctx, tx, xone.CreateMembershipData{
+		PersonID:         person.ID,
+		MembershipTypeID: data.MembershipTypeID,
+		EffectiveFrom:    data.EffectiveFrom,
+	})
+	if err != nil {
+		return xone.Person{}, err
+	}
+
+	if err := attachMemberships(ctx, tx, &person); err != nil {
+		return xone.Person{}, err
+	}
+
+	return person, nil
+}
+
 func deletePerson(ctx context.Context, tx dbtx, id string) error {
 	stmt, err := tx.PrepareContext(ctx, `
 		DELETE FROM
@@ -336,9 +717,88 @@ func deletePerson(ctx context.Context, tx dbtx, id string) error {
 
 	_, err = stmt.ExecContext(ctx, id)
 
-	return err
+	return mapError(err)
 }
 
+// archivePerson marks the person with the given PID as deleted at the given
+// point in time.
+func archivePerson(ctx context.Context, tx dbtx, id string, now time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			person
+		SET
+			deleted_at = ?,
+			version = version + 1
+		WHERE
+			public_id = ?
+			AND deleted_at = ''
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, now.UTC().Format(formatTimestamp), id)
+
+	return mapError(err)
+}
+
+func restorePerson(ctx context.Context, tx dbtx, id string) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			person
+		SET
+			deleted_at = '',
+			version = version + 1
+		WHERE
+			public_id = ?
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, id)
+
+	return mapError(err)
+}
+
+// findPurgeablePersons returns the PIDs of all persons who have been deleted
+// at or before the given point in time.
+func findPurgeablePersons(ctx context.Context, tx dbtx, before time.Time) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			public_id
+		FROM
+			person
+		WHERE
+			deleted_at <> ''
+			AND deleted_at <= ?
+		ORDER BY
+			id
+	`, before.UTC().Format(formatTimestamp))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pids []string
+	for rows.Next() {
+		var pid string
+		if err := rows.Scan(&pid); err != nil {
+			return nil, err
+		}
+
+		pids = append(pids, pid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pids, nil
+}
+
+// updatePerson overwrites the data of the person with the given PID and
+// increments their version. It fails with an *xone.ErrStaleVersion unless the
+// person exists with the version named in upd.
 func updatePerson(ctx context.Context, tx dbtx, id string, upd xone.UpdatePersonData) error {
 	stmt, err := tx.PrepareContext(ctx, `
 		UPDATE
@@ -353,9 +813,12 @@ func updatePerson(ctx context.Context, tx dbtx, id string, upd xone.UpdatePerson
 			street = ?,
 			house_number = ?,
 			zip_code = ?,
-			city = ?
+			city = ?,
+			version = version + 1
 		WHERE
 			public_id = ?
+			AND deleted_at = ''
+			AND version = ?
 	`)
 	if err != nil {
 		return err
@@ -366,9 +829,88 @@ func updatePerson(ctx context.Context, tx dbtx, id string, upd xone.UpdatePerson
 		dob = upd.DateOfBirth.Format(xone.FormatDateOfBirth)
 	}
 
-	_, err = stmt.ExecContext(ctx, upd.FirstName, upd.LastName, dob, upd.Email, upd.Phone, upd.Mobile, upd.Street, upd.HouseNumber, upd.ZipCode, upd.City, id)
+	res, err := stmt.ExecContext(ctx, upd.FirstName, upd.LastName, dob, upd.Email, upd.Phone, upd.Mobile, upd.Street, upd.HouseNumber, upd.ZipCode, upd.City, id, upd.Version)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if rows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rows != 1 {
+		return &xone.ErrStaleVersion{Entity: "person", ID: id, Version: upd.Version}
+	}
+
+	return nil
+}
+
+// patchPerson writes the fields which are set in the patch and increments the
+// version of the person with the given PID. If the patch names a version, it
+// fails with an *xone.ErrStaleVersion unless the person has that version.
+func patchPerson(ctx context.Context, tx dbtx, id string, patch xone.PatchPersonData) error {
+	var columns []string
+	var args []interface{}
+	set := func(column string, value *string) {
+		if value != nil {
+			columns = append(columns, column+" = ?")
+			args = append(args, *value)
+		}
+	}
+
+	var dob *string
+	if patch.DateOfBirth != nil {
+		s := ""
+		if !patch.DateOfBirth.IsZero() {
+			s = patch.DateOfBirth.Format(xone.FormatDateOfBirth)
+		}
+		dob = &s
+	}
+
+	set("first_name", patch.FirstName)
+	set("last_name", patch.LastName)
+	set("date_of_birth", dob)
+	set("email", patch.Email)
+	set("phone", patch.Phone)
+	set("mobile", patch.Mobile)
+	set("street", patch.Street)
+	set("house_number", patch.HouseNumber)
+	set("zip_code", patch.ZipCode)
+	set("city", patch.City)
+	columns = append(columns, "version = version + 1")
+
+	conditions := "public_id = ? AND deleted_at = ''"
+	args = append(args, id)
+	if patch.Version != 0 {
+		conditions += " AND version = ?"
+		args = append(args, patch.Version)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE
+			person
+		SET
+			`+strings.Join(columns, ", ")+`
+		WHERE
+			`+conditions+`
+	`)
+	if err != nil {
+		return err
+	}
+
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if rows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rows != 1 {
+		if patch.Version == 0 {
+			return &xone.ErrNotFound{Entity: "person", ID: id}
+		}
+		return &xone.ErrStaleVersion{Entity: "person", ID: id, Version: patch.Version}
+	}
 
-	return err
+	return nil
 }
 
 func attachMemberships(ctx context.Context, tx dbtx, p *xone.Person) error {
@@ -381,6 +923,25 @@ func attachMemberships(ctx context.Context, tx dbtx, p *xone.Person) error {
 	return nil
 }
 
+// attachAllMemberships loads the memberships of all given persons at once.
+func attachAllMemberships(ctx context.Context, tx dbtx, persons []xone.Person) error {
+	ids := make([]int, len(persons))
+	for i, p := range persons {
+		ids[i] = p.ID
+	}
+
+	memberships, err := findMembershipsByPersons(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range persons {
+		persons[i].Memberships = memberships[persons[i].ID]
+	}
+
+	return nil
+}
+
 func parseDateOfBirth(s string) (time.Time, error) {
 	return time.Parse(xone.FormatDateOfBirth, s)
 }
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ var persons = []xone.Person{
 		LastName:    "Potter",
 		DateOfBirth: time.Date(1980, time.July, 31, 0, 0, 0, 0, time.UTC),
 		Email:       "harry.potter@hogwarts.co.uk",
+		Version:     1,
 	},
 	{
 		ID:          2,
@@ -25,6 +27,7 @@ var persons = []xone.Person{
 		LastName:    "Weasley",
 		DateOfBirth: time.Time{},
 		Email:       "ron.weasley@hogwarts.co.uk",
+		Version:     1,
 	},
 	{
 		ID:          3,
@@ -33,6 +36,7 @@ var persons = []xone.Person{
 		LastName:    "Granger",
 		DateOfBirth: time.Date(1979, time.September, 19, 0, 0, 0, 0, time.UTC),
 		Email:       "hermione.granger@hogwarts.co.uk",
+		Version:     1,
 	},
 }
 
@@ -215,6 +219,7 @@ func Test_createPerson(t *testing.T) {
 				FirstName:   "Hermione",
 				LastName:    "Granger",
 				DateOfBirth: time.Time{},
+				Version:     1,
 			},
 			wantErr: false,
 		},
@@ -301,6 +306,7 @@ func Test_updatePerson(t *testing.T) {
 			args: args{
 				id: "2",
 				upd: xone.UpdatePersonData{
+					Version:     1,
 					FirstName:   "Ronald",
 					LastName:    "Weasley",
 					DateOfBirth: time.Date(1980, time.March, 1, 0, 0, 0, 0, time.UTC),
@@ -316,8 +322,22 @@ func Test_updatePerson(t *testing.T) {
 				DateOfBirth: time.Date(1980, time.March, 1, 0, 0, 0, 0, time.UTC),
 				Email:       "",
 				Phone:       "1234",
+				Version:     2,
 			},
 		},
+		{
+			name: "Stale version",
+			args: args{
+				id: "2",
+				upd: xone.UpdatePersonData{
+					Version:   2,
+					FirstName: "Ronald",
+					LastName:  "Weasley",
+				},
+			},
+			wantErr:    true,
+			wantPerson: persons[1],
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -376,3 +396,227 @@ func Test_parseDateOfBirth(t *testing.T) {
 		})
 	}
 }
+
+func Test_findManyPersons(t *testing.T) {
+	today := time.Date(1995, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		filter   xone.PersonFilter
+		wantPIDs []string
+		wantN    int
+		wantErr  bool
+	}{
+		{
+			name:     "No filter",
+			filter:   xone.PersonFilter{Today: today},
+			wantPIDs: []string{"1", "2", "3", "4", "5"},
+			wantN:    5,
+		},
+		{
+			name:     "Search name",
+			filter:   xone.PersonFilter{Today: today, Search: "weasLEY"},
+			wantPIDs: []string{"2", "4"},
+			wantN:    2,
+		},
+		{
+			name:     "Search city",
+			filter:   xone.PersonFilter{Today: today, Search: "hollow"},
+			wantPIDs: []string{"5"},
+			wantN:    1,
+		},
+		{
+			name:     "Search wildcard literally",
+			filter:   xone.PersonFilter{Today: today, Search: "_"},
+			wantPIDs: []string{"4"},
+			wantN:    1,
+		},
+		{
+			name:     "Current membership type",
+			filter:   xone.PersonFilter{Today: today, MembershipTypeID: 2},
+			wantPIDs: []string{"5"},
+			wantN:    1,
+		},
+		{
+			name:     "Current membership type in the future",
+			filter:   xone.PersonFilter{Today: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC), MembershipTypeID: 2},
+			wantPIDs: []string{"1", "5"},
+			wantN:    2,
+		},
+		{
+			name:     "Age range",
+			filter:   xone.PersonFilter{Today: time.Date(1995, time.August, 11, 0, 0, 0, 0, time.UTC), MinAge: 14, MaxAge: 15},
+			wantPIDs: []string{"1", "3", "4"},
+			wantN:    3,
+		},
+		{
+			name:     "Maximum age",
+			filter:   xone.PersonFilter{Today: time.Date(1995, time.August, 10, 0, 0, 0, 0, time.UTC), MaxAge: 13},
+			wantPIDs: []string{"4"},
+			wantN:    1,
+		},
+		{
+			name: "Effective date",
+			filter: xone.PersonFilter{
+				Today:          today,
+				EffectiveFrom:  time.Date(1991, time.September, 1, 0, 0, 0, 0, time.UTC),
+				EffectiveUntil: time.Date(1991, time.December, 31, 0, 0, 0, 0, time.UTC),
+			},
+			wantPIDs: []string{"1", "2", "3"},
+			wantN:    3,
+		},
+		{
+			name:     "Sort descending",
+			filter:   xone.PersonFilter{Today: today, SortBy: xone.SortByLastName, Descending: true},
+			wantPIDs: []string{"4", "2", "1", "3", "5"},
+			wantN:    5,
+		},
+		{
+			name:     "Sort by date of birth",
+			filter:   xone.PersonFilter{Today: today, SortBy: xone.SortByDateOfBirth},
+			wantPIDs: []string{"2", "5", "3", "1", "4"},
+			wantN:    5,
+		},
+		{
+			name:     "Limit and offset",
+			filter:   xone.PersonFilter{Today: today, SortBy: xone.SortByFirstName, Limit: 2, Offset: 1},
+			wantPIDs: []string{"4", "1"},
+			wantN:    5,
+		},
+		{
+			name:     "Offset without limit",
+			filter:   xone.PersonFilter{Today: today, Offset: 3},
+			wantPIDs: []string{"4", "5"},
+			wantN:    5,
+		},
+		{
+			name:    "Invalid sort field",
+			filter:  xone.PersonFilter{SortBy: "password"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mustOpenDB(t)
+			mustMigrateFile(t, db, "testdata/Test_findManyPersons.sql")
+
+			got, n, err := findManyPersons(context.Background(), db, tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findManyPersons() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var pids []string
+			for _, p := range got {
+				pids = append(pids, p.PID)
+			}
+			if !reflect.DeepEqual(pids, tt.wantPIDs) {
+				t.Errorf("findManyPersons() = %v, want %v", pids, tt.wantPIDs)
+			}
+			if n != tt.wantN {
+				t.Errorf("findManyPersons() n = %v, want %v", n, tt.wantN)
+			}
+		})
+	}
+}
+
+// Test_findManyPersons_currentMembership makes sure that filtering by the
+// current membership agrees with xone.Person.Membership for memberships
+// without or with a future effective date.
+func Test_findManyPersons_currentMembership(t *testing.T) {
+	today := time.Date(1995, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	db := mustOpenDB(t)
+	mustMigrateFile(t, db, "testdata/Test_findManyPersons_currentMembership.sql")
+
+	all, _, err := findManyPersons(context.Background(), db, xone.PersonFilter{Today: today})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		typeID   int
+		wantPIDs []string
+	}{
+		{name: "Active", typeID: 1, wantPIDs: []string{"2"}},
+		{name: "Passive", typeID: 2, wantPIDs: []string{"3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := findManyPersons(context.Background(), db, xone.PersonFilter{Today: today, MembershipTypeID: tt.typeID})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var pids []string
+			for _, p := range got {
+				pids = append(pids, p.PID)
+			}
+			if !reflect.DeepEqual(pids, tt.wantPIDs) {
+				t.Errorf("findManyPersons() = %v, want %v", pids, tt.wantPIDs)
+			}
+
+			var goPIDs []string
+			for _, p := range all {
+				if m := p.Membership(today); m != nil && m.Type.ID == tt.typeID {
+					goPIDs = append(goPIDs, p.PID)
+				}
+			}
+			if !reflect.DeepEqual(pids, goPIDs) {
+				t.Errorf("findManyPersons() = %v, but Person.Membership() selects %v", pids, goPIDs)
+			}
+		})
+	}
+}
+
+// BenchmarkFindPersons compares loading the memberships of all persons in
+// batches, as findManyPersons does, with loading them person by person, as it
+// did before, which costs one query per person. Both load the memberships of
+// the same 3000 persons, which are read once beforehand.
+func BenchmarkFindPersons(b *testing.B) {
+	ctx := context.Background()
+	db := mustOpenDB(b)
+
+	mt, err := createMembershipType(ctx, db, "active")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < 3000; i++ {
+		_, err := createPersonWithMembership(ctx, db, strconv.Itoa(i), xone.CreatePersonData{
+			FirstName:        "Harry",
+			LastName:         "Potter",
+			DateOfBirth:      time.Date(1980, time.July, 31, 0, 0, 0, 0, time.UTC),
+			MembershipTypeID: mt.ID,
+			EffectiveFrom:    time.Date(1991, time.September, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	persons, err := findPersons(ctx, db)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("Batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := attachAllMemberships(ctx, db, persons); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("PerPerson", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range persons {
+				if err := attachMemberships(ctx, db, &persons[j]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
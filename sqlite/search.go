@@ -0,0 +1,441 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/stillwondering/xone"
+	"golang.org/x/text/unicode/norm"
+)
+
+// The full-text index of persons relies on the FTS5 extension which is only
+// compiled into github.com/mattn/go-sqlite3 when building with the sqlite_fts5
+// tag. Its migrations are kept apart from the regular ones so that the
+// database keeps working without it.
+//
+//go:embed migration/fts5/*.sql
+var searchMigrationFS embed.FS
+
+// migrateSearch creates the full-text index of persons if FTS5 is available.
+// Otherwise the triggers keeping the index up to date are dropped, as they
+// would make every change to a person fail, and the index will be rebuilt once
+// the database is opened with FTS5 again.
+func migrateSearch(db *sql.DB) error {
+	enabled, err := searchEnabled(context.Background(), db)
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(searchMigrationFS, "migration/fts5/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	if !enabled {
+		return dropSearchTriggers(db, names)
+	}
+
+	for _, name := range names {
+		if err := migrateFile(db, searchMigrationFS, name); err != nil {
+			return fmt.Errorf("migration error: name=%q err=%w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func dropSearchTriggers(db *sql.DB, names []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, trigger := range []string{"person_fts_after_insert_person", "person_fts_after_delete_person", "person_fts_after_update_person"} {
+		if _, err := tx.Exec(`DROP TRIGGER IF EXISTS ` + trigger); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		if _, err := tx.Exec(`DELETE FROM migrations WHERE name = ?`, name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FullTextSearch reports whether SQLite has been compiled with FTS5, which
+// requires building with the sqlite_fts5 tag. Without it, PersonService.Search
+// matches substrings and does not rank the results, which callers may want to
+// point out to their users.
+func FullTextSearch(ctx context.Context, db *sql.DB) (bool, error) {
+	return searchEnabled(ctx, db)
+}
+
+// searchEnabled reports whether SQLite has been compiled with FTS5.
+func searchEnabled(ctx context.Context, db dbtx) (bool, error) {
+	var enabled bool
+	if err := db.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&enabled); err != nil {
+		return false, err
+	}
+
+	return enabled, nil
+}
+
+// Search returns the persons whose first name, last name, email address,
+// street or city contain words starting with every word of the query, the best
+// matches first. Names weigh more than the other data points. Case and
+// diacritics are ignored, so "muller" finds "Müller".
+//
+// If no person matches, the search is repeated with typos allowed, so that
+// "Mueler" finds "Müller" and "Schmitt" finds "Schmidt", see
+// searchPersonsWithTypos.
+//
+// Without FTS5, which requires building with the sqlite_fts5 tag, the words
+// may appear anywhere in the data points and the persons are sorted by name
+// instead, see FullTextSearch.
+func (ps *PersonService) Search(ctx context.Context, query string) ([]xone.Person, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	persons, err := searchPersons(ctx, tx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return persons, tx.Commit()
+}
+
+func searchPersons(ctx context.Context, tx dbtx, query string) ([]xone.Person, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	persons, err := searchPersonsExactly(ctx, tx, terms)
+	if err != nil || len(persons) > 0 {
+		return persons, err
+	}
+
+	return searchPersonsWithTypos(ctx, tx, terms)
+}
+
+func searchPersonsExactly(ctx context.Context, tx dbtx, terms []string) ([]xone.Person, error) {
+	enabled, err := searchEnabled(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return searchPersonsWithoutIndex(ctx, tx, terms)
+	}
+
+	// Every term is quoted so that it cannot be mistaken for an FTS5 operator
+	// and matches any word it is a prefix of.
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		match[i] = `"` + term + `"*`
+	}
+
+	return queryPersons(ctx, tx, `
+		SELECT
+			person.id,
+			person.public_id,
+			person.first_name,
+			person.last_name,
+			person.date_of_birth,
+			person.email,
+			person.phone,
+			person.mobile,
+			person.street,
+			person.house_number,
+			person.zip_code,
+			person.city,
+			person.deleted_at,
+			person.version
+		FROM
+			person_fts
+			JOIN person ON person.id = person_fts.rowid
+		WHERE
+			person_fts MATCH ?
+			AND person.deleted_at = ''
+		ORDER BY
+			bm25(person_fts, 10.0, 10.0, 5.0, 1.0, 1.0),
+			person.id
+	`, strings.Join(match, " "))
+}
+
+func searchPersonsWithoutIndex(ctx context.Context, tx dbtx, terms []string) ([]xone.Person, error) {
+	var where []string
+	var args []interface{}
+	for _, term := range terms {
+		where = append(where, `(
+			person.first_name LIKE ? ESCAPE '\'
+			OR person.last_name LIKE ? ESCAPE '\'
+			OR person.email LIKE ? ESCAPE '\'
+			OR person.street LIKE ? ESCAPE '\'
+			OR person.city LIKE ? ESCAPE '\'
+		)`)
+		pattern := "%" + escapeLike(term) + "%"
+		args = append(args, pattern, pattern, pattern, pattern, pattern)
+	}
+
+	return queryPersons(ctx, tx, `
+		SELECT
+			person.id,
+			person.public_id,
+			person.first_name,
+			person.last_name,
+			person.date_of_birth,
+			person.email,
+			person.phone,
+			person.mobile,
+			person.street,
+			person.house_number,
+			person.zip_code,
+			person.city,
+			person.deleted_at,
+			person.version
+		FROM
+			person
+		WHERE
+			person.deleted_at = ''
+			AND `+strings.Join(where, " AND ")+`
+		ORDER BY
+			person.last_name,
+			person.first_name,
+			person.id
+	`, args...)
+}
+
+// typoSearchLimit is the maximum number of persons returned by
+// searchPersonsWithTypos.
+const typoSearchLimit = 100
+
+// searchPersonsWithTypos returns the persons with a word starting with every
+// term, give or take a few typos, the fewest typos first and then sorted by
+// name. Terms of up to three letters must match exactly, longer ones may have
+// one typo and terms of eight letters or more two. As the index cannot look
+// up words this way, the data points of all persons are compared, which is
+// why it is only a fallback and returns at most typoSearchLimit persons.
+func searchPersonsWithTypos(ctx context.Context, tx dbtx, terms []string) ([]xone.Person, error) {
+	folded := make([][]rune, len(terms))
+	for i, term := range terms {
+		folded[i] = []rune(foldSearchTerm(term))
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			first_name,
+			last_name,
+			email,
+			street,
+			city
+		FROM
+			person
+		WHERE
+			deleted_at = ''
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type candidate struct {
+		id                  int
+		typos               int
+		lastName, firstName string
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		var email, street, city string
+		if err := rows.Scan(&c.id, &c.firstName, &c.lastName, &email, &street, &city); err != nil {
+			return nil, err
+		}
+
+		var words [][]rune
+		for _, word := range searchTerms(strings.Join([]string{c.firstName, c.lastName, email, street, city}, " ")) {
+			words = append(words, []rune(foldSearchTerm(word)))
+		}
+
+		matches := true
+		for _, term := range folded {
+			typos, ok := matchTerm(term, words)
+			if !ok {
+				matches = false
+				break
+			}
+			c.typos += typos
+		}
+		if matches {
+			candidates = append(candidates, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.typos != b.typos {
+			return a.typos < b.typos
+		}
+		if a.lastName != b.lastName {
+			return a.lastName < b.lastName
+		}
+		if a.firstName != b.firstName {
+			return a.firstName < b.firstName
+		}
+		return a.id < b.id
+	})
+	if len(candidates) > typoSearchLimit {
+		candidates = candidates[:typoSearchLimit]
+	}
+
+	rank := make(map[int]int, len(candidates))
+	placeholders := make([]string, len(candidates))
+	args := make([]interface{}, len(candidates))
+	for i, c := range candidates {
+		rank[c.id] = i
+		placeholders[i] = "?"
+		args[i] = c.id
+	}
+
+	persons, err := queryPersons(ctx, tx, `
+		SELECT
+			person.id,
+			person.public_id,
+			person.first_name,
+			person.last_name,
+			person.date_of_birth,
+			person.email,
+			person.phone,
+			person.mobile,
+			person.street,
+			person.house_number,
+			person.zip_code,
+			person.city,
+			person.deleted_at,
+			person.version
+		FROM
+			person
+		WHERE
+			person.id IN (`+strings.Join(placeholders, ", ")+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(persons, func(i, j int) bool {
+		return rank[persons[i].ID] < rank[persons[j].ID]
+	})
+
+	return persons, nil
+}
+
+// matchTerm reports whether one of the words starts with term, allowing for
+// as many typos as maxTypos permits, and returns the fewest typos needed.
+func matchTerm(term []rune, words [][]rune) (int, bool) {
+	best := maxTypos(term) + 1
+	for _, word := range words {
+		if typos := prefixDistance(term, word); typos < best {
+			best = typos
+		}
+	}
+
+	return best, best <= maxTypos(term)
+}
+
+// maxTypos returns the number of typos permitted in a search term.
+func maxTypos(term []rune) int {
+	switch {
+	case len(term) < 4:
+		return 0
+	case len(term) < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// prefixDistance returns the smallest Levenshtein distance between term and
+// any prefix of word, i.e. the number of letters that have to be inserted,
+// deleted or replaced in term for word to start with it.
+func prefixDistance(term, word []rune) int {
+	// row holds the distances between the first i letters of term and every
+	// prefix of word.
+	row := make([]int, len(word)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(term); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(word); j++ {
+			cost := 1
+			if term[i-1] == word[j-1] {
+				cost = 0
+			}
+
+			above := row[j]
+			row[j] = diagonal + cost
+			if above+1 < row[j] {
+				row[j] = above + 1
+			}
+			if row[j-1]+1 < row[j] {
+				row[j] = row[j-1] + 1
+			}
+			diagonal = above
+		}
+	}
+
+	best := row[0]
+	for _, d := range row[1:] {
+		if d < best {
+			best = d
+		}
+	}
+
+	return best
+}
+
+// foldSearchTerm lower-cases a word and removes its diacritics, like the
+// full-text index does.
+func foldSearchTerm(word string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(word) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+// searchTerms splits a search query into words the same way the full-text
+// index splits the data points of a person.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
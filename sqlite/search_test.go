@@ -0,0 +1,192 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/stillwondering/xone"
+)
+
+func Test_searchPersons(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "Empty query", query: "  ", want: nil},
+		{name: "Prefix of first name", query: "harr", want: []string{"1"}},
+		{name: "Case is ignored", query: "HERMIONE", want: []string{"3"}},
+		{name: "Email address", query: "ginny_weasley@hogwarts", want: []string{"4"}},
+		{name: "Every word must match", query: "weasley ottery", want: []string{"2", "4"}},
+		{name: "No match", query: "voldemort", want: nil},
+		{name: "Operators are no operators", query: `"harry" OR NOT`, want: nil},
+		{name: "Typo in prefix", query: "pottr", want: []string{"1"}},
+		{name: "Swapped letters", query: "hermoine", want: []string{"3"}},
+		{name: "Typo in one of the words", query: "ginny wesley", want: []string{"4"}},
+		{name: "Short words must match exactly", query: "rom", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mustOpenDB(t)
+			mustMigrateFile(t, db, "testdata/Test_findManyPersons.sql")
+			mustRebuildSearchIndex(t, db)
+
+			persons, err := searchPersons(context.Background(), db, tt.query)
+			if err != nil {
+				t.Fatalf("searchPersons() error = %v", err)
+			}
+
+			var got []string
+			for _, p := range persons {
+				got = append(got, p.PID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("searchPersons() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_searchPersons_index(t *testing.T) {
+	ctx := context.Background()
+	db := mustOpenDB(t)
+	if enabled, err := searchEnabled(ctx, db); err != nil {
+		t.Fatal(err)
+	} else if !enabled {
+		t.Skip("SQLite has been built without FTS5, use -tags sqlite_fts5")
+	}
+
+	for pid, data := range map[string]xone.CreatePersonData{
+		"1": {FirstName: "Harry", LastName: "Potter", City: "Little Whinging"},
+		"2": {FirstName: "Lily", LastName: "Evans", Street: "Potter Lane"},
+		"3": {FirstName: "Jürgen", LastName: "Müller"},
+	} {
+		if _, err := createPerson(ctx, db, pid, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(query string) []string {
+		t.Helper()
+
+		persons, err := searchPersons(ctx, db, query)
+		if err != nil {
+			t.Fatalf("searchPersons() error = %v", err)
+		}
+
+		var pids []string
+		for _, p := range persons {
+			pids = append(pids, p.PID)
+		}
+
+		return pids
+	}
+
+	if got, want := search("pott"), []string{"1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("searchPersons() = %v, want names to be ranked first %v", got, want)
+	}
+	if got, want := search("muller jurg"), []string{"3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("searchPersons() = %v, want diacritics to be ignored %v", got, want)
+	}
+
+	if err := updatePerson(ctx, db, "1", xone.UpdatePersonData{Version: 1, FirstName: "Harry", LastName: "Dursley"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := search("potter"), []string{"2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("searchPersons() after update = %v, want %v", got, want)
+	}
+
+	if err := deletePerson(ctx, db, "2"); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("potter"); got != nil {
+		t.Errorf("searchPersons() after delete = %v, want none", got)
+	}
+}
+
+func Test_searchPersons_typos(t *testing.T) {
+	ctx := context.Background()
+	db := mustOpenDB(t)
+
+	for pid, data := range map[string]xone.CreatePersonData{
+		"1": {FirstName: "Jürgen", LastName: "Müller"},
+		"2": {FirstName: "Anna", LastName: "Schmidt"},
+		"3": {FirstName: "Anna", LastName: "Schmitz"},
+		"4": {FirstName: "Lena", LastName: "Mahler"},
+	} {
+		if _, err := createPerson(ctx, db, pid, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "Diacritics and a typo", query: "Mueler", want: []string{"1"}},
+		{name: "Typo with diacritics", query: "Mülller", want: []string{"1"}},
+		{name: "Fewest typos first", query: "Schmitt", want: []string{"2", "3"}},
+		{name: "Exact matches win", query: "Schmitz", want: []string{"3"}},
+		{name: "Every word must match", query: "Jurgen Mahler", want: nil},
+		{name: "Too many typos", query: "Mohlar", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, err := searchPersons(ctx, db, tt.query)
+			if err != nil {
+				t.Fatalf("searchPersons() error = %v", err)
+			}
+
+			var got []string
+			for _, p := range persons {
+				got = append(got, p.PID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("searchPersons() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_prefixDistance(t *testing.T) {
+	tests := []struct {
+		term, word string
+		want       int
+	}{
+		{term: "pot", word: "potter", want: 0},
+		{term: "pottr", word: "potter", want: 1},
+		{term: "mueler", word: "muller", want: 1},
+		{term: "schmitt", word: "schmidt", want: 1},
+		{term: "hermoine", word: "hermione", want: 2},
+		{term: "potter", word: "pot", want: 3},
+		{term: "", word: "potter", want: 0},
+	}
+	for _, tt := range tests {
+		if got := prefixDistance([]rune(tt.term), []rune(tt.word)); got != tt.want {
+			t.Errorf("prefixDistance(%q, %q) = %d, want %d", tt.term, tt.word, got, tt.want)
+		}
+	}
+}
+
+// mustRebuildSearchIndex indexes persons which have been inserted while the
+// triggers were not in place, if the index exists at all.
+func mustRebuildSearchIndex(tb testing.TB, db *sql.DB) {
+	tb.Helper()
+
+	enabled, err := searchEnabled(context.Background(), db)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if !enabled {
+		return
+	}
+
+	if _, err := db.Exec(`INSERT INTO person_fts (person_fts) VALUES ('rebuild')`); err != nil {
+		tb.Fatal(err)
+	}
+}
@@ -0,0 +1,264 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+// DefaultSessionLifetime is the time after which a session expires unless a
+// different lifetime is configured on the SessionService.
+const DefaultSessionLifetime = 24 * time.Hour
+
+// formatTimestamp is the format used to store points in time. All timestamps
+// are stored in UTC so they can be compared as strings.
+const formatTimestamp = time.RFC3339
+
+var _ xone.SessionService = (*SessionService)(nil)
+
+type SessionService struct {
+	db            *sql.DB
+	Lifetime      time.Duration
+	Now           func() time.Time
+	GenerateToken func() (string, error)
+}
+
+func NewSessionService(db *sql.DB) *SessionService {
+	service := SessionService{
+		db:            db,
+		Lifetime:      DefaultSessionLifetime,
+		Now:           time.Now,
+		GenerateToken: generateToken,
+	}
+
+	return &service
+}
+
+// Create starts a new session for the given user.
+func (ss *SessionService) Create(ctx context.Context, user xone.User) (xone.Session, error) {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Session{}, err
+	}
+	defer tx.Rollback()
+
+	token, err := ss.GenerateToken()
+	if err != nil {
+		return xone.Session{}, err
+	}
+
+	now := ss.Now().UTC().Truncate(time.Second)
+	session := xone.Session{
+		Token:     token,
+		User:      withoutPassword(user),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ss.Lifetime),
+	}
+
+	if err := createSession(ctx, tx, session); err != nil {
+		return xone.Session{}, err
+	}
+
+	return session, tx.Commit()
+}
+
+// Find looks up the session which belongs to the given token. Expired
+// sessions are treated as if they did not exist.
+func (ss *SessionService) Find(ctx context.Context, token string) (xone.Session, bool, error) {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.Session{}, false, err
+	}
+	defer tx.Rollback()
+
+	session, found, err := findSession(ctx, tx, token)
+	if err != nil {
+		return xone.Session{}, false, err
+	}
+
+	if found && session.Expired(ss.Now()) {
+		return xone.Session{}, false, tx.Commit()
+	}
+
+	return session, found, tx.Commit()
+}
+
+// Revoke ends the session which belongs to the given token, e.g. on logout.
+func (ss *SessionService) Revoke(ctx context.Context, token string) error {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteSession(ctx, tx, token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeAll ends all sessions of the given user.
+func (ss *SessionService) RevokeAll(ctx context.Context, userID int) error {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteSessionsByUser(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteExpired removes all sessions which have expired from the database.
+func (ss *SessionService) DeleteExpired(ctx context.Context) error {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteExpiredSessions(ctx, tx, ss.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func createSession(ctx context.Context, db dbtx, session xone.Session) error {
+	stmt, err := db.PrepareContext(ctx, `
+		INSERT INTO session (
+			token_hash,
+			user_id,
+			created_at,
+			expires_at
+		) VALUES (
+			?,
+			?,
+			?,
+			?
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(
+		ctx,
+		hashToken(session.Token),
+		session.User.ID,
+		session.CreatedAt.UTC().Format(formatTimestamp),
+		session.ExpiresAt.UTC().Format(formatTimestamp),
+	)
+
+	return err
+}
+
+func findSession(ctx context.Context, db dbtx, token string) (xone.Session, bool, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		SELECT
+			session.created_at,
+			session.expires_at,
+			users.id,
+			users.email,
+			users.role
+		FROM
+			session
+			JOIN users ON session.user_id = users.id
+		WHERE
+			session.token_hash = ?
+	`)
+	if err != nil {
+		return xone.Session{}, false, err
+	}
+
+	session := xone.Session{Token: token}
+	var createdAt, expiresAt string
+
+	row := stmt.QueryRowContext(ctx, hashToken(token))
+	if err := row.Scan(&createdAt, &expiresAt, &session.User.ID, &session.User.Email, &session.User.Role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return xone.Session{}, false, nil
+		}
+
+		return xone.Session{}, false, err
+	}
+
+	if session.CreatedAt, err = time.Parse(formatTimestamp, createdAt); err != nil {
+		return xone.Session{}, true, err
+	}
+	if session.ExpiresAt, err = time.Parse(formatTimestamp, expiresAt); err != nil {
+		return xone.Session{}, true, err
+	}
+
+	return session, true, nil
+}
+
+func deleteSession(ctx context.Context, db dbtx, token string) error {
+	stmt, err := db.PrepareContext(ctx, `DELETE FROM session WHERE token_hash = ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, hashToken(token))
+
+	return err
+}
+
+func deleteSessionsByUser(ctx context.Context, db dbtx, userID int) error {
+	stmt, err := db.PrepareContext(ctx, `DELETE FROM session WHERE user_id = ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, userID)
+
+	return err
+}
+
+func deleteExpiredSessions(ctx context.Context, db dbtx, now time.Time) error {
+	stmt, err := db.PrepareContext(ctx, `DELETE FROM session WHERE expires_at <= ?`)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, now.UTC().Format(formatTimestamp))
+
+	return err
+}
+
+// generateToken returns a random, URL safe session token.
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the representation of a token that is stored in the
+// database. Only storing a hash means a leaked database does not allow to
+// take over any session.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// withoutPassword returns the user without their password hash, which
+// sessions never carry.
+func withoutPassword(user xone.User) xone.User {
+	user.Password = ""
+
+	return user
+}
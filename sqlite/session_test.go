@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+)
+
+var dumbledore = xone.User{
+	ID:    1,
+	Email: "albus.dumbledore@hogwarts.co.uk",
+	Role:  xone.RoleReadOnly,
+}
+
+func Test_findSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		want      xone.Session
+		wantFound bool
+		wantErr   bool
+	}{
+		{
+			name:      "Unknown token",
+			token:     "unknown",
+			want:      xone.Session{},
+			wantFound: false,
+			wantErr:   false,
+		},
+		{
+			name:  "Valid token",
+			token: "valid",
+			want: xone.Session{
+				Token:     "valid",
+				User:      dumbledore,
+				CreatedAt: time.Date(2022, time.February, 1, 10, 0, 0, 0, time.UTC),
+				ExpiresAt: time.Date(2022, time.February, 2, 10, 0, 0, 0, time.UTC),
+			},
+			wantFound: true,
+			wantErr:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mustOpenDB(t)
+			mustMigrateFile(t, db, "testdata/Test_session_prefill.sql")
+
+			got, gotFound, err := findSession(context.Background(), db, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("findSession() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotFound != tt.wantFound {
+				t.Errorf("findSession() gotFound = %v, want %v", gotFound, tt.wantFound)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findSession() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deleteSessions(t *testing.T) {
+	tests := []struct {
+		name      string
+		delete    func(ctx context.Context, db dbtx) error
+		wantFound map[string]bool
+	}{
+		{
+			name: "Single session",
+			delete: func(ctx context.Context, db dbtx) error {
+				return deleteSession(ctx, db, "valid")
+			},
+			wantFound: map[string]bool{"valid": false, "expired": true, "snape": true},
+		},
+		{
+			name: "All sessions of a user",
+			delete: func(ctx context.Context, db dbtx) error {
+				return deleteSessionsByUser(ctx, db, 1)
+			},
+			wantFound: map[string]bool{"valid": false, "expired": false, "snape": true},
+		},
+		{
+			name: "Expired sessions",
+			delete: func(ctx context.Context, db dbtx) error {
+				return deleteExpiredSessions(ctx, db, time.Date(2022, time.February, 1, 12, 0, 0, 0, time.UTC))
+			},
+			wantFound: map[string]bool{"valid": true, "expired": false, "snape": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mustOpenDB(t)
+			mustMigrateFile(t, db, "testdata/Test_session_prefill.sql")
+
+			if err := tt.delete(context.Background(), db); err != nil {
+				t.Fatal(err)
+			}
+
+			for token, wantFound := range tt.wantFound {
+				_, found, err := findSession(context.Background(), db, token)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if found != wantFound {
+					t.Errorf("findSession(%q) found = %v, want %v", token, found, wantFound)
+				}
+			}
+		})
+	}
+}
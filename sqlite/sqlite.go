@@ -13,7 +13,8 @@ import (
 	"sort"
 	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stillwondering/xone"
 )
 
 // dbtx is an abstraction layer over a database OR a transaction.
@@ -60,6 +61,10 @@ func Open(dsn string) (*sql.DB, error) {
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
 
+	if err := migrateSearch(db); err != nil {
+		return nil, fmt.Errorf("migrate search: %w", err)
+	}
+
 	return db, nil
 }
 
@@ -79,7 +84,7 @@ func migrate(db *sql.DB) error {
 
 	// Loop over all migration files and execute them in order.
 	for _, name := range names {
-		if err := migrateFile(db, name); err != nil {
+		if err := migrateFile(db, migrationFS, name); err != nil {
 			return fmt.Errorf("migration error: name=%q err=%w", name, err)
 		}
 	}
@@ -88,7 +93,7 @@ func migrate(db *sql.DB) error {
 
 // migrate runs a single migration file within a transaction. On success, the
 // migration file name is saved to the "migrations" table to prevent re-running.
-func migrateFile(db *sql.DB, name string) error {
+func migrateFile(db *sql.DB, fsys fs.FS, name string) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return err
@@ -104,7 +109,7 @@ func migrateFile(db *sql.DB, name string) error {
 	}
 
 	// Read and execute migration file.
-	if buf, err := fs.ReadFile(migrationFS, name); err != nil {
+	if buf, err := fs.ReadFile(fsys, name); err != nil {
 		return err
 	} else if _, err := tx.Exec(string(buf)); err != nil {
 		return err
@@ -117,3 +122,22 @@ func migrateFile(db *sql.DB, name string) error {
 
 	return tx.Commit()
 }
+
+// mapError translates the constraint violations reported by SQLite into the
+// error kinds of the xone package: violations of UNIQUE and PRIMARY KEY
+// constraints become an *xone.ErrConflict, all other violations, e.g. of a
+// FOREIGN KEY constraint, an *xone.ErrInvalid. The original error is kept as
+// their cause. Other errors are returned unchanged.
+func mapError(err error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code != sqlite3.ErrConstraint {
+		return err
+	}
+
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return &xone.ErrConflict{Msg: sqliteErr.Error(), Err: err}
+	default:
+		return &xone.ErrInvalid{Msg: sqliteErr.Error(), Err: err}
+	}
+}
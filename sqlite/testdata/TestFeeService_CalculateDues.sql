@@ -0,0 +1,36 @@
+INSERT INTO person (
+    id,
+    public_id,
+    first_name,
+    last_name,
+    date_of_birth
+) VALUES
+(1, "1", "Ginny", "Weasley", "1981-08-11");
+
+INSERT INTO membership_type (
+    id,
+    name
+) VALUES
+(1, "adult"),
+(2, "youth");
+
+INSERT INTO membership (
+    id,
+    type_id,
+    person_id,
+    effective_from,
+    end_date
+) VALUES
+(1, 1, 1, "2020-01-01", ""),
+(2, 2, 1, "2021-01-01", ""),
+(3, 1, 1, "2022-01-01", "");
+
+INSERT INTO membership_fee (
+    id,
+    membership_type_id,
+    amount,
+    billing_period,
+    valid_from
+) VALUES
+(1, 1, 10000, "annual", "2020-01-01"),
+(2, 2, 5000, "annual", "2020-01-01");
@@ -0,0 +1,35 @@
+INSERT INTO person (
+    id,
+    public_id,
+    first_name,
+    last_name,
+    date_of_birth,
+    email,
+    city
+) VALUES
+(1, "1", "Harry", "Potter", "1980-07-31", "harry.potter@hogwarts.co.uk", "Little Whinging"),
+(2, "2", "Ron", "Weasley", "", "ron.weasley@hogwarts.co.uk", "Ottery St Catchpole"),
+(3, "3", "Hermione", "Granger", "1979-09-19", "hermione.granger@hogwarts.co.uk", "London"),
+(4, "4", "Ginny", "Weasley", "1981-08-11", "ginny_weasley@hogwarts.co.uk", "Ottery St Catchpole"),
+(5, "5", "Albus", "Dumbledore", "1881-08-30", "albus.dumbledore@hogwarts.co.uk", "Godric's Hollow");
+
+INSERT INTO membership_type (
+    id,
+    name
+) VALUES
+(1, "active"),
+(2, "passive");
+
+INSERT INTO membership (
+    id,
+    type_id,
+    person_id,
+    effective_from
+) VALUES
+(1, 1, 1, "1991-09-01"),
+(2, 2, 1, "1998-05-02"),
+(3, 1, 2, "1991-09-01"),
+(4, 1, 3, "1991-09-01"),
+(5, 1, 4, "1992-09-01"),
+(6, 2, 5, ""),
+(7, 1, 5, "2045-07-31");
@@ -0,0 +1,33 @@
+INSERT INTO person (
+    id,
+    public_id,
+    first_name,
+    last_name,
+    date_of_birth
+) VALUES
+(1, "1", "Harry", "Potter", "1980-07-31"),
+(2, "2", "Ron", "Weasley", "1980-03-01"),
+(3, "3", "Hermione", "Granger", "1979-09-19"),
+(4, "4", "Ginny", "Weasley", "1981-08-11");
+
+INSERT INTO membership_type (
+    id,
+    name
+) VALUES
+(1, "active"),
+(2, "passive");
+
+INSERT INTO membership (
+    id,
+    type_id,
+    person_id,
+    effective_from,
+    end_date
+) VALUES
+(1, 1, 1, "2045-07-31", ""),
+(2, 1, 2, "1991-09-01", ""),
+(3, 2, 2, "", ""),
+(4, 1, 3, "1991-09-01", ""),
+(5, 2, 3, "1991-09-01", ""),
+(6, 2, 4, "1991-09-01", ""),
+(7, 1, 4, "1993-09-01", "1994-06-30");
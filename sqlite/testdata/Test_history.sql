@@ -0,0 +1,72 @@
+INSERT INTO person (
+    id,
+    public_id,
+    first_name,
+    last_name,
+    date_of_birth,
+    email,
+    city
+) VALUES
+(1, "1", "Harry", "Potter", "1980-07-31", "harry.potter@hogwarts.co.uk", "London"),
+(2, "2", "Ron", "Weasley", "", "ron.weasley@hogwarts.co.uk", "Ottery St Catchpole");
+
+INSERT INTO membership_type (
+    id,
+    name
+) VALUES
+(1, "regular"),
+(2, "passive");
+
+INSERT INTO membership (
+    id,
+    type_id,
+    person_id,
+    effective_from
+) VALUES
+(1, 2, 1, "1998-05-02"),
+(2, 1, 2, "1991-09-01");
+
+-- Replace the history written by the triggers with one that has taken place
+-- at well-known points in time.
+DELETE FROM person_history;
+DELETE FROM membership_history;
+DELETE FROM membership_type_history;
+
+INSERT INTO person_history (
+    id,
+    created_at,
+    person_id,
+    public_id,
+    first_name,
+    last_name,
+    date_of_birth,
+    email,
+    city
+) VALUES
+(1, "2020-01-01 10:00:00", 1, "1", "Harry", "Potter", "1980-07-31", "", "Little Whinging"),
+(2, "2020-01-01 10:00:00", 2, "2", "Ron", "Weasley", "", "ron.weasley@hogwarts.co.uk", "Ottery St Catchpole"),
+(3, "2020-02-01 10:00:00", 1, "1", "Harry", "Potter", "1980-07-31", "harry.potter@hogwarts.co.uk", "Little Whinging"),
+(4, "2020-02-01 11:00:00", 1, "1", "Harry", "Potter", "1980-07-31", "harry.potter@hogwarts.co.uk", "Little Whinging"),
+(5, "2020-03-01 10:00:00", 1, "1", "Harry", "Potter", "1980-07-31", "harry.potter@hogwarts.co.uk", "London");
+
+INSERT INTO membership_type_history (
+    id,
+    created_at,
+    membership_type_id,
+    name
+) VALUES
+(1, "2019-01-01 00:00:00", 1, "active"),
+(2, "2019-01-01 00:00:00", 2, "passive"),
+(3, "2020-06-01 00:00:00", 1, "regular");
+
+INSERT INTO membership_history (
+    id,
+    created_at,
+    membership_id,
+    type_id,
+    person_id,
+    effective_from
+) VALUES
+(1, "2020-01-01 10:00:00", 1, 1, 1, "1991-09-01"),
+(2, "2020-01-01 10:00:00", 2, 1, 2, "1991-09-01"),
+(3, "2020-04-01 10:00:00", 1, 2, 1, "1998-05-02");
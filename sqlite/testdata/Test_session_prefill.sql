@@ -0,0 +1,20 @@
+INSERT INTO `users` (
+    `id`,
+    `email`,
+    `password`
+) VALUES
+(1, "albus.dumbledore@hogwarts.co.uk", "Harrydidyouputyournameinthegobletoffire"),
+(2, "severus.snape@hogwarts.co.uk", "Detention!");
+
+INSERT INTO `session` (
+    `token_hash`,
+    `user_id`,
+    `created_at`,
+    `expires_at`
+) VALUES
+-- sha256("valid")
+("ec654fac9599f62e79e2706abef23dfb7c07c08185aa86db4d8695f0b718d1b3", 1, "2022-02-01T10:00:00Z", "2022-02-02T10:00:00Z"),
+-- sha256("expired")
+("fa64ea1e82e1206f828ab2a02917c7e92accb98e3b95881a1b4ad52b914b66e3", 1, "2022-01-01T10:00:00Z", "2022-01-02T10:00:00Z"),
+-- sha256("snape")
+("e63c8c8a0f530555c761a7f3383121d33be720b83bc038a8ca54b6e6c42300e1", 2, "2022-02-01T10:00:00Z", "2022-02-02T10:00:00Z");
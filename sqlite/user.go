@@ -4,19 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
+	"time"
 
 	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/bcrypt"
 )
 
 var _ xone.UserService = (*UserService)(nil)
 
 type UserService struct {
-	db *sql.DB
+	db  *sql.DB
+	Now func() time.Time
 }
 
 func NewUserService(db *sql.DB) (*UserService, error) {
 	service := UserService{
-		db: db,
+		db:  db,
+		Now: time.Now,
 	}
 
 	return &service, nil
@@ -44,6 +49,12 @@ func (us *UserService) Create(ctx context.Context, data xone.CreateUserData) (xo
 	}
 	defer tx.Rollback()
 
+	if data.Role == "" {
+		data.Role = xone.RoleReadOnly
+	} else if !data.Role.Valid() {
+		return xone.User{}, &xone.ErrInvalidRole{Role: data.Role}
+	}
+
 	if _, found, err := findUserByEmail(ctx, tx, data.Email); err != nil {
 		return xone.User{}, err
 	} else if found {
@@ -55,14 +66,57 @@ func (us *UserService) Create(ctx context.Context, data xone.CreateUserData) (xo
 		return xone.User{}, err
 	}
 
+	if err := createAuditEntry(ctx, tx, us.Now(), xone.AuditCreate, xone.AuditUser, strconv.Itoa(user.ID), nil, auditUser{ID: user.ID, Email: user.Email, Role: user.Role}); err != nil {
+		return xone.User{}, err
+	}
+
 	return user, tx.Commit()
 }
 
+// Authenticate verifies the given credentials and returns the matching user
+// without their password hash. If the email is unknown or the password is
+// wrong, *xone.ErrInvalidCredentials is returned.
+func (us *UserService) Authenticate(ctx context.Context, email, password string) (xone.User, error) {
+	tx, err := us.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xone.User{}, err
+	}
+	defer tx.Rollback()
+
+	user, found, err := findUserByEmail(ctx, tx, email)
+	if err != nil {
+		return xone.User{}, err
+	}
+
+	// Compare against a dummy hash for unknown users as well, so the response
+	// time does not reveal which email addresses are registered.
+	hash := dummyPasswordHash
+	if found {
+		hash = []byte(user.Password)
+	}
+
+	if !bcrypt.PasswordMatchesHash(hash, []byte(password)) || !found {
+		return xone.User{}, &xone.ErrInvalidCredentials{Email: email}
+	}
+
+	// The hash is not needed anymore, so it must not travel any further, e.g.
+	// into a session or the request context.
+	user.Password = ""
+
+	return user, tx.Commit()
+}
+
+// dummyPasswordHash is a valid bcrypt hash of a random password which is only
+// used to keep Authenticate busy for unknown users.
+var dummyPasswordHash = []byte("$2a$08$MxU7JPbgKHne3ENDK.C3IeHGSNMTdwmaYMQJAKn7lxma5XnoUYDGu")
+
 func findUserByEmail(ctx context.Context, db dbtx, email string) (xone.User, bool, error) {
 	stmt, err := db.PrepareContext(ctx, `
 		SELECT
+			id,
 			email,
-			password
+			password,
+			role
 		FROM
 			users
 		WHERE
@@ -74,7 +128,7 @@ func findUserByEmail(ctx context.Context, db dbtx, email string) (xone.User, boo
 
 	user := xone.User{}
 	row := stmt.QueryRowContext(ctx, email)
-	if err := row.Scan(&user.Email, &user.Password); err != nil {
+	if err := row.Scan(&user.ID, &user.Email, &user.Password, &user.Role); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return user, false, nil
 		}
@@ -89,8 +143,10 @@ func createUser(ctx context.Context, db dbtx, data xone.CreateUserData) (xone.Us
 	stmt, err := db.PrepareContext(ctx, `
 		INSERT INTO users (
 			email,
-			password
+			password,
+			role
 		) VALUES (
+			?,
 			?,
 			?
 		)
@@ -99,10 +155,20 @@ func createUser(ctx context.Context, db dbtx, data xone.CreateUserData) (xone.Us
 		return xone.User{}, err
 	}
 
-	_, err = stmt.ExecContext(ctx, data.Email, data.Password)
+	res, err := stmt.ExecContext(ctx, data.Email, data.Password, data.Role)
+	if err != nil {
+		return xone.User{}, mapError(err)
+	}
+
+	id, err := res.LastInsertId()
 	if err != nil {
 		return xone.User{}, err
 	}
 
-	return xone.User(data), nil
+	return xone.User{
+		ID:       int(id),
+		Email:    data.Email,
+		Password: data.Password,
+		Role:     data.Role,
+	}, nil
 }
@@ -37,8 +37,10 @@ func Test_findUserByEmail(t *testing.T) {
 				email: "albus.dumbledore@hogwarts.co.uk",
 			},
 			want: xone.User{
+				ID:       1,
 				Email:    "albus.dumbledore@hogwarts.co.uk",
 				Password: "Harrydidyouputyournameinthegobletoffire",
+				Role:     xone.RoleReadOnly,
 			},
 			wantFound: true,
 			wantErr:   false,
@@ -94,11 +96,14 @@ func Test_createUser(t *testing.T) {
 				data: xone.CreateUserData{
 					Email:    "severus.snape@hogwarts.co.uk",
 					Password: "Detention!",
+					Role:     xone.RoleBoard,
 				},
 			},
 			want: xone.User{
+				ID:       2,
 				Email:    "severus.snape@hogwarts.co.uk",
 				Password: "Detention!",
+				Role:     xone.RoleBoard,
 			},
 			wantErr: false,
 		},
@@ -1,11 +1,21 @@
 package xone
 
 type User struct {
+	ID       int
 	Email    string
 	Password string
+	Role     Role
 }
 
+// Can reports whether the user's role grants the given permission.
+func (u User) Can(p Permission) bool {
+	return u.Role.Can(p)
+}
+
+// CreateUserData contains all data which is necessary to create a new user.
+// Users without a role are created with RoleReadOnly.
 type CreateUserData struct {
 	Email    string
 	Password string
+	Role     Role
 }
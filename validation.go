@@ -0,0 +1,133 @@
+package xone
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// ValidationCode identifies why the value of a field is invalid. Codes are
+// meant to be interpreted by programs, e.g. to show a translated message next
+// to a form field.
+type ValidationCode string
+
+const (
+	ValidationRequired ValidationCode = "required"
+	ValidationFormat   ValidationCode = "invalid_format"
+	ValidationInFuture ValidationCode = "in_future"
+)
+
+// FieldError describes a single invalid field. Fields are named like the
+// columns of a CSV file, e.g. "first_name" or "membership_type", see the csv
+// package.
+type FieldError struct {
+	Field string
+	Code  ValidationCode
+}
+
+// ValidationError is returned if data passed to a service is invalid. It lists
+// every invalid field, not only the first one.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fmt.Sprintf("%s: %s", fe.Field, fe.Code)
+	}
+
+	return "validation failed: " + strings.Join(msgs, ", ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return &ErrInvalid{Msg: e.Error()}
+}
+
+// Has reports whether the field is invalid for the given reason.
+func (e *ValidationError) Has(field string, code ValidationCode) bool {
+	for _, fe := range e.Errors {
+		if fe.Field == field && fe.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validator collects the errors found while validating data.
+type validator struct {
+	errs []FieldError
+}
+
+// check records an error for the field unless ok is true.
+func (v *validator) check(ok bool, field string, code ValidationCode) {
+	if !ok {
+		v.errs = append(v.errs, FieldError{Field: field, Code: code})
+	}
+}
+
+// err returns a *ValidationError if at least one check has failed.
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Errors: v.errs}
+}
+
+// Validate checks the data of a new person: both names and the membership type
+// are required, the email address must be well-formed and the date of birth
+// must not lie in the future. Custom fields are validated by the repository
+// which knows their definitions.
+func (d CreatePersonData) Validate() error {
+	var v validator
+	validatePerson(&v, d.FirstName, d.LastName, d.DateOfBirth, d.Email)
+	v.check(d.MembershipTypeID > 0, "membership_type", ValidationRequired)
+
+	return v.err()
+}
+
+// Validate checks the data of a person like CreatePersonData.Validate.
+func (d UpdatePersonData) Validate() error {
+	var v validator
+	validatePerson(&v, d.FirstName, d.LastName, d.DateOfBirth, d.Email)
+
+	return v.err()
+}
+
+// Validate checks the fields of a patch which are set like
+// CreatePersonData.Validate, i.e. names cannot be cleared.
+func (d PatchPersonData) Validate() error {
+	var v validator
+	if d.FirstName != nil {
+		v.check(strings.TrimSpace(*d.FirstName) != "", "first_name", ValidationRequired)
+	}
+	if d.LastName != nil {
+		v.check(strings.TrimSpace(*d.LastName) != "", "last_name", ValidationRequired)
+	}
+	if d.DateOfBirth != nil {
+		v.check(d.DateOfBirth.IsZero() || !d.DateOfBirth.After(time.Now()), "date_of_birth", ValidationInFuture)
+	}
+	if d.Email != nil {
+		v.check(*d.Email == "" || validEmail(*d.Email), "email", ValidationFormat)
+	}
+
+	return v.err()
+}
+
+func validatePerson(v *validator, firstName, lastName string, dob time.Time, email string) {
+	v.check(strings.TrimSpace(firstName) != "", "first_name", ValidationRequired)
+	v.check(strings.TrimSpace(lastName) != "", "last_name", ValidationRequired)
+	v.check(dob.IsZero() || !dob.After(time.Now()), "date_of_birth", ValidationInFuture)
+	v.check(email == "" || validEmail(email), "email", ValidationFormat)
+}
+
+// validEmail reports whether s is a plain email address like
+// "harry.potter@hogwarts.co.uk", without a display name or angle brackets.
+func validEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+
+	return err == nil && addr.Name == "" && addr.Address == s
+}
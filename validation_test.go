@@ -0,0 +1,105 @@
+package xone
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCreatePersonData_Validate(t *testing.T) {
+	valid := CreatePersonData{
+		FirstName:        "Harry",
+		LastName:         "Potter",
+		DateOfBirth:      time.Date(1980, time.July, 31, 0, 0, 0, 0, time.UTC),
+		Email:            "harry.potter@hogwarts.co.uk",
+		MembershipTypeID: 1,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*CreatePersonData)
+		want   []FieldError
+	}{
+		{
+			name:   "Valid",
+			modify: func(d *CreatePersonData) {},
+		},
+		{
+			name:   "Without optional data",
+			modify: func(d *CreatePersonData) { d.DateOfBirth, d.Email = time.Time{}, "" },
+		},
+		{
+			name: "Missing names and membership type",
+			modify: func(d *CreatePersonData) {
+				d.FirstName, d.LastName, d.MembershipTypeID = " ", "", 0
+			},
+			want: []FieldError{
+				{Field: "first_name", Code: ValidationRequired},
+				{Field: "last_name", Code: ValidationRequired},
+				{Field: "membership_type", Code: ValidationRequired},
+			},
+		},
+		{
+			name:   "Date of birth in the future",
+			modify: func(d *CreatePersonData) { d.DateOfBirth = time.Now().AddDate(0, 0, 1) },
+			want:   []FieldError{{Field: "date_of_birth", Code: ValidationInFuture}},
+		},
+		{
+			name:   "Email with display name",
+			modify: func(d *CreatePersonData) { d.Email = "Harry <harry.potter@hogwarts.co.uk>" },
+			want:   []FieldError{{Field: "email", Code: ValidationFormat}},
+		},
+		{
+			name:   "Email without domain",
+			modify: func(d *CreatePersonData) { d.Email = "harry.potter" },
+			want:   []FieldError{{Field: "email", Code: ValidationFormat}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := valid
+			tt.modify(&data)
+
+			err := data.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("CreatePersonData.Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("CreatePersonData.Validate() error = %v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(invalid.Errors, tt.want) {
+				t.Errorf("CreatePersonData.Validate() errors = %v, want %v", invalid.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdatePersonData_Validate(t *testing.T) {
+	err := UpdatePersonData{FirstName: "Harry"}.Validate()
+
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Errors) != 1 || !invalid.Has("last_name", ValidationRequired) {
+		t.Errorf("UpdatePersonData.Validate() error = %v, want a missing last name", err)
+	}
+}
+
+func TestPatchPersonData_Validate(t *testing.T) {
+	email := "harry.potter@hogwarts.co.uk"
+	if err := (PatchPersonData{Email: &email}).Validate(); err != nil {
+		t.Errorf("PatchPersonData.Validate() error = %v, want nil", err)
+	}
+
+	empty, invalidEmail := "", "harry.potter"
+	err := PatchPersonData{LastName: &empty, Email: &invalidEmail}.Validate()
+
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Errors) != 2 || !invalid.Has("last_name", ValidationRequired) || !invalid.Has("email", ValidationFormat) {
+		t.Errorf("PatchPersonData.Validate() error = %v, want a cleared last name and an invalid email", err)
+	}
+}
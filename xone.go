@@ -10,13 +10,64 @@ const (
 
 type PersonRepository interface {
 	FindAll(context.Context) ([]Person, error)
+	FindMany(context.Context, PersonFilter) ([]Person, int, error)
 	Find(context.Context, string) (Person, bool, error)
 	Create(context.Context, CreatePersonData) (Person, error)
+
+	// Delete moves a person to the archive. Persons with an open balance
+	// cannot be deleted, see ErrOpenBalance. It fails with an *ErrNotFound if
+	// there is no person with the given PID.
 	Delete(context.Context, string) error
+
+	// Update fails with an *ErrNotFound if there is no person with the given
+	// PID and with an *ErrStaleVersion if the person has been changed since
+	// the version named in the data.
 	Update(context.Context, string, UpdatePersonData) error
+
+	// Patch changes only the fields of a person which are set in the data.
+	// It fails like Update, but skips the version check if no version is
+	// given.
+	Patch(context.Context, string, PatchPersonData) error
+
+	// Restore brings back a deleted person from the archive. It fails with an
+	// *ErrNotFound if there is no deleted person with the given PID.
+	Restore(context.Context, string) error
+
+	// Purge removes all persons for good which have been deleted longer ago
+	// than the retention period. It returns the number of purged persons.
+	Purge(context.Context) (int, error)
+}
+
+type MembershipService interface {
+	FindAllMembershipTypes(context.Context) ([]MembershipType, error)
+	CreateMembershipType(context.Context, string) (MembershipType, error)
+
+	// FindMembership returns the membership with the given ID. The returned
+	// bool is false if there is no such membership.
+	FindMembership(context.Context, int) (Membership, bool, error)
+
+	// UpdateMembership fails with an *ErrStaleVersion if the membership has
+	// been changed since the version named in the data.
+	UpdateMembership(context.Context, int, UpdateMembershipData) error
+
+	TerminateMembership(context.Context, int, TerminateMembershipData) error
+	ReinstateMembership(context.Context, int) error
 }
 
 type UserService interface {
 	FindByEmail(context.Context, string) (User, bool, error)
 	Create(context.Context, CreateUserData) (User, error)
+
+	// Authenticate returns the user with the given email and password. The
+	// returned user does not carry the password hash.
+	Authenticate(context.Context, string, string) (User, error)
+}
+
+type SessionService interface {
+	// Create and Find return sessions whose users do not carry the password
+	// hash.
+	Create(context.Context, User) (Session, error)
+	Find(context.Context, string) (Session, bool, error)
+	Revoke(context.Context, string) error
+	RevokeAll(context.Context, int) error
 }
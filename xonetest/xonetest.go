@@ -0,0 +1,503 @@
+// Package xonetest provides a test suite which every implementation of the
+// storage services has to pass, so that the backends of xone can be used
+// interchangeably. It is meant to be called from the tests of the backends,
+// e.g. the sqlite and the postgres package.
+package xonetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stillwondering/xone"
+	"github.com/stillwondering/xone/bcrypt"
+)
+
+// Backend contains the services of an implementation under test. All of them
+// have to work on the same, initially empty, database.
+type Backend struct {
+	Persons     xone.PersonRepository
+	Users       xone.UserService
+	Memberships xone.MembershipService
+	History     xone.HistoryService
+}
+
+// Run runs the test suite. The given function is called for every test and
+// has to return services on a fresh database.
+func Run(t *testing.T, newBackend func(*testing.T) Backend) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, Backend)
+	}{
+		{name: "Users", fn: testUsers},
+		{name: "MembershipTypes", fn: testMembershipTypes},
+		{name: "Persons", fn: testPersons},
+		{name: "FindMany", fn: testFindMany},
+		{name: "Archive", fn: testArchive},
+		{name: "Memberships", fn: testMemberships},
+		{name: "History", fn: testHistory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newBackend(t))
+		})
+	}
+}
+
+func testUsers(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	if _, found, err := b.Users.FindByEmail(ctx, "albus.dumbledore@hogwarts.co.uk"); err != nil || found {
+		t.Fatalf("UserService.FindByEmail() found = %v, err = %v, want false, nil", found, err)
+	}
+
+	hash, err := bcrypt.HashFromPassword([]byte("Harrydidyouputyournameinthegobletoffire"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := b.Users.Create(ctx, xone.CreateUserData{
+		Email:    "albus.dumbledore@hogwarts.co.uk",
+		Password: string(hash),
+	})
+	if err != nil {
+		t.Fatalf("UserService.Create() error = %v", err)
+	}
+	if user.ID == 0 || user.Email != "albus.dumbledore@hogwarts.co.uk" || user.Password != string(hash) || user.Role != xone.RoleReadOnly {
+		t.Errorf("UserService.Create() = %v, want a read-only user", user)
+	}
+
+	found, ok, err := b.Users.FindByEmail(ctx, "albus.dumbledore@hogwarts.co.uk")
+	if err != nil || !ok {
+		t.Fatalf("UserService.FindByEmail() found = %v, err = %v, want true, nil", ok, err)
+	}
+	if found != user {
+		t.Errorf("UserService.FindByEmail() = %v, want %v", found, user)
+	}
+
+	tests := []struct {
+		name    string
+		data    xone.CreateUserData
+		wantErr interface{}
+	}{
+		{name: "Existing user", data: xone.CreateUserData{Email: "albus.dumbledore@hogwarts.co.uk", Password: "x"}, wantErr: new(*xone.ErrUserExists)},
+		{name: "Invalid role", data: xone.CreateUserData{Email: "severus.snape@hogwarts.co.uk", Password: "x", Role: "headmaster"}, wantErr: new(*xone.ErrInvalidRole)},
+	}
+	for _, tt := range tests {
+		if _, err := b.Users.Create(ctx, tt.data); !errors.As(err, tt.wantErr) {
+			t.Errorf("%s: UserService.Create() error = %v, want %T", tt.name, err, tt.wantErr)
+		}
+	}
+
+	admin, err := b.Users.Create(ctx, xone.CreateUserData{Email: "minerva.mcgonagall@hogwarts.co.uk", Password: "x", Role: xone.RoleAdmin})
+	if err != nil {
+		t.Fatalf("UserService.Create() error = %v", err)
+	}
+	if admin.Role != xone.RoleAdmin || admin.ID == user.ID {
+		t.Errorf("UserService.Create() = %v, want a new admin", admin)
+	}
+
+	got, err := b.Users.Authenticate(ctx, "albus.dumbledore@hogwarts.co.uk", "Harrydidyouputyournameinthegobletoffire")
+	if err != nil {
+		t.Fatalf("UserService.Authenticate() error = %v", err)
+	}
+	if want := (xone.User{ID: user.ID, Email: user.Email, Role: user.Role}); got != want {
+		t.Errorf("UserService.Authenticate() = %v, want %v without the password hash", got, want)
+	}
+
+	for _, email := range []string{"albus.dumbledore@hogwarts.co.uk", "severus.snape@hogwarts.co.uk"} {
+		var e *xone.ErrInvalidCredentials
+		if _, err := b.Users.Authenticate(ctx, email, "wrong password"); !errors.As(err, &e) {
+			t.Errorf("UserService.Authenticate(%q) error = %v, want %T", email, err, e)
+		}
+	}
+}
+
+func testMembershipTypes(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	active := mustCreateMembershipType(t, b, "active")
+	passive := mustCreateMembershipType(t, b, "passive")
+	if active.ID == 0 || active.ID == passive.ID {
+		t.Errorf("MembershipService.CreateMembershipType() IDs = %d, %d, want distinct IDs", active.ID, passive.ID)
+	}
+
+	var e *xone.ErrMembershipTypeExists
+	if _, err := b.Memberships.CreateMembershipType(ctx, "active"); !errors.As(err, &e) {
+		t.Errorf("MembershipService.CreateMembershipType() error = %v, want %T", err, e)
+	}
+
+	got, err := b.Memberships.FindAllMembershipTypes(ctx)
+	if err != nil {
+		t.Fatalf("MembershipService.FindAllMembershipTypes() error = %v", err)
+	}
+	if want := []xone.MembershipType{active, passive}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MembershipService.FindAllMembershipTypes() = %v, want %v", got, want)
+	}
+}
+
+func testPersons(t *testing.T, b Backend) {
+	ctx := context.Background()
+	mt := mustCreateMembershipType(t, b, "active")
+
+	tests := []struct {
+		name    string
+		data    xone.CreatePersonData
+		wantErr interface{}
+	}{
+		{name: "Missing name", data: xone.CreatePersonData{FirstName: "Harry", MembershipTypeID: mt.ID}, wantErr: new(*xone.ValidationError)},
+		{name: "Unknown membership type", data: xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID + 42}, wantErr: new(*xone.ErrInvalid)},
+		{name: "Unknown custom field", data: xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", MembershipTypeID: mt.ID, CustomFields: map[string]string{"house": "Gryffindor"}}, wantErr: new(*xone.ErrUnknownField)},
+	}
+	for _, tt := range tests {
+		if _, err := b.Persons.Create(ctx, tt.data); !errors.As(err, tt.wantErr) {
+			t.Errorf("%s: PersonRepository.Create() error = %v, want %T", tt.name, err, tt.wantErr)
+		}
+	}
+
+	p, err := b.Persons.Create(ctx, xone.CreatePersonData{
+		FirstName:        "Harry",
+		LastName:         "Potter",
+		DateOfBirth:      date(1980, time.July, 31),
+		Email:            "harry.potter@hogwarts.co.uk",
+		Street:           "Privet Drive",
+		HouseNumber:      "4",
+		City:             "Little Whinging",
+		MembershipTypeID: mt.ID,
+		EffectiveFrom:    date(1991, time.September, 1),
+	})
+	if err != nil {
+		t.Fatalf("PersonRepository.Create() error = %v", err)
+	}
+	if p.ID == 0 || p.PID == "" {
+		t.Errorf("PersonRepository.Create() ID = %d, PID = %q, want both to be set", p.ID, p.PID)
+	}
+	want := xone.Person{
+		ID:          p.ID,
+		PID:         p.PID,
+		FirstName:   "Harry",
+		LastName:    "Potter",
+		DateOfBirth: date(1980, time.July, 31),
+		Email:       "harry.potter@hogwarts.co.uk",
+		Street:      "Privet Drive",
+		HouseNumber: "4",
+		City:        "Little Whinging",
+		Version:     1,
+	}
+	if len(p.Memberships) == 1 {
+		want.Memberships = []xone.Membership{{ID: p.Memberships[0].ID, Type: mt, EffectiveFrom: date(1991, time.September, 1), Version: 1}}
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("PersonRepository.Create() = %v, want %v", p, want)
+	}
+
+	if got, found, err := b.Persons.Find(ctx, p.PID); err != nil || !found || !reflect.DeepEqual(got, p) {
+		t.Errorf("PersonRepository.Find() = %v, %v, %v, want %v, true, nil", got, found, err, p)
+	}
+	if _, found, err := b.Persons.Find(ctx, "unknown"); err != nil || found {
+		t.Errorf("PersonRepository.Find() found = %v, err = %v, want false, nil", found, err)
+	}
+
+	data := p.ToUpdateData()
+	data.Street = "Grimmauld Place"
+	data.HouseNumber = "12"
+	data.City = "London"
+	if err := b.Persons.Update(ctx, p.PID, data); err != nil {
+		t.Fatalf("PersonRepository.Update() error = %v", err)
+	}
+	want.Street, want.HouseNumber, want.City, want.Version = "Grimmauld Place", "12", "London", 2
+	mustFindPerson(t, b, want)
+
+	email := "harry@potter.co.uk"
+	if err := b.Persons.Patch(ctx, p.PID, xone.PatchPersonData{Version: 2, Email: &email}); err != nil {
+		t.Fatalf("PersonRepository.Patch() error = %v", err)
+	}
+	want.Email, want.Version = email, 3
+	mustFindPerson(t, b, want)
+
+	if err := b.Persons.Patch(ctx, p.PID, xone.PatchPersonData{}); err != nil {
+		t.Fatalf("PersonRepository.Patch() empty patch error = %v", err)
+	}
+	mustFindPerson(t, b, want)
+
+	invalid := ""
+	errTests := []struct {
+		name    string
+		fn      func() error
+		wantErr interface{}
+	}{
+		{name: "Update stale version", fn: func() error { return b.Persons.Update(ctx, p.PID, data) }, wantErr: new(*xone.ErrStaleVersion)},
+		{name: "Update unknown person", fn: func() error { return b.Persons.Update(ctx, "unknown", data) }, wantErr: new(*xone.ErrNotFound)},
+		{name: "Update invalid data", fn: func() error { return b.Persons.Update(ctx, p.PID, xone.UpdatePersonData{Version: 3}) }, wantErr: new(*xone.ValidationError)},
+		{name: "Patch stale version", fn: func() error { return b.Persons.Patch(ctx, p.PID, xone.PatchPersonData{Version: 2, Email: &email}) }, wantErr: new(*xone.ErrStaleVersion)},
+		{name: "Patch unknown person", fn: func() error { return b.Persons.Patch(ctx, "unknown", xone.PatchPersonData{Email: &email}) }, wantErr: new(*xone.ErrNotFound)},
+		{name: "Patch invalid data", fn: func() error { return b.Persons.Patch(ctx, p.PID, xone.PatchPersonData{LastName: &invalid}) }, wantErr: new(*xone.ValidationError)},
+	}
+	for _, tt := range errTests {
+		if err := tt.fn(); !errors.As(err, tt.wantErr) {
+			t.Errorf("%s: error = %v, want %T", tt.name, err, tt.wantErr)
+		}
+	}
+	mustFindPerson(t, b, want)
+}
+
+func testFindMany(t *testing.T, b Backend) {
+	ctx := context.Background()
+	active := mustCreateMembershipType(t, b, "active")
+	passive := mustCreateMembershipType(t, b, "passive")
+
+	harry := mustCreatePerson(t, b, xone.CreatePersonData{FirstName: "Harry", LastName: "Potter", DateOfBirth: date(1980, time.July, 31), City: "London", MembershipTypeID: active.ID, EffectiveFrom: date(1991, time.September, 1)})
+	hermione := mustCreatePerson(t, b, xone.CreatePersonData{FirstName: "Hermione", LastName: "Granger", DateOfBirth: date(1979, time.September, 19), City: "'s-Hertogenbosch", MembershipTypeID: active.ID, EffectiveFrom: date(1991, time.September, 1)})
+	ron := mustCreatePerson(t, b, xone.CreatePersonData{FirstName: "Ron", LastName: "Weasley", DateOfBirth: date(1980, time.March, 1), City: "Ottery St Catchpole", MembershipTypeID: passive.ID, EffectiveFrom: date(1991, time.September, 1)})
+
+	today := date(2000, time.January, 1)
+	tests := []struct {
+		name   string
+		filter xone.PersonFilter
+		want   []xone.Person
+		wantN  int
+	}{
+		{name: "All", filter: xone.PersonFilter{}, want: []xone.Person{harry, hermione, ron}, wantN: 3},
+		{name: "Search", filter: xone.PersonFilter{Search: "POTTER"}, want: []xone.Person{harry}, wantN: 1},
+		{name: "Search city", filter: xone.PersonFilter{Search: "catch"}, want: []xone.Person{ron}, wantN: 1},
+		{name: "Search wildcard", filter: xone.PersonFilter{Search: "%"}, want: nil, wantN: 0},
+		{name: "Membership type", filter: xone.PersonFilter{MembershipTypeID: passive.ID, Today: today}, want: []xone.Person{ron}, wantN: 1},
+		{name: "Min age", filter: xone.PersonFilter{MinAge: 20, Today: today}, want: []xone.Person{hermione}, wantN: 1},
+		{name: "Sort", filter: xone.PersonFilter{SortBy: xone.SortByLastName}, want: []xone.Person{hermione, harry, ron}, wantN: 3},
+		{name: "Sort descending", filter: xone.PersonFilter{SortBy: xone.SortByLastName, Descending: true}, want: []xone.Person{ron, harry, hermione}, wantN: 3},
+		// Text is sorted byte by byte, a collation ignoring punctuation would
+		// sort Hermione's city last.
+		{name: "Sort by city", filter: xone.PersonFilter{SortBy: xone.SortByCity}, want: []xone.Person{hermione, harry, ron}, wantN: 3},
+		{name: "Limit and offset", filter: xone.PersonFilter{SortBy: xone.SortByLastName, Limit: 1, Offset: 1}, want: []xone.Person{harry}, wantN: 3},
+		{name: "Offset", filter: xone.PersonFilter{SortBy: xone.SortByLastName, Offset: 2}, want: []xone.Person{ron}, wantN: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n, err := b.Persons.FindMany(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("PersonRepository.FindMany() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PersonRepository.FindMany() = %v, want %v", got, tt.want)
+			}
+			if n != tt.wantN {
+				t.Errorf("PersonRepository.FindMany() n = %d, want %d", n, tt.wantN)
+			}
+		})
+	}
+
+	var e *xone.ErrInvalid
+	if _, _, err := b.Persons.FindMany(ctx, xone.PersonFilter{SortBy: "wand"}); !errors.As(err, &e) {
+		t.Errorf("PersonRepository.FindMany() error = %v, want %T", err, e)
+	}
+
+	all, err := b.Persons.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("PersonRepository.FindAll() error = %v", err)
+	}
+	if want := []xone.Person{harry, hermione, ron}; !reflect.DeepEqual(all, want) {
+		t.Errorf("PersonRepository.FindAll() = %v, want %v", all, want)
+	}
+}
+
+func testArchive(t *testing.T, b Backend) {
+	ctx := context.Background()
+	mt := mustCreateMembershipType(t, b, "active")
+	p := mustCreatePerson(t, b, xone.CreatePersonData{FirstName: "Sirius", LastName: "Black", MembershipTypeID: mt.ID})
+
+	if err := b.Persons.Delete(ctx, p.PID); err != nil {
+		t.Fatalf("PersonRepository.Delete() error = %v", err)
+	}
+	if _, found, err := b.Persons.Find(ctx, p.PID); err != nil || found {
+		t.Errorf("PersonRepository.Find() found = %v, err = %v, want false, nil", found, err)
+	}
+
+	archived, n, err := b.Persons.FindMany(ctx, xone.PersonFilter{Archived: true})
+	if err != nil {
+		t.Fatalf("PersonRepository.FindMany() error = %v", err)
+	}
+	if n != 1 || len(archived) != 1 || archived[0].PID != p.PID || !archived[0].Archived() || archived[0].Version != 2 {
+		t.Errorf("PersonRepository.FindMany() = %v, %d, want the deleted person with version 2", archived, n)
+	}
+
+	if purged, err := b.Persons.Purge(ctx); err != nil || purged != 0 {
+		t.Errorf("PersonRepository.Purge() = %d, %v, want 0, nil", purged, err)
+	}
+
+	var e *xone.ErrNotFound
+	if err := b.Persons.Delete(ctx, p.PID); !errors.As(err, &e) {
+		t.Errorf("PersonRepository.Delete() deleted person error = %v, want %T", err, e)
+	}
+
+	if err := b.Persons.Restore(ctx, p.PID); err != nil {
+		t.Fatalf("PersonRepository.Restore() error = %v", err)
+	}
+	p.Version = 3
+	mustFindPerson(t, b, p)
+
+	if err := b.Persons.Restore(ctx, p.PID); !errors.As(err, &e) {
+		t.Errorf("PersonRepository.Restore() active person error = %v, want %T", err, e)
+	}
+}
+
+func testMemberships(t *testing.T, b Backend) {
+	ctx := context.Background()
+	active := mustCreateMembershipType(t, b, "active")
+	passive := mustCreateMembershipType(t, b, "passive")
+	p := mustCreatePerson(t, b, xone.CreatePersonData{FirstName: "Neville", LastName: "Longbottom", MembershipTypeID: active.ID, EffectiveFrom: date(1991, time.September, 1)})
+	if len(p.Memberships) != 1 {
+		t.Fatalf("PersonRepository.Create() memberships = %v, want one", p.Memberships)
+	}
+	m := p.Memberships[0]
+
+	data := m.ToUpdateData()
+	data.MembershipTypeID = passive.ID
+	if err := b.Memberships.UpdateMembership(ctx, m.ID, data); err != nil {
+		t.Fatalf("MembershipService.UpdateMembership() error = %v", err)
+	}
+	m.Type, m.Version = passive, 2
+	p.Memberships = []xone.Membership{m}
+	mustFindPerson(t, b, p)
+
+	if err := b.Memberships.TerminateMembership(ctx, m.ID, xone.TerminateMembershipData{EndDate: date(1998, time.June, 30), Reason: xone.TerminationResignation}); err != nil {
+		t.Fatalf("MembershipService.TerminateMembership() error = %v", err)
+	}
+	m.EndDate, m.TerminationReason, m.Version = date(1998, time.June, 30), xone.TerminationResignation, 3
+	p.Memberships = []xone.Membership{m}
+	mustFindPerson(t, b, p)
+
+	if err := b.Memberships.ReinstateMembership(ctx, m.ID); err != nil {
+		t.Fatalf("MembershipService.ReinstateMembership() error = %v", err)
+	}
+	m.EndDate, m.TerminationReason, m.Version = time.Time{}, "", 4
+	p.Memberships = []xone.Membership{m}
+	mustFindPerson(t, b, p)
+
+	tests := []struct {
+		name    string
+		fn      func() error
+		wantErr interface{}
+	}{
+		{name: "Update stale version", fn: func() error { return b.Memberships.UpdateMembership(ctx, m.ID, data) }, wantErr: new(*xone.ErrStaleVersion)},
+		{name: "Update without version", fn: func() error {
+			unversioned := data
+			unversioned.Version = 0
+			return b.Memberships.UpdateMembership(ctx, m.ID, unversioned)
+		}, wantErr: new(*xone.ErrStaleVersion)},
+		{name: "Update unknown membership", fn: func() error { return b.Memberships.UpdateMembership(ctx, m.ID+42, data) }, wantErr: new(*xone.ErrNotFound)},
+		{name: "Terminate before effective date", fn: func() error {
+			return b.Memberships.TerminateMembership(ctx, m.ID, xone.TerminateMembershipData{EndDate: date(1990, time.June, 30), Reason: xone.TerminationOther})
+		}, wantErr: new(*xone.ErrInvalid)},
+		{name: "Terminate with invalid reason", fn: func() error {
+			return b.Memberships.TerminateMembership(ctx, m.ID, xone.TerminateMembershipData{EndDate: date(1998, time.June, 30), Reason: "expelled"})
+		}, wantErr: new(*xone.ErrInvalidTerminationReason)},
+		{name: "Terminate unknown membership", fn: func() error {
+			return b.Memberships.TerminateMembership(ctx, m.ID+42, xone.TerminateMembershipData{EndDate: date(1998, time.June, 30), Reason: xone.TerminationOther})
+		}, wantErr: new(*xone.ErrNotFound)},
+		{name: "Reinstate unknown membership", fn: func() error { return b.Memberships.ReinstateMembership(ctx, m.ID+42) }, wantErr: new(*xone.ErrNotFound)},
+	}
+	for _, tt := range tests {
+		if err := tt.fn(); !errors.As(err, tt.wantErr) {
+			t.Errorf("%s: error = %v, want %T", tt.name, err, tt.wantErr)
+		}
+	}
+	mustFindPerson(t, b, p)
+}
+
+func testHistory(t *testing.T, b Backend) {
+	ctx := context.Background()
+	mt := mustCreateMembershipType(t, b, "active")
+	p := mustCreatePerson(t, b, xone.CreatePersonData{FirstName: "Luna", LastName: "Lovegood", MembershipTypeID: mt.ID, EffectiveFrom: date(1992, time.September, 1)})
+	before := time.Now().Add(-time.Minute)
+
+	email := "luna.lovegood@hogwarts.co.uk"
+	if err := b.Persons.Patch(ctx, p.PID, xone.PatchPersonData{Email: &email}); err != nil {
+		t.Fatalf("PersonRepository.Patch() error = %v", err)
+	}
+	if err := b.Memberships.TerminateMembership(ctx, p.Memberships[0].ID, xone.TerminateMembershipData{EndDate: date(1999, time.June, 30), Reason: xone.TerminationResignation}); err != nil {
+		t.Fatalf("MembershipService.TerminateMembership() error = %v", err)
+	}
+
+	timeline, err := b.History.Timeline(ctx, p.PID)
+	if err != nil {
+		t.Fatalf("HistoryService.Timeline() error = %v", err)
+	}
+	var personCreated, membershipCreated, emailChanged, terminated bool
+	for _, c := range timeline {
+		switch {
+		case c.MembershipID == 0 && c.Created:
+			personCreated = true
+		case c.MembershipID == 0:
+			emailChanged = reflect.DeepEqual(c.Fields, []xone.FieldChange{{Field: "email", New: email}})
+		case c.Created:
+			membershipCreated = c.MembershipID == p.Memberships[0].ID
+		default:
+			terminated = reflect.DeepEqual(c.Fields, []xone.FieldChange{
+				{Field: "end_date", New: "1999-06-30"},
+				{Field: "termination_reason", New: string(xone.TerminationResignation)},
+			})
+		}
+	}
+	if len(timeline) != 4 || !personCreated || !membershipCreated || !emailChanged || !terminated {
+		t.Errorf("HistoryService.Timeline() = %+v, want the creation, the email change and the termination", timeline)
+	}
+
+	if got, err := b.History.Timeline(ctx, "unknown"); err != nil || got != nil {
+		t.Errorf("HistoryService.Timeline() unknown person = %v, %v, want nil, nil", got, err)
+	}
+
+	if _, found, err := b.History.PersonAt(ctx, p.PID, before); err != nil || found {
+		t.Errorf("HistoryService.PersonAt() before creation found = %v, err = %v, want false, nil", found, err)
+	}
+	got, found, err := b.History.PersonAt(ctx, p.PID, time.Now().Add(time.Minute))
+	if err != nil || !found {
+		t.Fatalf("HistoryService.PersonAt() found = %v, err = %v, want true, nil", found, err)
+	}
+	if got.Email != email || len(got.Memberships) != 1 || got.Memberships[0].TerminationReason != xone.TerminationResignation || got.Memberships[0].Type.Name != mt.Name {
+		t.Errorf("HistoryService.PersonAt() = %+v, want the current version", got)
+	}
+}
+
+func mustCreateMembershipType(tb testing.TB, b Backend, name string) xone.MembershipType {
+	tb.Helper()
+
+	mt, err := b.Memberships.CreateMembershipType(context.Background(), name)
+	if err != nil {
+		tb.Fatalf("MembershipService.CreateMembershipType() error = %v", err)
+	}
+
+	return mt
+}
+
+func mustCreatePerson(tb testing.TB, b Backend, data xone.CreatePersonData) xone.Person {
+	tb.Helper()
+
+	p, err := b.Persons.Create(context.Background(), data)
+	if err != nil {
+		tb.Fatalf("PersonRepository.Create() error = %v", err)
+	}
+
+	return p
+}
+
+// mustFindPerson fails unless the stored person equals want.
+func mustFindPerson(tb testing.TB, b Backend, want xone.Person) {
+	tb.Helper()
+
+	got, found, err := b.Persons.Find(context.Background(), want.PID)
+	if err != nil {
+		tb.Fatalf("PersonRepository.Find() error = %v", err)
+	}
+	if !found {
+		tb.Fatalf("PersonRepository.Find() found = false, want true")
+	}
+	if !reflect.DeepEqual(got, want) {
+		tb.Errorf("PersonRepository.Find() = %v, want %v", got, want)
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}